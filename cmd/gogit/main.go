@@ -1,12 +1,42 @@
 package main
 
 import (
+	"errors"
 	"os"
+
+	"github.com/shanmugharajk/gogit/internal/commands"
+)
+
+// Exit statuses, as git uses them.
+const (
+	// exitFailure is for commands that report failure themselves, such as
+	// cat-file -e or verify-commit.
+	exitFailure = 1
+	// exitFatal is for errors that stop a command once it has started.
+	exitFatal = 128
+	// exitUsage is for commands given bad options or arguments.
+	exitUsage = 129
 )
 
 func main() {
-	cmd := NewRootCmd()
-	if err := cmd.Execute(); err != nil {
-		os.Exit(1)
+	root := NewRootCmd()
+	cmd, err := root.ExecuteC()
+	if err == nil {
+		return
+	}
+
+	var usageErr *commands.UsageError
+	switch {
+	case cmd.SilenceErrors:
+		os.Exit(exitFailure)
+	case errors.As(err, &usageErr):
+		// Found by the command after cobra's own checks passed, so the
+		// usage still has to be shown
+		cmd.PrintErr("\n" + cmd.UsageString())
+		os.Exit(exitUsage)
+	case cmd.SilenceUsage:
+		os.Exit(exitFatal)
+	default:
+		os.Exit(exitUsage)
 	}
 }
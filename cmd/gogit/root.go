@@ -1,6 +1,9 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/shanmugharajk/gogit/internal/commands"
 	"github.com/spf13/cobra"
 )
@@ -12,12 +15,68 @@ func NewRootCmd() *cobra.Command {
 		Short: "A Go implementation of Git",
 		Long: `GoGit is a comprehensive implementation of Git internals written in pure Go.
 It serves as both an educational tool and a library for working with Git repositories.`,
+		PersistentPreRunE: applyGlobalOptions,
 	}
+	// Like git, report a command used wrongly as an error with its usage;
+	// applyGlobalOptions switches to fatal errors once it starts running.
+	cmd.SetErrPrefix("error:")
+
+	cmd.PersistentFlags().StringArrayP("directory", "C", nil, "run as if started in <path>")
+	cmd.PersistentFlags().String("git-dir", "", "set the path to the repository")
+	cmd.PersistentFlags().String("work-tree", "", "set the path to the working tree")
 
 	// Add subcommands
 	cmd.AddCommand(commands.NewInitCmd())
 	cmd.AddCommand(commands.NewAddCmd())
 	cmd.AddCommand(commands.NewCommitCmd())
+	cmd.AddCommand(commands.NewPackRefsCmd())
+	cmd.AddCommand(commands.NewResetCmd())
+	cmd.AddCommand(commands.NewRmCmd())
+	cmd.AddCommand(commands.NewMvCmd())
+	cmd.AddCommand(commands.NewLsFilesCmd())
+	cmd.AddCommand(commands.NewLsTreeCmd())
+	cmd.AddCommand(commands.NewCatFileCmd())
+	cmd.AddCommand(commands.NewHashObjectCmd())
+	cmd.AddCommand(commands.NewFsckCmd())
+	cmd.AddCommand(commands.NewPruneCmd())
+	cmd.AddCommand(commands.NewGcCmd())
+	cmd.AddCommand(commands.NewCloneCmd())
+	cmd.AddCommand(commands.NewVerifyCommitCmd())
+	cmd.AddCommand(commands.NewLogCmd())
+	cmd.AddCommand(commands.NewTagCmd())
+	cmd.AddCommand(commands.NewVerifyTagCmd())
+	cmd.AddCommand(commands.NewInterpretTrailersCmd())
 
 	return cmd
 }
+
+// applyGlobalOptions changes to the directories given with -C, in order, and
+// passes --git-dir and --work-tree on through the environment, as git does,
+// so that they apply to every command.
+func applyGlobalOptions(cmd *cobra.Command, args []string) error {
+	// The flags and arguments were accepted, so from here on a failure is
+	// not a matter of usage
+	cmd.SilenceUsage = true
+	cmd.SetErrPrefix("fatal:")
+
+	dirs, _ := cmd.Flags().GetStringArray("directory")
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		if err := os.Chdir(dir); err != nil {
+			return fmt.Errorf("cannot change to '%s': %w", dir, err)
+		}
+	}
+
+	for flag, env := range map[string]string{"git-dir": "GIT_DIR", "work-tree": "GIT_WORK_TREE"} {
+		if !cmd.Flags().Changed(flag) {
+			continue
+		}
+		value, _ := cmd.Flags().GetString(flag)
+		if err := os.Setenv(env, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
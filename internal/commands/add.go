@@ -1,72 +1,74 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 
-	"github.com/shanmugharajk/gogit/internal/index"
 	"github.com/shanmugharajk/gogit/internal/object"
 	"github.com/shanmugharajk/gogit/internal/storage"
 	"github.com/shanmugharajk/gogit/internal/workspace"
+	"github.com/shanmugharajk/gogit/pkg/gogit"
 	"github.com/spf13/cobra"
 )
 
 // NewAddCmd creates the add command.
 func NewAddCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "add",
+		Use:   "add <paths>...",
 		Short: "Add file contents to the index",
 		Long: `Add file contents to the index (staging area).
-This command reads a file from the workspace, stores it as a blob object,
-and adds it to the index.`,
-		Args: cobra.ExactArgs(1),
+This command reads files from the workspace, stores them as blob objects,
+and adds them to the index. Directories are added recursively, leaving out
+files matched by .gitignore. Files are hashed in parallel; see --jobs.`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: runAdd,
 	}
 
+	addJobsFlag(cmd)
+
 	return cmd
 }
 
 func runAdd(cmd *cobra.Command, args []string) error {
-	// Get the current working directory
-	cwd, err := os.Getwd()
+	repo, ws, err := openWorkTree()
 	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+		return err
 	}
+	db := repo.Objects()
 
-	// Construct paths
-	gitPath := filepath.Join(cwd, ".git")
-	dbPath := filepath.Join(gitPath, "objects")
-	indexPath := filepath.Join(gitPath, "index")
-
-	// Initialize workspace, storage, and index
-	ws := workspace.New(cwd)
-	db := storage.New(dbPath)
-	idx := index.New(indexPath)
-
-	// Get the file path from arguments
-	path := args[0]
+	jobs, err := hashJobs(cmd, repo)
+	if err != nil {
+		return err
+	}
 
-	// Read file from workspace
-	data, err := ws.ReadFile(path)
+	// Expand the arguments to the files they name
+	paths, err := expandAddPaths(repo, ws, args)
 	if err != nil {
-		return fmt.Errorf("failed to read file %s: %w", path, err)
+		return err
 	}
 
-	// Get file stats
-	stat, err := ws.StatFile(path)
+	// Stream the files into the database as blobs
+	files, err := storeFiles(ws, db, paths, jobs)
 	if err != nil {
-		return fmt.Errorf("failed to stat file %s: %w", path, err)
+		return err
 	}
 
-	// Create blob and store it
-	blob := object.NewBlob(data)
-	if err := db.Store(blob); err != nil {
-		return fmt.Errorf("failed to store blob for %s: %w", path, err)
+	// Make the blobs durable before the index refers to them
+	if err := db.Flush(); err != nil {
+		return fmt.Errorf("failed to flush objects: %w", err)
 	}
 
-	// Add entry to index
-	idx.Add(path, blob.GetOID(), stat)
+	// Add entries to the existing index
+	idx, err := repo.LockIndex()
+	if err != nil {
+		return fmt.Errorf("failed to load index: %w", err)
+	}
+	for _, f := range files {
+		idx.Add(f.path, f.oid, f.stat)
+	}
 
 	// Write index updates
 	if err := idx.WriteUpdates(); err != nil {
@@ -75,3 +77,62 @@ func runAdd(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// expandAddPaths returns the files named by args in a stable order, with
+// directories expanded to the files beneath them that are not ignored.
+func expandAddPaths(repo *gogit.Repository, ws *workspace.Workspace, args []string) ([]string, error) {
+	ignore, err := repo.Ignore()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var paths []string
+
+	for _, arg := range args {
+		p, err := repo.WorkTreePath(arg)
+		if err != nil {
+			return nil, err
+		}
+		files, err := ws.ListFilesUnder(filepath.FromSlash(p))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("pathspec '%s' did not match any files", arg)
+			}
+			return nil, fmt.Errorf("failed to list %s: %w", arg, err)
+		}
+		isDir := len(files) != 1 || files[0] != filepath.FromSlash(p)
+
+		for _, name := range files {
+			name = filepath.ToSlash(name)
+			if isDir {
+				excluded, err := ignore.IsIgnored(name, false)
+				if err != nil {
+					return nil, err
+				}
+				if excluded {
+					continue
+				}
+			}
+			if !seen[name] {
+				seen[name] = true
+				paths = append(paths, name)
+			}
+		}
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// storeBlob streams the workspace file at path into the database as a blob
+// and returns its OID.
+func storeBlob(ws *workspace.Workspace, db storage.ObjectStore, path string) (object.ObjectID, error) {
+	f, size, err := ws.OpenFile(path)
+	if err != nil {
+		return object.ObjectID{}, err
+	}
+	defer f.Close()
+
+	return db.StoreStream("blob", size, f)
+}
@@ -0,0 +1,238 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/object"
+	"github.com/shanmugharajk/gogit/internal/refs"
+	"github.com/shanmugharajk/gogit/internal/revision"
+	"github.com/shanmugharajk/gogit/internal/storage"
+	"github.com/shanmugharajk/gogit/internal/tag"
+	"github.com/spf13/cobra"
+)
+
+// defaultBatchFormat is the header printed for each object in batch modes.
+const defaultBatchFormat = "%(objectname) %(objecttype) %(objectsize)"
+
+// NewCatFileCmd creates the cat-file command.
+func NewCatFileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cat-file (-t | -s | -e | -p | <type>) <object> | (--batch | --batch-check)[=<format>]",
+		Short: "Provide content or type and size information for repository objects",
+		Long: `Show the type (-t), size (-s) or pretty-printed contents (-p) of an object,
+check that it exists (-e, reporting only through the exit status), or print
+its raw contents when given the expected <type>.
+--batch and --batch-check read object names from standard input, one per line,
+and print a header for each (and with --batch its contents), so that tools can
+query many objects through a single process. The header format may use
+%(objectname), %(objecttype), %(objectsize) and %(rest).`,
+		RunE: runCatFile,
+	}
+
+	cmd.Flags().BoolP("type", "t", false, "show the object type")
+	cmd.Flags().BoolP("size", "s", false, "show the object size")
+	cmd.Flags().BoolP("exists", "e", false, "exit with zero status if the object exists")
+	cmd.Flags().BoolP("pretty", "p", false, "pretty-print the object's contents")
+	cmd.Flags().String("batch", "", "show info and contents of objects read from stdin")
+	cmd.Flags().String("batch-check", "", "show info about objects read from stdin")
+	cmd.Flags().Lookup("batch").NoOptDefVal = defaultBatchFormat
+	cmd.Flags().Lookup("batch-check").NoOptDefVal = defaultBatchFormat
+	cmd.MarkFlagsMutuallyExclusive("type", "size", "exists", "pretty", "batch", "batch-check")
+
+	return cmd
+}
+
+func runCatFile(cmd *cobra.Command, args []string) error {
+	showType, _ := cmd.Flags().GetBool("type")
+	showSize, _ := cmd.Flags().GetBool("size")
+	exists, _ := cmd.Flags().GetBool("exists")
+	pretty, _ := cmd.Flags().GetBool("pretty")
+
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	algo := repo.ObjectFormat()
+	db := repo.Objects()
+	refsStore := repo.Refs()
+
+	for _, name := range []string{"batch", "batch-check"} {
+		if cmd.Flags().Changed(name) {
+			if len(args) > 0 {
+				return usageErrorf("--%s takes no arguments", name)
+			}
+			format, _ := cmd.Flags().GetString(name)
+			return catFileBatch(db, refsStore, os.Stdin, os.Stdout, format, name == "batch")
+		}
+	}
+
+	expectedType := ""
+	if !showType && !showSize && !exists && !pretty {
+		if len(args) != 2 {
+			return usageErrorf("expected <type> <object>")
+		}
+		expectedType, args = args[0], args[1:]
+	}
+	if len(args) != 1 {
+		return usageErrorf("expected exactly one object")
+	}
+
+	oid, err := revision.New(refsStore, db, args[0]).Resolve()
+	if err != nil {
+		// -e reports a missing object named in full through its status
+		full, parseErr := object.ParseOID(args[0], algo)
+		if !exists || parseErr != nil {
+			return err
+		}
+		oid = full
+	}
+	obj, err := db.OpenObject(oid)
+	if err != nil {
+		if exists {
+			// A missing object is reported through the exit status alone.
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+		}
+		return err
+	}
+	defer obj.Close()
+
+	switch {
+	case exists:
+		return nil
+	case showType:
+		fmt.Println(obj.Type)
+		return nil
+	case showSize:
+		fmt.Println(obj.Size)
+		return nil
+	case pretty && obj.Type == "tree":
+		var out strings.Builder
+		if err := listTree(db, &lsTreeOptions{}, oid, "", &out); err != nil {
+			return err
+		}
+		fmt.Print(out.String())
+		return nil
+	case pretty:
+		_, err := io.Copy(os.Stdout, obj)
+		return err
+	}
+
+	// Tags are peeled, and a commit to its tree, as git does.
+	for obj.Type != expectedType {
+		switch {
+		case obj.Type == "tag":
+			loaded, err := db.Load(oid)
+			if err != nil {
+				return err
+			}
+			oid = loaded.(*tag.Tag).Object
+		case obj.Type == "commit" && expectedType == "tree":
+			c, err := db.LoadCommit(oid)
+			if err != nil {
+				return err
+			}
+			oid = c.TreeOID
+		default:
+			return fmt.Errorf("%s %s: bad file", expectedType, args[0])
+		}
+
+		obj.Close()
+		if obj, err = db.OpenObject(oid); err != nil {
+			return err
+		}
+		defer obj.Close()
+	}
+	_, err = io.Copy(os.Stdout, obj)
+	return err
+}
+
+// catFileBatch answers one query per line of input. Each line names an
+// object, optionally followed by text exposed as %(rest). Names that do not
+// resolve are reported as missing rather than ending the batch.
+func catFileBatch(db storage.ObjectStore, refsStore refs.Refs, input io.Reader, output io.Writer, format string, contents bool) error {
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	out := bufio.NewWriter(output)
+
+	for scanner.Scan() {
+		// The line is split into name and rest only when the format asks for
+		// %(rest), so that names containing spaces still work otherwise.
+		name, rest := scanner.Text(), ""
+		if strings.Contains(format, "%(rest)") {
+			name, rest, _ = strings.Cut(strings.TrimLeft(name, " \t"), " ")
+			rest = strings.TrimLeft(rest, " \t")
+		}
+
+		oid, err := revision.New(refsStore, db, name).Resolve()
+		var obj *storage.ObjectReader
+		if err == nil {
+			obj, err = db.OpenObject(oid)
+		}
+		if err != nil {
+			fmt.Fprintf(out, "%s missing\n", name)
+			if err := out.Flush(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		header, err := expandBatchFormat(format, oid, obj.Type, obj.Size, rest)
+		if err == nil {
+			fmt.Fprintf(out, "%s\n", header)
+			if contents {
+				_, err = io.Copy(out, obj)
+				out.WriteString("\n")
+			}
+		}
+		obj.Close()
+		if err != nil {
+			return err
+		}
+
+		// Flush after every object so callers can interleave queries and reads.
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// expandBatchFormat substitutes the %(atom) placeholders of a batch format.
+func expandBatchFormat(format string, oid object.ObjectID, objType string, size int64, rest string) (string, error) {
+	var b strings.Builder
+
+	for {
+		start := strings.Index(format, "%(")
+		if start < 0 {
+			b.WriteString(format)
+			break
+		}
+		end := strings.IndexByte(format[start:], ')')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated format atom in %q", format)
+		}
+		b.WriteString(format[:start])
+
+		switch atom := format[start+2 : start+end]; atom {
+		case "objectname":
+			b.WriteString(oid.String())
+		case "objecttype":
+			b.WriteString(objType)
+		case "objectsize":
+			fmt.Fprintf(&b, "%d", size)
+		case "rest":
+			b.WriteString(rest)
+		default:
+			return "", fmt.Errorf("unknown format element: %%(%s)", atom)
+		}
+		format = format[start+end+1:]
+	}
+
+	return b.String(), nil
+}
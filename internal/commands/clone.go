@@ -0,0 +1,345 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/object"
+	"github.com/shanmugharajk/gogit/internal/reachable"
+	"github.com/shanmugharajk/gogit/internal/refs"
+	"github.com/shanmugharajk/gogit/internal/storage"
+	"github.com/shanmugharajk/gogit/internal/workspace"
+	"github.com/shanmugharajk/gogit/pkg/gogit"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// originRemote is the name given to the repository a clone came from.
+	originRemote = "origin"
+	// remotesPrefix is the namespace for remote-tracking references.
+	remotesPrefix = "refs/remotes/"
+)
+
+// NewCloneCmd creates the clone command.
+func NewCloneCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clone [--shared] [--reference <repository>]... [--template=<dir>] <repository> [<directory>]",
+		Short: "Clone a local repository into a new directory",
+		Long: `Clone the repository at a local path into a new directory, create
+remote-tracking branches for its branches under refs/remotes/origin, copy its
+tags, and check out the branch its HEAD points at.
+--shared does not copy any objects; the clone reads them from the source
+through objects/info/alternates instead. The source must then outlive the
+clone, and pruning objects from it that the clone still uses, or running gc
+there after deleting a branch, corrupts the clone.
+--reference borrows objects from another local repository the same way, and
+only the objects it lacks are copied from the source.
+The new repository is created from the template directory as by init, and its
+post-checkout hook runs once the working tree is checked out.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: runClone,
+	}
+
+	cmd.Flags().BoolP("shared", "s", false, "borrow objects from the source instead of copying them")
+	cmd.Flags().StringArray("reference", nil, "borrow objects from <repository>")
+	cmd.Flags().String("template", "", "directory from which templates will be used")
+
+	return cmd
+}
+
+func runClone(cmd *cobra.Command, args []string) (err error) {
+	shared, _ := cmd.Flags().GetBool("shared")
+	references, _ := cmd.Flags().GetStringArray("reference")
+
+	srcGitPath, err := findLocalRepository(args[0])
+	if err != nil {
+		return err
+	}
+	src, err := gogit.OpenGitDir(srcGitPath, "")
+	if err != nil {
+		return err
+	}
+	srcAlgo := src.ObjectFormat()
+
+	var alternates []string
+	if shared {
+		alternates = append(alternates, filepath.Join(srcGitPath, "objects"))
+	}
+	for _, reference := range references {
+		refGitPath, err := findLocalRepository(reference)
+		if err != nil {
+			return fmt.Errorf("reference repository '%s' is not a local repository", reference)
+		}
+		refRepo, err := gogit.OpenGitDir(refGitPath, "")
+		if err != nil {
+			return err
+		}
+		refAlgo := refRepo.ObjectFormat()
+		if refAlgo != srcAlgo {
+			return fmt.Errorf("reference repository '%s' uses %s, not %s", reference, refAlgo.Name, srcAlgo.Name)
+		}
+		alternates = append(alternates, filepath.Join(refGitPath, "objects"))
+	}
+
+	dir := cloneDirName(args[0])
+	if len(args) > 1 {
+		dir = args[1]
+	}
+	rootPath, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	created, err := prepareCloneDir(rootPath, dir)
+	if err != nil {
+		return err
+	}
+	// Leave nothing half-cloned behind.
+	checkedOut := false
+	defer func() {
+		if err == nil || checkedOut {
+			return
+		}
+		if created {
+			os.RemoveAll(rootPath)
+		} else {
+			os.RemoveAll(filepath.Join(rootPath, ".git"))
+		}
+	}()
+
+	fmt.Fprintf(os.Stderr, "Cloning into '%s'...\n", dir)
+
+	global, err := openGlobalConfig()
+	if err != nil {
+		return err
+	}
+	repo, err := gogit.Init(rootPath, &gogit.InitOptions{
+		ObjectFormat: srcAlgo.Name,
+		TemplateDir:  templateDir(cmd, global),
+	})
+	if err != nil {
+		return err
+	}
+	db, err := repo.Database()
+	if err != nil {
+		return err
+	}
+	srcRefs := src.Refs()
+	if err := db.WriteAlternates(alternates); err != nil {
+		return fmt.Errorf("failed to write alternates: %w", err)
+	}
+
+	srcRefList, err := srcRefs.ListRefs()
+	if err != nil {
+		return fmt.Errorf("failed to list refs: %w", err)
+	}
+	srcHead, err := srcRefs.ReadHead()
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	headTarget, err := srcRefs.ReadSymref(refs.HeadRef)
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD: %w", err)
+	}
+
+	if !shared {
+		srcDB, err := src.Database()
+		if err != nil {
+			return err
+		}
+		if err := copyObjects(db, srcDB, srcGitPath, srcHead, srcRefList); err != nil {
+			return err
+		}
+	}
+
+	if err := writeCloneRefs(repo, srcGitPath, srcRefList, headTarget, srcHead); err != nil {
+		return err
+	}
+
+	if srcHead.IsZero() {
+		fmt.Fprintln(os.Stderr, "warning: You appear to have cloned an empty repository.")
+		return nil
+	}
+
+	head, err := db.LoadCommit(srcHead)
+	if err != nil {
+		return err
+	}
+	idx, err := repo.LockIndex()
+	if err != nil {
+		return err
+	}
+	if err := resetIndex(db, workspace.New(rootPath), idx, head.TreeOID, true); err != nil {
+		idx.Release()
+		return err
+	}
+	if err := idx.WriteUpdates(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+
+	fmt.Fprintln(os.Stderr, "done.")
+
+	// The clone is complete, so it is kept even if the hook fails.
+	checkedOut = true
+	return repo.RunHook(gogit.HookPostCheckout, &gogit.HookOptions{
+		Args: []string{object.NullOID(srcAlgo).String(), srcHead.String(), "1"},
+	})
+}
+
+// findLocalRepository returns the .git directory of the repository at path,
+// which may name a working tree, a .git directory or a bare repository.
+func findLocalRepository(path string) (string, error) {
+	path = strings.TrimPrefix(path, "file://")
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	for _, candidate := range []string{filepath.Join(abs, ".git"), abs} {
+		if isDir(filepath.Join(candidate, "objects")) && isDir(filepath.Join(candidate, refs.RefsDir)) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("repository '%s' does not exist", path)
+}
+
+// isDir reports whether path exists and is a directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// cloneDirName derives the directory a clone of source is created in, as
+// git does: the last path component without any .git suffix.
+func cloneDirName(source string) string {
+	name := filepath.Base(filepath.Clean(strings.TrimPrefix(source, "file://")))
+	if name == ".git" {
+		name = filepath.Base(filepath.Dir(filepath.Clean(source)))
+	}
+	return strings.TrimSuffix(name, ".git")
+}
+
+// prepareCloneDir checks that rootPath is missing or an empty directory and
+// creates it, reporting whether it had to be created.
+func prepareCloneDir(rootPath string, dir string) (bool, error) {
+	entries, err := os.ReadDir(rootPath)
+	if err == nil {
+		if len(entries) > 0 {
+			return false, fmt.Errorf("destination path '%s' already exists and is not an empty directory", dir)
+		}
+		return false, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	if err := os.MkdirAll(rootPath, 0755); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// copyObjects packs every object reachable from the source's HEAD and refs
+// into db, leaving out those db can already read from its alternates.
+func copyObjects(db *storage.Database, srcDB *storage.Database, srcGitPath string, srcHead object.ObjectID, srcRefList []*refs.Ref) error {
+	roots := make([]object.ObjectID, 0, len(srcRefList)+1)
+	if !srcHead.IsZero() {
+		roots = append(roots, srcHead)
+	}
+	for _, ref := range srcRefList {
+		roots = append(roots, ref.OID)
+	}
+
+	live, err := reachable.Walk(srcDB, roots)
+	if err != nil {
+		return fmt.Errorf("failed to walk source objects: %w", err)
+	}
+
+	var missing []object.ObjectID
+	for oid := range live {
+		exists, err := db.Exists(oid)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			missing = append(missing, oid)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].Compare(missing[j]) < 0
+	})
+
+	// The pack is written from the source's objects.
+	if err := db.AddAlternate(filepath.Join(srcGitPath, "objects")); err != nil {
+		return err
+	}
+	if _, err := db.WritePack(missing); err != nil {
+		return fmt.Errorf("failed to copy objects: %w", err)
+	}
+	return nil
+}
+
+// writeCloneRefs records the source's branches as remote-tracking branches
+// of origin, copies its tags, points HEAD the way the source's HEAD points
+// and configures origin and the checked-out branch.
+func writeCloneRefs(repo *gogit.Repository, srcGitPath string, srcRefList []*refs.Ref, headTarget string, srcHead object.ObjectID) error {
+	refsStore := repo.Refs()
+	message := "clone: from " + srcGitPath
+	originPrefix := remotesPrefix + originRemote + "/"
+
+	for _, ref := range srcRefList {
+		name := ""
+		if branch, ok := strings.CutPrefix(ref.Name, refs.HeadsPrefix); ok {
+			name = originPrefix + branch
+		} else if strings.HasPrefix(ref.Name, refs.TagsPrefix) {
+			name = ref.Name
+		} else {
+			continue
+		}
+		if err := refsStore.UpdateRef(name, ref.OID, message); err != nil {
+			return fmt.Errorf("failed to update %s: %w", name, err)
+		}
+	}
+
+	cfg := repo.Config()
+	cfg.Set("remote."+originRemote+".url", srcGitPath)
+	cfg.Set("remote."+originRemote+".fetch", "+"+refs.HeadsPrefix+"*:"+originPrefix+"*")
+
+	branch, onBranch := strings.CutPrefix(headTarget, refs.HeadsPrefix)
+	switch {
+	case onBranch:
+		if err := refsStore.SetSymref(refs.HeadRef, headTarget); err != nil {
+			return fmt.Errorf("failed to update HEAD: %w", err)
+		}
+		if !srcHead.IsZero() {
+			if err := refsStore.UpdateRef(headTarget, srcHead, message); err != nil {
+				return fmt.Errorf("failed to update %s: %w", headTarget, err)
+			}
+			if err := refsStore.SetSymref(originPrefix+refs.HeadRef, originPrefix+branch); err != nil {
+				return fmt.Errorf("failed to update %s: %w", originPrefix+refs.HeadRef, err)
+			}
+		}
+		cfg.Set("branch."+branch+".remote", originRemote)
+		cfg.Set("branch."+branch+".merge", headTarget)
+
+	case !srcHead.IsZero():
+		if err := refsStore.UpdateRef(refs.HeadRef, srcHead, message); err != nil {
+			return fmt.Errorf("failed to update HEAD: %w", err)
+		}
+
+	default:
+		if err := refsStore.SetSymref(refs.HeadRef, refs.HeadsPrefix+"master"); err != nil {
+			return fmt.Errorf("failed to update HEAD: %w", err)
+		}
+	}
+
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
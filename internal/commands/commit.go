@@ -8,126 +8,567 @@ import (
 	"strings"
 	"time"
 
-	"github.com/shanmugharajk/gogit/internal/commit"
+	"github.com/shanmugharajk/gogit/internal/file"
 	"github.com/shanmugharajk/gogit/internal/object"
-	"github.com/shanmugharajk/gogit/internal/refs"
-	"github.com/shanmugharajk/gogit/internal/storage"
-	"github.com/shanmugharajk/gogit/internal/workspace"
+	"github.com/shanmugharajk/gogit/pkg/gogit"
 	"github.com/spf13/cobra"
 )
 
+// commitEditMsgFile holds the message of the commit being made, for the
+// hooks and the editor that read or change it.
+const commitEditMsgFile = "COMMIT_EDITMSG"
+
+// gitDateFormat is how git prints dates by default.
+const gitDateFormat = "Mon Jan 2 15:04:05 2006 -0700"
+
+// cleanupConfigKey sets the default for --cleanup.
+const cleanupConfigKey = "commit.cleanup"
+
 // NewCommitCmd creates the commit command.
 func NewCommitCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "commit",
+		Use:   "commit [--amend [--reset-author]] [-m <msg>]... [-F <file>] [-e | --no-edit] [-s] [--trailer <token>[(=|:)<value>]]... [--cleanup=<mode>] [--allow-empty] [--allow-empty-message] [-S | --gpg-sign=<key> | --no-gpg-sign] [-n | --no-verify]",
 		Short: "Record changes to the repository",
-		Long: `Create a commit that records the current state of the workspace.
-This command reads all files in the workspace and stores them as blob objects in the database.`,
+		Long: `Create a commit that records the contents of the index, as staged with add,
+rm, mv and reset.
+The message is taken from -m, each one a paragraph, or from -F, where "-" is
+standard input. Otherwise, or with -e, it is edited in $GIT_EDITOR, core.editor,
+$VISUAL or $EDITOR, starting from a template listing the changes; --no-edit
+uses the message as it is.
+-s adds a Signed-off-by trailer for the committer and --trailer adds others,
+as interpret-trailers does, before the message is edited.
+--amend replaces the commit HEAD points at with one that has the same parents
+and author, and its message unless another is given. --reset-author makes the
+committer its author.
+--cleanup (default: commit.cleanup) is one of strip, whitespace, verbatim,
+scissors or default, which strips an edited message and only tidies the
+whitespace of others. An empty message aborts the commit unless
+--allow-empty-message is given.
+The pre-commit, prepare-commit-msg, commit-msg and post-commit hooks are run
+around the commit; --no-verify skips pre-commit and commit-msg.
+A commit that changes nothing is refused unless --allow-empty is given.
+-S, or commit.gpgSign, signs the commit with the SSH key file named by
+user.signingKey; --gpg-sign=<key> signs it with the key file given instead.
+gpg.format must be ssh.`,
 		RunE: runCommit,
 	}
 
+	cmd.Flags().StringArrayP("message", "m", nil, "use the given message as a paragraph of the commit message")
+	cmd.Flags().StringP("file", "F", "", "read the commit message from the given file, or - for standard input")
+	cmd.Flags().BoolP("edit", "e", false, "edit the message given with -m or -F")
+	cmd.Flags().Bool("no-edit", false, "use the message without launching an editor")
+	cmd.Flags().Bool("amend", false, "replace the commit HEAD points at")
+	cmd.Flags().BoolP("signoff", "s", false, "add a Signed-off-by trailer for the committer")
+	cmd.Flags().StringArray("trailer", nil, "add the given trailer to the message")
+	cmd.Flags().Bool("reset-author", false, "with --amend, take the author from the committer")
+	cmd.Flags().String("cleanup", "", "how to clean up the message: strip, whitespace, verbatim, scissors or default")
+	cmd.Flags().Bool("allow-empty", false, "allow a commit that changes nothing")
+	cmd.Flags().Bool("allow-empty-message", false, "allow a commit with an empty message")
+	cmd.Flags().BoolP("no-verify", "n", false, "bypass the pre-commit and commit-msg hooks")
+	addSignFlags(cmd)
+
 	return cmd
 }
 
-func runCommit(cmd *cobra.Command, args []string) error {
-	// Get the current working directory
-	cwd, err := os.Getwd()
+// commitMessage describes where the message of a new commit comes from and
+// how it is finished.
+type commitMessage struct {
+	// text is the message before editing.
+	text string
+	// source tells the prepare-commit-msg hook where text came from, with
+	// the commit it came from when amending. It is empty when there was no
+	// text.
+	source []string
+	// edit launches the editor on the message.
+	edit bool
+
+	cleanup    gogit.CleanupMode
+	allowEmpty bool
+	noVerify   bool
+	env        []string
+}
+
+// readCommitMessage gathers the message options given to commit. The
+// message of amended, when not nil, is used unless another is given.
+func readCommitMessage(cmd *cobra.Command, repo *gogit.Repository, amended *gogit.Commit) (*commitMessage, error) {
+	messages, _ := cmd.Flags().GetStringArray("message")
+	path, _ := cmd.Flags().GetString("file")
+	edit, _ := cmd.Flags().GetBool("edit")
+	noEdit, _ := cmd.Flags().GetBool("no-edit")
+	msg := &commitMessage{}
+	msg.allowEmpty, _ = cmd.Flags().GetBool("allow-empty-message")
+	msg.noVerify, _ = cmd.Flags().GetBool("no-verify")
+
+	mode, _ := cmd.Flags().GetString("cleanup")
+	ok := cmd.Flags().Changed("cleanup")
+	if !ok {
+		var err error
+		if mode, ok, err = lookupConfig(repo, cleanupConfigKey); err != nil {
+			return nil, err
+		}
+	}
+	if ok {
+		var err error
+		if msg.cleanup, err = gogit.ParseCleanupMode(mode); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case cmd.Flags().Changed("message") && cmd.Flags().Changed("file"):
+		return nil, usageErrorf("options '-m' and '-F' cannot be used together")
+	case cmd.Flags().Changed("message"):
+		var b strings.Builder
+		for i, m := range messages {
+			if i > 0 {
+				b.WriteString("\n\n")
+			}
+			b.WriteString(strings.TrimRight(m, "\n"))
+		}
+		b.WriteString("\n")
+		msg.text, msg.source = b.String(), []string{"message"}
+	case cmd.Flags().Changed("file"):
+		var data []byte
+		var err error
+		if path == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read log file '%s': %w", path, err)
+		}
+		msg.text, msg.source = string(data), []string{"message"}
+	case amended != nil:
+		msg.text, msg.source = amended.Message, []string{"commit", amended.GetOID().String()}
+		edit = edit || !noEdit
+	default:
+		edit = edit || !noEdit
+	}
+	if edit && noEdit {
+		return nil, usageErrorf("options '--edit' and '--no-edit' cannot be used together")
+	}
+	msg.edit = edit
+
+	if err := addCommitTrailers(cmd, repo, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// addCommitTrailers adds the trailers of -s and --trailer to the message.
+// Like git, --signoff does not repeat a sign-off that already ends the
+// trailers.
+func addCommitTrailers(cmd *cobra.Command, repo *gogit.Repository, msg *commitMessage) error {
+	signoff, _ := cmd.Flags().GetBool("signoff")
+	trailers, _ := cmd.Flags().GetStringArray("trailer")
+	if !signoff && len(trailers) == 0 {
+		return nil
+	}
+
+	var edits []gogit.TrailerEdit
+	if signoff {
+		name, email := os.Getenv("GIT_COMMITTER_NAME"), os.Getenv("GIT_COMMITTER_EMAIL")
+		if name == "" && email == "" {
+			name, email = os.Getenv("GIT_AUTHOR_NAME"), os.Getenv("GIT_AUTHOR_EMAIL")
+		}
+		edits = append(edits, gogit.TrailerEdit{
+			Trailer:  gogit.Trailer{Key: gogit.SignedOffBy, Value: fmt.Sprintf("%s <%s>", name, email)},
+			Where:    gogit.TrailerEnd,
+			IfExists: gogit.TrailerAddIfDifferentNeighbor,
+		})
+	}
+
+	defaults, err := readTrailerDefaults(func(key string) (string, bool, error) {
+		return lookupConfig(repo, key)
+	})
+	if err != nil {
+		return err
+	}
+	more, err := defaults.edits(trailers)
 	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+		return err
 	}
+	b := gogit.ParseTrailerBlock(msg.text, &gogit.TrailerParseOptions{Separators: defaults.separators})
+	b.Apply(append(edits, more...))
+	msg.text = b.String()
+	return nil
+}
 
-	// Construct paths
-	gitPath := filepath.Join(cwd, ".git")
-	dbPath := filepath.Join(gitPath, "objects")
+func runCommit(cmd *cobra.Command, args []string) error {
+	repo, _, err := openWorkTree()
+	if err != nil {
+		return err
+	}
+	db := repo.Objects()
 
-	// Initialize workspace, storage, and refs management
-	workspace := workspace.New(cwd)
-	db := storage.New(dbPath)
-	refsStore := refs.New(gitPath)
+	amend, _ := cmd.Flags().GetBool("amend")
+	resetAuthor, _ := cmd.Flags().GetBool("reset-author")
+	if resetAuthor && !amend {
+		return usageErrorf("--reset-author can be used only with --amend")
+	}
 
-	parentOID, err := refsStore.ReadHead()
+	head, err := repo.Head()
 	if err != nil {
-		return fmt.Errorf("failed to read HEAD: %w", err)
+		return err
+	}
+	parent := head
+	var amended *gogit.Commit
+	if amend {
+		if head.IsZero() {
+			return fmt.Errorf("you have nothing to amend")
+		}
+		if amended, err = db.LoadCommit(head); err != nil {
+			return err
+		}
+		parent = amended.ParentOID()
 	}
 
-	// List all files in the workspace
-	files, err := workspace.ListFiles()
+	msg, err := readCommitMessage(cmd, repo, amended)
 	if err != nil {
-		return fmt.Errorf("failed to list workspace files: %w", err)
+		return err
+	}
+	signer, err := commitSigner(cmd, repo)
+	if err != nil {
+		return err
+	}
+
+	hookEnv := []string{"GIT_INDEX_FILE=" + filepath.Join(repo.GitDir(), "index")}
+	msg.env = hookEnv
+	if !msg.noVerify {
+		if err := repo.RunHook(gogit.HookPreCommit, &gogit.HookOptions{Env: hookEnv}); err != nil {
+			return err
+		}
 	}
 
-	entries := make([]*object.Entry, 0, len(files))
+	// The commit records the index, as staged by add, rm and reset
+	tree, err := repo.WriteTree()
+	if err != nil {
+		return err
+	}
 
-	// Store each file as a blob object
-	for _, filePath := range files {
-		data, err := workspace.ReadFile(filePath)
+	var parentTree object.ObjectID
+	if !parent.IsZero() {
+		parentCommit, err := db.LoadCommit(parent)
 		if err != nil {
-			return fmt.Errorf("failed to read file %s: %w", filePath, err)
+			return err
+		}
+		parentTree = parentCommit.TreeOID
+	}
+	stats, err := repo.DiffStat(parentTree, tree)
+	if err != nil {
+		return err
+	}
+	allowEmpty, _ := cmd.Flags().GetBool("allow-empty")
+	if len(stats) == 0 && !allowEmpty {
+		if amended != nil {
+			return fmt.Errorf("You asked to amend the most recent commit, but doing so would make\n" +
+				"it empty. You can repeat your command with --allow-empty, or you can\n" +
+				"remove the commit entirely with \"git reset HEAD^\".")
+		}
+		if err := printNothingToCommit(repo, parent.IsZero()); err != nil {
+			return err
 		}
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		return fmt.Errorf("nothing to commit")
+	}
 
-		// Create a blob object and store it
-		blob := object.NewBlob(data)
-		if err := db.Store(blob); err != nil {
-			return fmt.Errorf("failed to store blob for %s: %w", filePath, err)
+	var template string
+	if msg.edit {
+		if template, err = commitTemplate(repo, msg.cleanup, amended, parent.IsZero(), stats); err != nil {
+			return err
 		}
+	}
+	message, err := finishMessage(repo, msg, template)
+	if err != nil {
+		return err
+	}
 
-		// Get file stats
-		stat, err := workspace.StatFile(filePath)
-		if err != nil {
-			return fmt.Errorf("failed to stat file %s: %w", filePath, err)
+	// Create the commit and move HEAD to it
+	opts := gogit.CommitOptions{
+		Message: message,
+		Tree:    tree,
+		Amend:   amended,
+		Signer:  signer,
+	}
+	if resetAuthor {
+		opts.Author = gogit.NewSignature(os.Getenv("GIT_AUTHOR_NAME"), os.Getenv("GIT_AUTHOR_EMAIL"), time.Now())
+	}
+	commitObj, err := repo.CreateCommit(opts)
+	if err != nil {
+		return err
+	}
+
+	if err := printCommitSummary(repo, commitObj, amended != nil, stats); err != nil {
+		return err
+	}
+
+	// The commit is made, so a failing post-commit hook changes nothing.
+	repo.RunHook(gogit.HookPostCommit, &gogit.HookOptions{Env: hookEnv})
+
+	return nil
+}
+
+// finishMessage writes the message, followed by template when it is to be
+// edited, to COMMIT_EDITMSG. The prepare-commit-msg hook, the editor and,
+// unless noVerify, the commit-msg hook may then change it before it is read
+// back and cleaned up.
+func finishMessage(repo *gogit.Repository, msg *commitMessage, template string) (string, error) {
+	path := filepath.Join(repo.GitDir(), commitEditMsgFile)
+	text := msg.text
+	if msg.edit {
+		text += "\n" + template
+	}
+	if err := os.WriteFile(path, []byte(text), file.ModeFile); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", commitEditMsgFile, err)
+	}
+
+	hookArgs := append([]string{path}, msg.source...)
+	if err := repo.RunHook(gogit.HookPrepareCommitMsg, &gogit.HookOptions{Args: hookArgs, Env: msg.env}); err != nil {
+		return "", err
+	}
+	if msg.edit {
+		if err := launchEditor(repo, path); err != nil {
+			return "", fmt.Errorf("%w\nPlease supply the message using either -m or -F option.", err)
+		}
+	}
+	if !msg.noVerify {
+		if err := repo.RunHook(gogit.HookCommitMsg, &gogit.HookOptions{Args: []string{path}, Env: msg.env}); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", commitEditMsgFile, err)
+	}
+	message := gogit.CleanupMessage(string(data), msg.cleanup, msg.edit)
+	if strings.TrimSpace(message) == "" && !msg.allowEmpty {
+		return "", fmt.Errorf("aborting commit due to empty commit message")
+	}
+	return message, nil
+}
+
+// commitTemplate returns the comment lines appended to a message being
+// edited: how it will be cleaned up, the date of the commit being amended,
+// if any, the branch, and the changes the commit makes to its parent.
+func commitTemplate(repo *gogit.Repository, cleanup gogit.CleanupMode, amended *gogit.Commit, initial bool, changes []gogit.FileStat) (string, error) {
+	var b strings.Builder
+	comment := func(format string, args ...any) {
+		line := fmt.Sprintf(format, args...)
+		if line == "" {
+			fmt.Fprintf(&b, "%c\n", gogit.CommentChar)
+		} else {
+			fmt.Fprintf(&b, "%c %s\n", gogit.CommentChar, line)
 		}
+	}
 
-		entries = append(entries, object.NewEntry(filePath, blob.GetOID(), stat))
+	switch cleanup.Resolve(true) {
+	case gogit.CleanupStrip:
+		comment("Please enter the commit message for your changes. Lines starting")
+		comment("with '%c' will be ignored, and an empty message aborts the commit.", gogit.CommentChar)
+	case gogit.CleanupScissors:
+		b.WriteString(gogit.ScissorsLine + "\n")
+		comment("Do not modify or remove the line above.")
+		comment("Everything below it will be ignored.")
+	default:
+		comment("Please enter the commit message for your changes. Lines starting")
+		comment("with '%c' will be kept; you may remove them yourself if you want to.", gogit.CommentChar)
+		comment("An empty message aborts the commit.")
+	}
+	comment("")
+	if amended != nil {
+		comment("Date:      %s", amended.Author.Time.Format(gitDateFormat))
+		comment("")
 	}
 
-	// Build tree hierarchy
-	root := object.Build(entries)
+	where, err := branchStatus(repo)
+	if err != nil {
+		return "", err
+	}
+	comment("%s", where)
+	if initial {
+		comment("")
+		comment("Initial commit")
+		comment("")
+	}
 
-	// Traverse and store all trees
-	var storeErr error
-	root.Traverse(func(tree *object.Tree) {
-		if storeErr != nil {
-			return // Skip if we already encountered an error
+	if len(changes) == 0 {
+		comment("No changes")
+	} else {
+		comment("Changes to be committed:")
+		for _, change := range changes {
+			fmt.Fprintf(&b, "%c\t%-12s%s\n", gogit.CommentChar, change.Kind.String()+":", change.Path)
 		}
-		if err := db.Store(tree); err != nil {
-			storeErr = fmt.Errorf("failed to store tree: %w", err)
+		comment("")
+	}
+
+	status, err := repo.Status()
+	if err != nil {
+		return "", err
+	}
+	writeWorkTreeStatus(&b, status, string(gogit.CommentChar), false)
+	return b.String(), nil
+}
+
+// writeWorkTreeStatus writes the sections of git status listing changes
+// not staged for commit and untracked files, each line after prefix, and
+// with hints on what to do about them when hints is set.
+func writeWorkTreeStatus(w io.Writer, status *gogit.Status, prefix string, hints bool) {
+	line := func(text string) {
+		switch {
+		case text == "" || prefix == "" || text[0] == '\t':
+			fmt.Fprintf(w, "%s%s\n", prefix, text)
+		default:
+			fmt.Fprintf(w, "%s %s\n", prefix, text)
 		}
-	})
-	if storeErr != nil {
-		return storeErr
 	}
 
-	// Get author info from environment
-	name := os.Getenv("GIT_AUTHOR_NAME")
-	email := os.Getenv("GIT_AUTHOR_EMAIL")
-	author := commit.NewAuthor(name, email, time.Now())
+	if len(status.Unstaged) > 0 {
+		line("Changes not staged for commit:")
+		if hints {
+			add := "git add"
+			for _, change := range status.Unstaged {
+				if change.Kind == gogit.Deleted {
+					add = "git add/rm"
+				}
+			}
+			line(fmt.Sprintf(`  (use "%s <file>..." to update what will be committed)`, add))
+			line(`  (use "git restore <file>..." to discard changes in working directory)`)
+		}
+		for _, change := range status.Unstaged {
+			line(fmt.Sprintf("\t%-12s%s", change.Kind.String()+":", change.Path))
+		}
+		line("")
+	}
+	if len(status.Untracked) > 0 {
+		line("Untracked files:")
+		if hints {
+			line(`  (use "git add <file>..." to include in what will be committed)`)
+		}
+		for _, path := range status.Untracked {
+			line("\t" + path)
+		}
+		line("")
+	}
+}
+
+// branchStatus describes where HEAD is, as git status does.
+func branchStatus(repo *gogit.Repository) (string, error) {
+	branch, err := repo.CurrentBranch()
+	if err != nil {
+		return "", err
+	}
+	if branch != "" {
+		return "On branch " + branch, nil
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	return "HEAD detached at " + head.Short(), nil
+}
 
-	// Read commit message from stdin
-	messageBytes, err := io.ReadAll(os.Stdin)
+// printNothingToCommit explains, as git status does, that a commit would
+// change nothing, listing the changes that could be staged. initial is set
+// when there is no commit yet.
+func printNothingToCommit(repo *gogit.Repository, initial bool) error {
+	where, err := branchStatus(repo)
 	if err != nil {
-		return fmt.Errorf("failed to read commit message: %w", err)
+		return err
+	}
+	status, err := repo.Status()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(where)
+	if initial {
+		fmt.Println()
+		fmt.Println("Initial commit")
+		fmt.Println()
 	}
-	message := string(messageBytes)
+	writeWorkTreeStatus(os.Stdout, status, "", true)
+	switch {
+	case len(status.Unstaged) > 0:
+		fmt.Println(`no changes added to commit (use "git add" and/or "git commit -a")`)
+	case len(status.Untracked) > 0:
+		fmt.Println(`nothing added to commit but untracked files present (use "git add" to track)`)
+	case initial:
+		fmt.Println(`nothing to commit (create/copy files and use "git add" to track)`)
+	default:
+		fmt.Println("nothing to commit, working tree clean")
+	}
+	return nil
+}
 
-	// Create and store commit
-	commitObj := commit.NewCommit(parentOID, root.GetOID(), author, message)
-	if err := db.Store(commitObj); err != nil {
-		return fmt.Errorf("failed to store commit: %w", err)
+// printCommitSummary reports a new commit as git does: the branch and
+// abbreviated OID with the title, the author when someone else is
+// committing and the date when amending, then, unless nothing changed, how
+// many files and lines changed and which files were created or deleted or
+// changed mode.
+func printCommitSummary(repo *gogit.Repository, c *gogit.Commit, amend bool, stats []gogit.FileStat) error {
+	branch, err := repo.CurrentBranch()
+	if err != nil {
+		return err
 	}
+	label := branch
+	if branch == "" {
+		label = "detached HEAD"
+	}
+	if len(c.Parents) == 0 {
+		label += " (root-commit)"
+	}
+	fmt.Printf("[%s %s] %s\n", label, c.GetOID().Short(), c.Title())
 
-	if err := refsStore.UpdateHead(commitObj.GetOID()); err != nil {
-		return fmt.Errorf("failed to update HEAD: %w", err)
+	if c.Committer != nil && (c.Author.Name != c.Committer.Name || c.Author.Email != c.Committer.Email) {
+		fmt.Printf(" Author: %s <%s>\n", c.Author.Name, c.Author.Email)
+	}
+	if amend {
+		fmt.Printf(" Date: %s\n", c.Author.Time.Format(gitDateFormat))
 	}
 
-	firstLine := message
-	if i := strings.IndexByte(message, '\n'); i >= 0 {
-		firstLine = message[:i]
+	if len(stats) == 0 {
+		return nil
 	}
-	label := ""
-	if parentOID == "" {
-		label = "(root-commit)"
+	insertions, deletions := 0, 0
+	for _, stat := range stats {
+		insertions += stat.Insertions
+		deletions += stat.Deletions
 	}
-	fmt.Printf("[%s %s] %s\n", label, commitObj.GetOID(), firstLine)
+	fmt.Println(shortStat(len(stats), insertions, deletions))
 
+	for _, stat := range stats {
+		switch {
+		case stat.Kind == gogit.Added:
+			fmt.Printf(" create mode %s %s\n", stat.NewMode, stat.Path)
+		case stat.Kind == gogit.Deleted:
+			fmt.Printf(" delete mode %s %s\n", stat.OldMode, stat.Path)
+		case stat.OldMode != stat.NewMode:
+			fmt.Printf(" mode change %s => %s %s\n", stat.OldMode, stat.NewMode, stat.Path)
+		}
+	}
 	return nil
 }
+
+// shortStat formats the totals of a diff as git diff --shortstat does.
+func shortStat(files int, insertions int, deletions int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, " %d %s changed", files, plural(files, "file", "files"))
+	if insertions > 0 || deletions == 0 {
+		fmt.Fprintf(&b, ", %d %s(+)", insertions, plural(insertions, "insertion", "insertions"))
+	}
+	if deletions > 0 || insertions == 0 {
+		fmt.Fprintf(&b, ", %d %s(-)", deletions, plural(deletions, "deletion", "deletions"))
+	}
+	return b.String()
+}
+
+// plural picks the singular or plural form for n.
+func plural(n int, one string, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/shanmugharajk/gogit/pkg/gogit"
+)
+
+// defaultEditor is used when no editor is configured.
+const defaultEditor = "vi"
+
+// lookupConfig returns key from the repository's config, falling back to
+// the user's global config.
+func lookupConfig(repo *gogit.Repository, key string) (string, bool, error) {
+	if value, ok := repo.Config().Get(key); ok {
+		return value, true, nil
+	}
+	global, err := openGlobalConfig()
+	if err != nil {
+		return "", false, err
+	}
+	value, ok := global.Get(key)
+	return value, ok, nil
+}
+
+// editor returns the command used to edit messages: GIT_EDITOR, then
+// core.editor, then VISUAL unless the terminal is dumb, then EDITOR.
+func editor(repo *gogit.Repository) (string, error) {
+	if value := os.Getenv("GIT_EDITOR"); value != "" {
+		return value, nil
+	}
+	value, ok, err := lookupConfig(repo, "core.editor")
+	if err != nil {
+		return "", err
+	}
+	if ok && value != "" {
+		return value, nil
+	}
+
+	dumb := os.Getenv("TERM") == "" || os.Getenv("TERM") == "dumb"
+	if value := os.Getenv("VISUAL"); value != "" && !dumb {
+		return value, nil
+	}
+	if value := os.Getenv("EDITOR"); value != "" {
+		return value, nil
+	}
+	if dumb {
+		return "", errors.New("terminal is dumb, but EDITOR unset")
+	}
+	return defaultEditor, nil
+}
+
+// launchEditor opens path in the user's editor and waits for it to exit.
+// The editor is run by the shell so that it may carry arguments.
+func launchEditor(repo *gogit.Repository, path string) error {
+	name, err := editor(repo)
+	if err != nil {
+		return err
+	}
+	if name == ":" {
+		return nil
+	}
+
+	cmd := exec.Command("sh", "-c", name+` "$@"`, name, path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("there was a problem with the editor '%s'", name)
+	}
+	return nil
+}
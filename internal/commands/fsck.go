@@ -0,0 +1,616 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/object"
+	"github.com/shanmugharajk/gogit/internal/refs"
+	"github.com/shanmugharajk/gogit/internal/storage"
+	"github.com/shanmugharajk/gogit/pkg/gogit"
+	"github.com/spf13/cobra"
+)
+
+// NewFsckCmd creates the fsck command.
+func NewFsckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fsck [--unreachable] [--no-dangling] [--no-reflogs]",
+		Short: "Verify the connectivity and validity of the objects in the database",
+		Long: `Check the integrity of the repository. Every loose and packed object is
+inflated and hashed again, trees are checked for ordering and entry modes, and
+commit and tag headers for their syntax. Starting from HEAD, all refs, their
+reflogs and the index, fsck then walks the object graph to find missing
+objects and objects nothing refers to, which are reported as dangling.
+The checksums of the index and of every packfile are verified as well.
+Objects borrowed from alternates count as present but are not checked.
+Errors make fsck exit with a non-zero status; dangling objects do not.`,
+		Args: cobra.NoArgs,
+		RunE: runFsck,
+	}
+
+	cmd.Flags().Bool("unreachable", false, "show all unreachable objects, not only dangling ones")
+	cmd.Flags().Bool("no-dangling", false, "do not report dangling objects")
+	cmd.Flags().Bool("no-reflogs", false, "do not treat reflog entries as reachable")
+
+	return cmd
+}
+
+// fsckLink is a reference from one object to another, with the type the
+// referring object expects the target to have.
+type fsckLink struct {
+	oid     object.ObjectID
+	objType string
+}
+
+// fsckChecker accumulates what fsck learns about the object database.
+type fsckChecker struct {
+	db *storage.Database
+
+	// types holds the type of every object found in the database.
+	types map[object.ObjectID]string
+	// links holds the references out of every object that parsed.
+	links map[object.ObjectID][]fsckLink
+	// referenced marks objects that another object refers to.
+	referenced map[object.ObjectID]bool
+
+	failed bool
+}
+
+func runFsck(cmd *cobra.Command, args []string) error {
+	unreachable, _ := cmd.Flags().GetBool("unreachable")
+	noDangling, _ := cmd.Flags().GetBool("no-dangling")
+	noReflogs, _ := cmd.Flags().GetBool("no-reflogs")
+
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	db, err := repo.Database()
+	if err != nil {
+		return err
+	}
+
+	c := &fsckChecker{
+		db:         db,
+		types:      make(map[object.ObjectID]string),
+		links:      make(map[object.ObjectID][]fsckLink),
+		referenced: make(map[object.ObjectID]bool),
+	}
+
+	if err := c.checkObjects(); err != nil {
+		return err
+	}
+	c.checkLinks()
+
+	roots, err := c.collectRoots(repo, !noReflogs)
+	if err != nil {
+		return err
+	}
+	reachable := c.walk(roots)
+
+	if !noDangling || unreachable {
+		c.reportUnreachable(reachable, unreachable)
+	}
+
+	if c.failed {
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		return fmt.Errorf("fsck found errors")
+	}
+	return nil
+}
+
+// errorf reports a problem that makes fsck fail.
+func (c *fsckChecker) errorf(format string, args ...any) {
+	c.failed = true
+	fmt.Fprintf(os.Stderr, "error: "+format+"\n", args...)
+}
+
+// report prints a finding about an object, named by one of git's fsck
+// message IDs. Errors make fsck fail; warnings do not.
+func (c *fsckChecker) report(severity string, objType string, oid object.ObjectID, msgID string, detail string) {
+	if severity == "error" {
+		c.failed = true
+	}
+	fmt.Fprintf(os.Stderr, "%s in %s %s: %s: %s\n", severity, objType, oid, msgID, detail)
+}
+
+// checkObjects verifies every loose and packed object and every packfile.
+func (c *fsckChecker) checkObjects() error {
+	loose, err := c.db.LooseObjects()
+	if err != nil {
+		return fmt.Errorf("failed to list loose objects: %w", err)
+	}
+	for _, oid := range loose {
+		c.checkLoose(oid)
+	}
+
+	problems, err := c.db.VerifyPacks()
+	if err != nil {
+		return fmt.Errorf("failed to read packs: %w", err)
+	}
+	for _, problem := range problems {
+		c.errorf("%v", problem)
+	}
+
+	packed, err := c.db.PackedObjects()
+	if err != nil {
+		return fmt.Errorf("failed to read packs: %w", err)
+	}
+	for _, oid := range packed {
+		objType, data, err := c.db.ReadPackedObject(oid)
+		if err != nil {
+			c.errorf("%s: unable to read packed object: %v", oid, err)
+			continue
+		}
+		c.checkContents(oid, objType, data)
+	}
+
+	return nil
+}
+
+// checkLoose verifies a loose object. Blobs are hashed as they are read so
+// that large files are not held in memory.
+func (c *fsckChecker) checkLoose(oid object.ObjectID) {
+	obj, err := c.db.OpenObject(oid)
+	if err != nil {
+		c.errorf("%s: object corrupt or missing: %v", oid, err)
+		return
+	}
+	defer obj.Close()
+
+	if obj.Type == "blob" {
+		actual, err := c.db.HashStream(obj.Type, obj.Size, obj)
+		if err != nil {
+			c.errorf("%s: object corrupt: %v", oid, err)
+			return
+		}
+		if actual != oid {
+			c.errorf("%s: hash mismatch, contents hash to %s", oid, actual)
+			return
+		}
+		c.types[oid] = obj.Type
+		return
+	}
+
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		c.errorf("%s: object corrupt: %v", oid, err)
+		return
+	}
+	if int64(len(data)) != obj.Size {
+		c.errorf("%s: object corrupt: header says %d bytes, found %d", oid, obj.Size, len(data))
+		return
+	}
+	c.checkContents(oid, obj.Type, data)
+}
+
+// checkContents verifies the hash and syntax of an object read in full.
+func (c *fsckChecker) checkContents(oid object.ObjectID, objType string, data []byte) {
+	actual, err := c.db.HashStream(objType, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		c.errorf("%s: object corrupt: %v", oid, err)
+		return
+	}
+	if actual != oid {
+		c.errorf("%s: hash mismatch, contents hash to %s", oid, actual)
+		return
+	}
+
+	if prev, ok := c.types[oid]; ok && prev != objType {
+		c.errorf("%s: stored both as a %s and a %s", oid, prev, objType)
+	}
+	c.types[oid] = objType
+
+	switch objType {
+	case "blob":
+	case "tree":
+		c.checkTree(oid, data)
+	case "commit":
+		c.checkCommit(oid, data)
+	case "tag":
+		c.checkTag(oid, data)
+	default:
+		c.errorf("%s: unknown object type %s", oid, objType)
+	}
+}
+
+// checkTree validates entry modes, names and ordering.
+func (c *fsckChecker) checkTree(oid object.ObjectID, data []byte) {
+	entries, err := object.ReadTreeEntries(data, c.db.Algorithm())
+	if err != nil {
+		c.report("error", "tree", oid, "badTree", err.Error())
+		return
+	}
+
+	// Each problem is reported once per tree, however many entries have it.
+	reported := make(map[string]bool)
+	report := func(severity string, msgID string, detail string) {
+		if !reported[msgID] {
+			reported[msgID] = true
+			c.report(severity, "tree", oid, msgID, detail)
+		}
+	}
+
+	var links []fsckLink
+	seen := make(map[string]bool)
+	prevKey := ""
+
+	for i, entry := range entries {
+		name := entry.Basename()
+		mode := entry.Mode()
+
+		switch {
+		case name == "":
+			report("warning", "emptyName", "contains empty pathname")
+		case strings.Contains(name, "/"):
+			report("warning", "fullPathname", "contains full pathnames")
+		case name == ".":
+			report("warning", "hasDot", "contains '.'")
+		case name == "..":
+			report("warning", "hasDotdot", "contains '..'")
+		case strings.EqualFold(name, ".git"):
+			report("warning", "hasDotgit", "contains '.git'")
+		}
+
+		if strings.HasPrefix(mode, "0") {
+			report("warning", "zeroPaddedFilemode", "contains zero-padded file modes")
+			mode = strings.TrimLeft(mode, "0")
+		}
+
+		switch mode {
+		case object.DirectoryMode:
+			links = append(links, fsckLink{oid: entry.OID, objType: "tree"})
+		case object.GitlinkMode:
+			// Submodule commits are not stored in this repository.
+		case object.RegularMode, object.ExecutableMode, object.SymlinkMode:
+			links = append(links, fsckLink{oid: entry.OID, objType: "blob"})
+		default:
+			report("warning", "badFilemode", "contains bad file modes")
+			links = append(links, fsckLink{oid: entry.OID, objType: "blob"})
+		}
+
+		// Subtrees sort as if their names ended in a slash.
+		key := name
+		if mode == object.DirectoryMode {
+			key += "/"
+		}
+		if seen[name] {
+			report("error", "duplicateEntries", "contains duplicate file entries")
+		} else if i > 0 && key < prevKey {
+			report("error", "treeNotSorted", "not properly sorted")
+		}
+		seen[name] = true
+		prevKey = key
+	}
+
+	c.addLinks(oid, links)
+}
+
+// checkCommit validates the header of a commit: a tree, any number of
+// parents, then an author and a committer.
+func (c *fsckChecker) checkCommit(oid object.ObjectID, data []byte) {
+	header, ok := objectHeader(data)
+	if !ok {
+		c.report("error", "commit", oid, "unterminatedHeader", "unterminated header")
+		return
+	}
+
+	var links []fsckLink
+	lines := strings.Split(header, "\n")
+
+	value, ok := strings.CutPrefix(lines[0], "tree ")
+	if !ok {
+		c.report("error", "commit", oid, "missingTree", "invalid format - expected 'tree' line")
+		return
+	}
+	tree, err := object.ParseOID(value, c.db.Algorithm())
+	if err != nil {
+		c.report("error", "commit", oid, "badTreeSha1", "invalid 'tree' line format - bad sha1")
+		return
+	}
+	links = append(links, fsckLink{oid: tree, objType: "tree"})
+	lines = lines[1:]
+
+	for len(lines) > 0 && strings.HasPrefix(lines[0], "parent ") {
+		parent, err := object.ParseOID(strings.TrimPrefix(lines[0], "parent "), c.db.Algorithm())
+		if err != nil {
+			c.report("error", "commit", oid, "badParentSha1", "invalid 'parent' line format - bad sha1")
+			return
+		}
+		links = append(links, fsckLink{oid: parent, objType: "commit"})
+		lines = lines[1:]
+	}
+
+	for _, field := range []string{"author", "committer"} {
+		if len(lines) == 0 || !strings.HasPrefix(lines[0], field+" ") {
+			c.report("error", "commit", oid, "missing"+capitalize(field), "invalid format - expected '"+field+"' line")
+			return
+		}
+		if msgID, detail := checkIdent(strings.TrimPrefix(lines[0], field+" ")); msgID != "" {
+			c.report("error", "commit", oid, msgID, detail)
+		}
+		lines = lines[1:]
+	}
+
+	c.addLinks(oid, links)
+}
+
+// checkTag validates the header of an annotated tag: the tagged object and
+// its type, the tag name and, optionally, the tagger.
+func (c *fsckChecker) checkTag(oid object.ObjectID, data []byte) {
+	header, ok := objectHeader(data)
+	if !ok {
+		c.report("error", "tag", oid, "unterminatedHeader", "unterminated header")
+		return
+	}
+	lines := strings.Split(header, "\n")
+
+	value, ok := strings.CutPrefix(lines[0], "object ")
+	if !ok {
+		c.report("error", "tag", oid, "missingObject", "invalid format - expected 'object' line")
+		return
+	}
+	target, err := object.ParseOID(value, c.db.Algorithm())
+	if err != nil {
+		c.report("error", "tag", oid, "badObjectSha1", "invalid 'object' line format - bad sha1")
+		return
+	}
+
+	if len(lines) < 2 || !strings.HasPrefix(lines[1], "type ") {
+		c.report("error", "tag", oid, "missingTypeEntry", "invalid format - expected 'type' line")
+		return
+	}
+	targetType := strings.TrimPrefix(lines[1], "type ")
+	switch targetType {
+	case "blob", "tree", "commit", "tag":
+	default:
+		c.report("error", "tag", oid, "badType", "invalid 'type' value")
+		return
+	}
+
+	if len(lines) < 3 || !strings.HasPrefix(lines[2], "tag ") {
+		c.report("error", "tag", oid, "missingTagEntry", "invalid format - expected 'tag' line")
+		return
+	}
+
+	if len(lines) > 3 && strings.HasPrefix(lines[3], "tagger ") {
+		if msgID, detail := checkIdent(strings.TrimPrefix(lines[3], "tagger ")); msgID != "" {
+			c.report("error", "tag", oid, msgID, detail)
+		}
+	}
+
+	c.addLinks(oid, []fsckLink{{oid: target, objType: targetType}})
+}
+
+// objectHeader returns the header lines of a commit or tag, which end at the
+// first blank line or at the end of the object.
+func objectHeader(data []byte) (string, bool) {
+	if i := bytes.Index(data, []byte("\n\n")); i >= 0 {
+		return string(data[:i]), true
+	}
+	if len(data) > 0 && data[len(data)-1] == '\n' {
+		return string(data[:len(data)-1]), true
+	}
+	return "", false
+}
+
+// checkIdent validates an identity of the form "Name <email> 1234567890
+// +0100", returning a message ID and detail for the first problem found.
+func checkIdent(ident string) (string, string) {
+	open := strings.IndexByte(ident, '<')
+	if open < 0 {
+		return "missingEmail", "invalid author/committer line - missing email"
+	}
+	if open == 0 || ident[open-1] != ' ' {
+		return "missingSpaceBeforeEmail", "invalid author/committer line - missing space before email"
+	}
+	if strings.ContainsAny(ident[:open], ">") {
+		return "badName", "invalid author/committer line - bad name"
+	}
+
+	closing := strings.IndexByte(ident[open:], '>')
+	if closing < 0 || strings.ContainsAny(ident[open+1:open+closing], "<") {
+		return "badEmail", "invalid author/committer line - bad email"
+	}
+	rest := ident[open+closing+1:]
+
+	if !strings.HasPrefix(rest, " ") {
+		return "missingSpaceBeforeDate", "invalid author/committer line - missing space before date"
+	}
+	date, zone, ok := strings.Cut(rest[1:], " ")
+	if !ok || date == "" || strings.Trim(date, "0123456789") != "" {
+		return "badDate", "invalid author/committer line - bad date"
+	}
+	if len(date) > 1 && date[0] == '0' {
+		return "zeroPaddedDate", "invalid author/committer line - zero-padded date"
+	}
+	if len(zone) != 5 || (zone[0] != '+' && zone[0] != '-') || strings.Trim(zone[1:], "0123456789") != "" {
+		return "badTimezone", "invalid author/committer line - bad time zone"
+	}
+	return "", ""
+}
+
+// capitalize upper-cases the first letter of a field name for use in a
+// message ID.
+func capitalize(s string) string {
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// addLinks records the references out of oid.
+func (c *fsckChecker) addLinks(oid object.ObjectID, links []fsckLink) {
+	seen := make(map[fsckLink]bool)
+	for _, link := range links {
+		if seen[link] {
+			continue
+		}
+		seen[link] = true
+		c.links[oid] = append(c.links[oid], link)
+		c.referenced[link.oid] = true
+	}
+}
+
+// checkLinks reports references to objects that are missing or have a type
+// other than the one expected, and then lists every missing object.
+func (c *fsckChecker) checkLinks() {
+	sources := make([]object.ObjectID, 0, len(c.links))
+	for oid := range c.links {
+		sources = append(sources, oid)
+	}
+	sortOIDs(sources)
+
+	missing := make(map[object.ObjectID]string)
+	for _, source := range sources {
+		for _, link := range c.links[source] {
+			actual, ok := c.types[link.oid]
+			if !ok && c.borrowed(link.oid) {
+				continue
+			}
+			if !ok {
+				fmt.Printf("broken link from %7s %s\n              to %7s %s\n",
+					c.types[source], source, link.objType, link.oid)
+				missing[link.oid] = link.objType
+				c.failed = true
+				continue
+			}
+			if actual != link.objType {
+				c.errorf("%s: object is a %s, not a %s, but %s %s refers to it as one",
+					link.oid, actual, link.objType, c.types[source], source)
+			}
+		}
+	}
+
+	oids := make([]object.ObjectID, 0, len(missing))
+	for oid := range missing {
+		oids = append(oids, oid)
+	}
+	sortOIDs(oids)
+	for _, oid := range oids {
+		fmt.Printf("missing %s %s\n", missing[oid], oid)
+	}
+}
+
+// borrowed reports whether oid is stored in an alternate. Objects there are
+// not checked, but count as present.
+func (c *fsckChecker) borrowed(oid object.ObjectID) bool {
+	exists, err := c.db.Exists(oid)
+	return err == nil && exists
+}
+
+// collectRoots returns the objects named by HEAD, every ref, the reflogs when
+// withReflogs is set, and the index. Roots that are missing are reported.
+func (c *fsckChecker) collectRoots(repo *gogit.Repository, withReflogs bool) ([]object.ObjectID, error) {
+	refsStore := repo.Refs()
+	var roots []object.ObjectID
+	addRoot := func(oid object.ObjectID, what string) {
+		if oid.IsZero() {
+			return
+		}
+		if _, ok := c.types[oid]; !ok && !c.borrowed(oid) {
+			c.errorf("%s: invalid sha1 pointer %s", what, oid)
+			return
+		}
+		roots = append(roots, oid)
+	}
+
+	head, err := refsStore.ReadHead()
+	if err != nil {
+		c.errorf("HEAD: %v", err)
+	}
+	addRoot(head, refs.HeadRef)
+
+	refList, err := refsStore.ListRefs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refs: %w", err)
+	}
+	for _, ref := range refList {
+		addRoot(ref.OID, ref.Name)
+	}
+
+	if withReflogs {
+		names := []string{refs.HeadRef}
+		for _, ref := range refList {
+			names = append(names, ref.Name)
+		}
+		for _, name := range names {
+			entries, err := refsStore.ReadLog(name)
+			if err != nil {
+				c.errorf("%v", err)
+				continue
+			}
+			for _, entry := range entries {
+				addRoot(entry.OldOID, name+" reflog")
+				addRoot(entry.NewOID, name+" reflog")
+			}
+		}
+	}
+
+	idx, err := repo.ReadIndex()
+	if err != nil {
+		c.errorf("%v", err)
+		return roots, nil
+	}
+	for _, entry := range idx.Entries() {
+		if object.FormatMode(entry.Mode) == object.GitlinkMode {
+			continue
+		}
+		addRoot(entry.OID, "index entry "+entry.Path)
+	}
+
+	return roots, nil
+}
+
+// walk returns every stored object reachable from roots.
+func (c *fsckChecker) walk(roots []object.ObjectID) map[object.ObjectID]bool {
+	reachable := make(map[object.ObjectID]bool)
+	queue := append([]object.ObjectID(nil), roots...)
+
+	for len(queue) > 0 {
+		oid := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if reachable[oid] {
+			continue
+		}
+		if _, ok := c.types[oid]; !ok {
+			continue
+		}
+		reachable[oid] = true
+		for _, link := range c.links[oid] {
+			queue = append(queue, link.oid)
+		}
+	}
+
+	return reachable
+}
+
+// reportUnreachable prints the stored objects outside reachable: all of them
+// when all is set, otherwise only those no other object refers to.
+func (c *fsckChecker) reportUnreachable(reachable map[object.ObjectID]bool, all bool) {
+	var oids []object.ObjectID
+	for oid := range c.types {
+		if !reachable[oid] {
+			oids = append(oids, oid)
+		}
+	}
+	sortOIDs(oids)
+
+	for _, oid := range oids {
+		switch {
+		case all:
+			fmt.Printf("unreachable %s %s\n", c.types[oid], oid)
+		case !c.referenced[oid]:
+			fmt.Printf("dangling %s %s\n", c.types[oid], oid)
+		}
+	}
+}
+
+// sortOIDs sorts object IDs in ascending order.
+func sortOIDs(oids []object.ObjectID) {
+	sort.Slice(oids, func(i, j int) bool {
+		return oids[i].Compare(oids[j]) < 0
+	})
+}
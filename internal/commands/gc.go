@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shanmugharajk/gogit/internal/file"
+	"github.com/shanmugharajk/gogit/internal/object"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// gcPruneExpireKey is the config key holding the default grace period
+	// for unreachable objects.
+	gcPruneExpireKey = "gc.pruneExpire"
+	// defaultPruneExpire is the grace period when gc.pruneExpire is unset.
+	defaultPruneExpire = "2.weeks.ago"
+
+	// staleLockAge is how long a lock file must go unmodified before gc
+	// and prune treat it as left behind by a process that died. No command holds a
+	// lock for anywhere near this long.
+	staleLockAge = time.Hour
+)
+
+// NewGcCmd creates the gc command.
+func NewGcCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gc [--prune=<date> | --no-prune]",
+		Short: "Cleanup unnecessary files and optimize the local repository",
+		Long: `Pack all refs, then repack every object reachable from HEAD, the refs, their
+reflogs and the index into a single pack, replacing the existing packs and
+removing the loose copies. Objects borrowed from alternates are not copied.
+Unreachable objects are removed once older than the prune date, which
+defaults to gc.pruneExpire or "2.weeks.ago"; younger ones are kept as loose
+objects. --no-prune keeps every unreachable object.
+Temporary files from interrupted writes and lock files that have not been
+touched for an hour are removed as well.`,
+		Args: cobra.NoArgs,
+		RunE: runGc,
+	}
+
+	cmd.Flags().String("prune", "", "prune unreachable objects older than <date>")
+	cmd.Flags().Bool("no-prune", false, "do not prune any unreachable objects")
+	cmd.MarkFlagsMutuallyExclusive("prune", "no-prune")
+
+	return cmd
+}
+
+func runGc(cmd *cobra.Command, args []string) error {
+	noPrune, _ := cmd.Flags().GetBool("no-prune")
+
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	gitPath := repo.GitDir()
+	cfg := repo.Config()
+
+	expireText := defaultPruneExpire
+	if value, ok := cfg.Get(gcPruneExpireKey); ok {
+		expireText = value
+	}
+	if cmd.Flags().Changed("prune") {
+		expireText, _ = cmd.Flags().GetString("prune")
+	}
+	if noPrune {
+		expireText = "never"
+	}
+	now := time.Now()
+	expire, err := parseExpiry(expireText, now)
+	if err != nil {
+		return err
+	}
+
+	// Only one gc may run at a time.
+	gcLock := file.NewLockfile(filepath.Join(gitPath, "gc.pid"))
+	held, err := gcLock.HoldForUpdate()
+	if err != nil {
+		return fmt.Errorf("failed to hold lock: %w", err)
+	}
+	if !held {
+		return fmt.Errorf("gc is already running in this repository")
+	}
+	defer gcLock.Rollback()
+
+	if _, err := removeStaleLocks(gitPath, now.Add(-staleLockAge), false); err != nil {
+		return fmt.Errorf("failed to remove stale locks: %w", err)
+	}
+
+	if err := repo.Refs().PackRefs(true, true); err != nil {
+		return fmt.Errorf("failed to pack refs: %w", err)
+	}
+	db, err := repo.Database()
+	if err != nil {
+		return err
+	}
+
+	// Note the packs before writing a new one, so that only these are
+	// replaced.
+	oldPacks, err := db.Packs()
+	if err != nil {
+		return fmt.Errorf("failed to read packs: %w", err)
+	}
+
+	live, err := reachableObjects(repo)
+	if err != nil {
+		return err
+	}
+	keep := func(oid object.ObjectID) bool { return live[oid] }
+
+	// Objects borrowed from alternates stay where they are.
+	oids := make([]object.ObjectID, 0, len(live))
+	for oid := range live {
+		local, err := db.ExistsLocally(oid)
+		if err != nil {
+			return err
+		}
+		if local {
+			oids = append(oids, oid)
+		}
+	}
+	sort.Slice(oids, func(i, j int) bool {
+		return oids[i].Compare(oids[j]) < 0
+	})
+
+	var newPack string
+	if len(oids) > 0 {
+		if newPack, err = db.WritePack(oids); err != nil {
+			return err
+		}
+	}
+
+	// Unreachable objects in the old packs would vanish with them, so keep
+	// those still within the grace period as loose objects.
+	if _, err := db.LoosenPacked(keep, expire, newPack); err != nil {
+		return fmt.Errorf("failed to unpack unreachable objects: %w", err)
+	}
+	for _, packPath := range oldPacks {
+		if packPath == newPack {
+			continue
+		}
+		if err := db.RemovePack(packPath); err != nil {
+			return fmt.Errorf("failed to remove pack: %w", err)
+		}
+	}
+
+	if _, err := db.PrunePacked(false); err != nil {
+		return fmt.Errorf("failed to prune packed objects: %w", err)
+	}
+	if _, err := db.PruneLoose(keep, expire, false); err != nil {
+		return fmt.Errorf("failed to prune objects: %w", err)
+	}
+	if _, err := db.RemoveTempFiles(expire, false); err != nil {
+		return fmt.Errorf("failed to remove temporary files: %w", err)
+	}
+
+	return nil
+}
+
+// removeStaleLocks removes lock files under gitPath, outside the object
+// database, that were last modified before cutoff, and returns their paths.
+// With dryRun nothing is removed.
+func removeStaleLocks(gitPath string, cutoff time.Time, dryRun bool) ([]string, error) {
+	objectsPath := filepath.Join(gitPath, "objects")
+
+	var removed []string
+	err := filepath.WalkDir(gitPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if path == objectsPath {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".lock") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		removed = append(removed, path)
+		if dryRun {
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	})
+	return removed, err
+}
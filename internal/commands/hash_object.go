@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/shanmugharajk/gogit/internal/commit"
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/object"
+	"github.com/shanmugharajk/gogit/internal/storage"
+	"github.com/shanmugharajk/gogit/pkg/gogit"
+	"github.com/spf13/cobra"
+)
+
+// NewHashObjectCmd creates the hash-object command.
+func NewHashObjectCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hash-object [-t <type>] [-w] [--literally] (--stdin | <file>...)",
+		Short: "Compute object ID and optionally create an object from a file",
+		Long: `Compute the object ID of each file, or of standard input with --stdin, as an
+object of the given type (blob by default) and print it. With -w the object is
+also written to the object database. Tree, commit and tag contents are checked
+for well-formedness unless --literally is given, which also allows any type.`,
+		RunE: runHashObject,
+	}
+
+	cmd.Flags().StringP("type", "t", "blob", "type of object to create")
+	cmd.Flags().BoolP("write", "w", false, "write the object into the object database")
+	cmd.Flags().Bool("stdin", false, "read the object from standard input")
+	cmd.Flags().Bool("literally", false, "allow any type and skip checking the contents")
+
+	return cmd
+}
+
+func runHashObject(cmd *cobra.Command, args []string) error {
+	objType, _ := cmd.Flags().GetString("type")
+	write, _ := cmd.Flags().GetBool("write")
+	stdin, _ := cmd.Flags().GetBool("stdin")
+	literally, _ := cmd.Flags().GetBool("literally")
+
+	if !stdin && len(args) == 0 {
+		return fmt.Errorf("no files given, and --stdin not specified")
+	}
+
+	// Hashing alone does not need a repository, but uses the object format
+	// of the one it runs in, SHA-1 outside of any.
+	var db storage.ObjectStore
+	repo, err := openRepository()
+	switch {
+	case err == nil:
+		db = repo.Objects()
+	case write || !errors.Is(err, gogit.ErrRepositoryNotFound):
+		return err
+	default:
+		db = storage.NewMemory(hashalgo.SHA1)
+	}
+
+	hash := func(data []byte) error {
+		if !literally {
+			if err := checkObjectContents(objType, data, db.Algorithm()); err != nil {
+				return err
+			}
+		}
+
+		obj := object.NewRaw(objType, data)
+		if write {
+			if err := db.Store(obj); err != nil {
+				return fmt.Errorf("failed to write object: %w", err)
+			}
+		} else {
+			db.HashObject(obj)
+		}
+
+		fmt.Println(obj.GetOID())
+		return nil
+	}
+
+	if stdin {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read standard input: %w", err)
+		}
+		if err := hash(data); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range args {
+		if objType == "blob" {
+			// Blobs need no checking, so stream them rather than reading
+			// possibly large files into memory.
+			oid, err := hashBlobFile(db, name, write)
+			if err != nil {
+				return err
+			}
+			fmt.Println(oid)
+			continue
+		}
+
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("could not open '%s' for reading: %w", name, err)
+		}
+		if err := hash(data); err != nil {
+			return err
+		}
+	}
+
+	return db.Flush()
+}
+
+// hashBlobFile streams the file name as a blob, storing it when write is set.
+func hashBlobFile(db storage.ObjectStore, name string, write bool) (object.ObjectID, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return object.ObjectID{}, fmt.Errorf("could not open '%s' for reading: %w", name, err)
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return object.ObjectID{}, fmt.Errorf("failed to stat '%s': %w", name, err)
+	}
+
+	if write {
+		return db.StoreStream("blob", stat.Size(), f)
+	}
+	return db.HashStream("blob", stat.Size(), f)
+}
+
+// checkObjectContents rejects unknown types and contents that do not parse
+// as the given type, with object IDs of algo.
+func checkObjectContents(objType string, data []byte, algo *hashalgo.Algorithm) error {
+	var err error
+
+	switch objType {
+	case "blob":
+	case "tree":
+		_, err = object.ParseTree(data, algo)
+	case "commit":
+		_, err = commit.Parse(data, algo)
+	case "tag":
+		if !bytes.HasPrefix(data, []byte("object ")) || !bytes.Contains(data, []byte("\ntype ")) {
+			err = fmt.Errorf("tag is missing its object or type header")
+		}
+	default:
+		return fmt.Errorf("invalid object type \"%s\"", objType)
+	}
+
+	if err != nil {
+		return fmt.Errorf("corrupt %s: %w", objType, err)
+	}
+	return nil
+}
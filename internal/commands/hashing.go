@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/shanmugharajk/gogit/internal/object"
+	"github.com/shanmugharajk/gogit/internal/storage"
+	"github.com/shanmugharajk/gogit/internal/workspace"
+	"github.com/shanmugharajk/gogit/pkg/gogit"
+	"github.com/spf13/cobra"
+)
+
+// hashJobsConfigKey sets the default number of files hashed in parallel.
+const hashJobsConfigKey = "core.hashJobs"
+
+// storedFile is a workspace file that has been written to the database.
+type storedFile struct {
+	path string
+	oid  object.ObjectID
+	stat os.FileInfo
+}
+
+// addJobsFlag registers the flag controlling hashing concurrency.
+func addJobsFlag(cmd *cobra.Command) {
+	cmd.Flags().IntP("jobs", "j", 0, "number of files to hash in parallel (default: "+hashJobsConfigKey+" or the number of CPUs)")
+}
+
+// hashJobs returns the number of hashing workers: the --jobs flag if given,
+// otherwise core.hashJobs, where zero or less means one per CPU.
+func hashJobs(cmd *cobra.Command, repo *gogit.Repository) (int, error) {
+	jobs, _ := cmd.Flags().GetInt("jobs")
+
+	if !cmd.Flags().Changed("jobs") {
+		var err error
+		if jobs, err = repo.Config().Int(hashJobsConfigKey, 0); err != nil {
+			return 0, fmt.Errorf("bad config: %w", err)
+		}
+	}
+
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	return jobs, nil
+}
+
+// storeFiles streams each workspace file into the database as a blob using
+// up to jobs workers. Results are in the order of paths. Once a file fails
+// no further files are started, and the error of the earliest failing path
+// is returned, so the outcome does not depend on scheduling.
+func storeFiles(ws *workspace.Workspace, db storage.ObjectStore, paths []string, jobs int) ([]*storedFile, error) {
+	results := make([]*storedFile, len(paths))
+	errs := make([]error, len(paths))
+
+	var failed atomic.Bool
+	work := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < min(jobs, len(paths)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				results[i], errs[i] = storeFile(ws, db, paths[i])
+				if errs[i] != nil {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+
+	for i := range paths {
+		if failed.Load() {
+			break
+		}
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to store blob for %s: %w", paths[i], err)
+		}
+	}
+	return results, nil
+}
+
+// storeFile stats and stores a single workspace file. The file is stat'ed
+// before it is read so that a change made while hashing is noticed later.
+func storeFile(ws *workspace.Workspace, db storage.ObjectStore, path string) (*storedFile, error) {
+	stat, err := ws.StatFile(path)
+	if err != nil {
+		return nil, err
+	}
+	oid, err := storeBlob(ws, db, path)
+	if err != nil {
+		return nil, err
+	}
+	return &storedFile{path: path, oid: oid, stat: stat}, nil
+}
@@ -4,45 +4,143 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/shanmugharajk/gogit/internal/config"
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/refs"
+	"github.com/shanmugharajk/gogit/pkg/gogit"
 	"github.com/spf13/cobra"
 )
 
+// NewInitCmd creates the init command.
 func NewInitCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "init [path]",
+		Use:   "init [-q] [--bare] [--template=<dir>] [--separate-git-dir <dir>] [-b <branch>] [path]",
 		Short: "Initialize a new git repository",
 		Long: `Initialize a new git repository in the current directory or specified path.
-This command creates the necessary directory structure and files for a git repository.`,
+This command creates the necessary directory structure and files for a git repository.
+With --bare, the repository is created directly in the path, without a working tree.
+Files are copied from the template directory given by --template, GIT_TEMPLATE_DIR
+or init.templateDir. Running init in an existing repository is safe: it adds
+anything missing but overwrites nothing.`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: runInit,
 	}
 
+	cmd.Flags().BoolP("quiet", "q", false, "only print error and warning messages")
+	cmd.Flags().Bool("bare", false, "create a bare repository")
+	cmd.Flags().String("template", "", "directory from which templates will be used")
+	cmd.Flags().StringP("initial-branch", "b", "", "override the name of the initial branch")
+	cmd.Flags().String("separate-git-dir", "", "create the repository in <dir> and link it from the working tree")
+	cmd.Flags().String("ref-format", refs.StorageFiles, "ref storage format to use (files or reftable)")
+	cmd.Flags().String("object-format", hashalgo.SHA1.Name, "hash algorithm naming objects (sha1 or sha256)")
+
 	return cmd
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	bare, _ := cmd.Flags().GetBool("bare")
+	separateGitDir, _ := cmd.Flags().GetString("separate-git-dir")
+
+	opts := &gogit.InitOptions{Bare: bare, SeparateGitDir: separateGitDir}
+	// Formats given explicitly must match those of an existing repository.
+	if cmd.Flags().Changed("ref-format") {
+		opts.RefFormat, _ = cmd.Flags().GetString("ref-format")
+	}
+	if cmd.Flags().Changed("object-format") {
+		opts.ObjectFormat, _ = cmd.Flags().GetString("object-format")
+	}
+
+	global, err := openGlobalConfig()
+	if err != nil {
+		return err
+	}
+
+	opts.InitialBranch, _ = cmd.Flags().GetString("initial-branch")
+	if opts.InitialBranch == "" {
+		opts.InitialBranch, _ = global.Get("init.defaultBranch")
+	}
+
+	opts.TemplateDir = templateDir(cmd, global)
+
 	path := "."
 	if len(args) > 0 {
 		path = args[0]
 	}
 
-	rootPath, err := filepath.Abs(filepath.Clean(path))
+	repo, err := gogit.Init(path, opts)
 	if err != nil {
 		return err
 	}
 
-	gitPath := filepath.Join(rootPath, ".git")
+	if quiet {
+		return nil
+	}
+	if repo.Reinitialized() {
+		if cmd.Flags().Changed("initial-branch") {
+			fmt.Fprintf(os.Stderr, "warning: re-init: ignored --initial-branch=%s\n", opts.InitialBranch)
+		}
+		fmt.Printf("Reinitialized existing Jit repository in %s\n", repo.GitDir())
+	} else {
+		fmt.Printf("Initialized empty Jit repository in %s\n", repo.GitDir())
+	}
+	return nil
+}
+
+// templateDir returns the template directory for a new repository: the one
+// given with --template, else GIT_TEMPLATE_DIR, else init.templateDir. A
+// directory that does not exist is warned about.
+func templateDir(cmd *cobra.Command, global *config.Config) string {
+	dir, _ := cmd.Flags().GetString("template")
+	if !cmd.Flags().Changed("template") {
+		var ok bool
+		if dir, ok = os.LookupEnv("GIT_TEMPLATE_DIR"); !ok {
+			dir, _ = global.Get("init.templateDir")
+		}
+	}
+	dir = expandHome(dir)
 
-	dirs := []string{"objects", "refs"}
-	for _, dir := range dirs {
-		dirPath := filepath.Join(gitPath, dir)
-		if err := os.MkdirAll(dirPath, 0755); err != nil {
-			fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
-			os.Exit(1)
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: templates not found in %s\n", dir)
 		}
 	}
+	return dir
+}
 
-	fmt.Printf("Initialized empty Jit repository in %s\n", gitPath)
-	return nil
+// openGlobalConfig reads the user's global config: GIT_CONFIG_GLOBAL when
+// set, otherwise ~/.gitconfig, falling back to $XDG_CONFIG_HOME/git/config
+// when that does not exist.
+func openGlobalConfig() (*config.Config, error) {
+	if path, ok := os.LookupEnv("GIT_CONFIG_GLOBAL"); ok {
+		return config.Open(path)
+	}
+
+	var candidates []string
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".gitconfig"))
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		candidates = append(candidates, filepath.Join(xdg, "git", "config"))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".config", "git", "config"))
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return config.Open(path)
+		}
+	}
+	return config.Open("")
+}
+
+// expandHome replaces a leading "~/" in path with the home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
 }
@@ -0,0 +1,316 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/file"
+	"github.com/shanmugharajk/gogit/pkg/gogit"
+	"github.com/spf13/cobra"
+)
+
+// Config keys for the defaults of --where, --if-exists and --if-missing, and
+// the characters separating trailer keys from values.
+const (
+	trailerWhereConfigKey      = "trailer.where"
+	trailerIfExistsConfigKey   = "trailer.ifexists"
+	trailerIfMissingConfigKey  = "trailer.ifmissing"
+	trailerSeparatorsConfigKey = "trailer.separators"
+)
+
+// NewInterpretTrailersCmd creates the interpret-trailers command.
+func NewInterpretTrailersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "interpret-trailers [--in-place] [--trim-empty] [(--trailer <key>[(=|:)<value>])...] [--where=<placement>] [--if-exists=<action>] [--if-missing=<action>] [--parse] [<file>...]",
+		Short: "Add or parse structured information in commit messages",
+		Long: `Add trailers to commit messages, or print the trailers they have.
+Each file, or standard input when none is given, is read as a commit message
+and written to standard output with the --trailer lines added to its trailer
+block: the last paragraph other than the title, ahead of any comments and
+"---" line, whose lines are all "Key: value", or at least a quarter of them
+when one is a Signed-off-by line.
+--where (default: trailer.where) is end, after, start or before, --if-exists
+(default: trailer.ifexists) is addIfDifferentNeighbor, addIfDifferent, add,
+replace or doNothing, and --if-missing (default: trailer.ifmissing) is add or
+doNothing. Each applies to the --trailer options given after it.
+--parse prints only the trailers of the input, each on one line.`,
+	}
+
+	// The placement options are kept in order with the trailers they apply
+	// to, so they are flags of their own rather than plain strings.
+	trailers := &trailerArgs{}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return runInterpretTrailers(cmd, args, trailers)
+	}
+
+	cmd.Flags().Var(&trailerFlag{args: trailers}, "trailer", "trailer to add, as key=value or key: value")
+	cmd.Flags().Var(&trailerOptionFlag{set: trailers.setWhere}, "where", "where to place the trailers that follow: end, after, start or before")
+	cmd.Flags().Var(&trailerOptionFlag{set: trailers.setIfExists}, "if-exists", "action when a trailer that follows has the key of an existing one")
+	cmd.Flags().Var(&trailerOptionFlag{set: trailers.setIfMissing}, "if-missing", "action when a trailer that follows has a key no existing one has")
+	cmd.Flags().Bool("in-place", false, "edit the files in place")
+	cmd.Flags().Bool("trim-empty", false, "leave out trailers with empty values")
+	cmd.Flags().Bool("only-trailers", false, "output only the trailers")
+	cmd.Flags().Bool("only-input", false, "do not add trailers, only process the input")
+	cmd.Flags().Bool("unfold", false, "join values spanning several lines")
+	cmd.Flags().Bool("parse", false, "same as --only-trailers --only-input --unfold")
+	cmd.Flags().Bool("no-divider", false, "do not treat --- as the end of the commit message")
+
+	return cmd
+}
+
+func runInterpretTrailers(cmd *cobra.Command, args []string, trailers *trailerArgs) error {
+	inPlace, _ := cmd.Flags().GetBool("in-place")
+	onlyInput, _ := cmd.Flags().GetBool("only-input")
+	noDivider, _ := cmd.Flags().GetBool("no-divider")
+	format := gogit.TrailerFormat{}
+	format.TrimEmpty, _ = cmd.Flags().GetBool("trim-empty")
+	format.OnlyTrailers, _ = cmd.Flags().GetBool("only-trailers")
+	format.Unfold, _ = cmd.Flags().GetBool("unfold")
+	if parse, _ := cmd.Flags().GetBool("parse"); parse {
+		format.OnlyTrailers, format.Unfold, onlyInput = true, true, true
+	}
+
+	if onlyInput && cmd.Flags().Changed("trailer") {
+		return fmt.Errorf("--trailer with --only-input does not make sense")
+	}
+	if inPlace && len(args) == 0 {
+		return fmt.Errorf("no input file given for in-place editing")
+	}
+
+	// Configuration is read from the repository when there is one
+	lookup := func(key string) (string, bool, error) {
+		global, err := openGlobalConfig()
+		if err != nil {
+			return "", false, err
+		}
+		value, ok := global.Get(key)
+		return value, ok, nil
+	}
+	if repo, err := openRepository(); err == nil {
+		lookup = func(key string) (string, bool, error) {
+			return lookupConfig(repo, key)
+		}
+	}
+
+	defaults, err := readTrailerDefaults(lookup)
+	if err != nil {
+		return err
+	}
+	edits, err := trailers.edits(defaults)
+	if err != nil {
+		return err
+	}
+	opts := &gogit.TrailerParseOptions{Separators: defaults.separators, NoDivider: noDivider}
+
+	process := func(message string) string {
+		b := gogit.ParseTrailerBlock(message, opts)
+		b.Apply(edits)
+		return b.Format(format)
+	}
+
+	if len(args) == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("could not read from stdin: %w", err)
+		}
+		fmt.Fprint(cmd.OutOrStdout(), process(string(data)))
+		return nil
+	}
+	for _, path := range args {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("could not read input file '%s': %w", path, err)
+		}
+		out := process(string(data))
+		if !inPlace {
+			fmt.Fprint(cmd.OutOrStdout(), out)
+			continue
+		}
+		if err := os.WriteFile(path, []byte(out), file.ModeFile); err != nil {
+			return fmt.Errorf("could not write to '%s': %w", path, err)
+		}
+	}
+	return nil
+}
+
+// trailerDefaults is how trailers are added according to the trailer.*
+// configuration.
+type trailerDefaults struct {
+	where      gogit.TrailerWhere
+	ifExists   gogit.TrailerIfExists
+	ifMissing  gogit.TrailerIfMissing
+	separators string
+}
+
+// readTrailerDefaults reads the trailer configuration through lookup.
+func readTrailerDefaults(lookup func(key string) (string, bool, error)) (*trailerDefaults, error) {
+	d := &trailerDefaults{separators: gogit.DefaultTrailerSeparators}
+	if v, ok, err := lookup(trailerWhereConfigKey); err != nil {
+		return nil, err
+	} else if ok {
+		if d.where, err = gogit.ParseTrailerWhere(v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok, err := lookup(trailerIfExistsConfigKey); err != nil {
+		return nil, err
+	} else if ok {
+		if d.ifExists, err = gogit.ParseTrailerIfExists(v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok, err := lookup(trailerIfMissingConfigKey); err != nil {
+		return nil, err
+	} else if ok {
+		if d.ifMissing, err = gogit.ParseTrailerIfMissing(v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok, err := lookup(trailerSeparatorsConfigKey); err != nil {
+		return nil, err
+	} else if ok && v != "" {
+		d.separators = v
+	}
+	return d, nil
+}
+
+// edits parses trailers given as --trailer arguments.
+func (d *trailerDefaults) edits(trailers []string) ([]gogit.TrailerEdit, error) {
+	edits := make([]gogit.TrailerEdit, 0, len(trailers))
+	for _, arg := range trailers {
+		t, err := gogit.ParseTrailer(arg, d.separators)
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, d.edit(t))
+	}
+	return edits, nil
+}
+
+// edit returns the edit adding t as configured.
+func (d *trailerDefaults) edit(t gogit.Trailer) gogit.TrailerEdit {
+	return gogit.TrailerEdit{Trailer: t, Where: d.where, IfExists: d.ifExists, IfMissing: d.ifMissing}
+}
+
+// trailerArgs collects --trailer arguments in the order given, each with the
+// --where, --if-exists and --if-missing given before it. Like git, an option
+// only affects the trailers that follow it.
+type trailerArgs struct {
+	current  trailerOptions
+	trailers []trailerArg
+}
+
+// trailerOptions holds the placement options given so far. Options not
+// given fall back to the trailer.* configuration.
+type trailerOptions struct {
+	where     *gogit.TrailerWhere
+	ifExists  *gogit.TrailerIfExists
+	ifMissing *gogit.TrailerIfMissing
+}
+
+// trailerArg is one --trailer argument and the options in effect for it.
+type trailerArg struct {
+	value string
+	opts  trailerOptions
+}
+
+func (a *trailerArgs) setWhere(value string) error {
+	where, err := gogit.ParseTrailerWhere(value)
+	if err != nil {
+		return err
+	}
+	a.current.where = &where
+	return nil
+}
+
+func (a *trailerArgs) setIfExists(value string) error {
+	ifExists, err := gogit.ParseTrailerIfExists(value)
+	if err != nil {
+		return err
+	}
+	a.current.ifExists = &ifExists
+	return nil
+}
+
+func (a *trailerArgs) setIfMissing(value string) error {
+	ifMissing, err := gogit.ParseTrailerIfMissing(value)
+	if err != nil {
+		return err
+	}
+	a.current.ifMissing = &ifMissing
+	return nil
+}
+
+// edits parses the trailers, adding each as its options say or else as d
+// does.
+func (a *trailerArgs) edits(d *trailerDefaults) ([]gogit.TrailerEdit, error) {
+	edits := make([]gogit.TrailerEdit, 0, len(a.trailers))
+	for _, arg := range a.trailers {
+		t, err := gogit.ParseTrailer(arg.value, d.separators)
+		if err != nil {
+			return nil, err
+		}
+		edit := d.edit(t)
+		if arg.opts.where != nil {
+			edit.Where = *arg.opts.where
+		}
+		if arg.opts.ifExists != nil {
+			edit.IfExists = *arg.opts.ifExists
+		}
+		if arg.opts.ifMissing != nil {
+			edit.IfMissing = *arg.opts.ifMissing
+		}
+		edits = append(edits, edit)
+	}
+	return edits, nil
+}
+
+// trailerFlag is the --trailer flag, recording each argument in args.
+type trailerFlag struct {
+	args *trailerArgs
+}
+
+func (f *trailerFlag) Set(value string) error {
+	f.args.trailers = append(f.args.trailers, trailerArg{value: value, opts: f.args.current})
+	return nil
+}
+
+func (f *trailerFlag) String() string {
+	if len(f.args.trailers) == 0 {
+		return ""
+	}
+	values := make([]string, len(f.args.trailers))
+	for i, arg := range f.args.trailers {
+		values[i] = arg.value
+	}
+	return "[" + strings.Join(values, ",") + "]"
+}
+
+func (f *trailerFlag) Type() string {
+	return "stringArray"
+}
+
+// trailerOptionFlag is one of --where, --if-exists and --if-missing, which
+// changes the options of the trailers that follow through set.
+type trailerOptionFlag struct {
+	set   func(value string) error
+	value string
+}
+
+func (f *trailerOptionFlag) Set(value string) error {
+	if err := f.set(value); err != nil {
+		return err
+	}
+	f.value = value
+	return nil
+}
+
+func (f *trailerOptionFlag) String() string {
+	return f.value
+}
+
+func (f *trailerOptionFlag) Type() string {
+	return "string"
+}
@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestInterpretTrailersAppliesOptionsInOrder(t *testing.T) {
+	newTestWorkTree(t)
+	writeFiles(t, map[string]string{"msg": "subject\n\nbody\n\nAcked-by: x\nFixes: 1\n"})
+
+	cmd := NewInterpretTrailersCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	runCommand(t, cmd,
+		"--trailer", "A: 1", "--where", "start", "--trailer", "B: 2",
+		"--if-exists", "replace", "--trailer", "Fixes: 2",
+		"--where", "end", "--trailer", "C: 3",
+		"--if-missing", "doNothing", "--trailer", "D: 4",
+		"msg")
+
+	// As git does: A precedes --where start, the later --where end moves C
+	// back to the end, Fixes replaces its namesake and D is dropped.
+	want := "subject\n\nbody\n\nFixes: 2\nB: 2\nAcked-by: x\nA: 1\nC: 3\n"
+	if got := out.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/pkg/gogit"
+	"github.com/spf13/cobra"
+)
+
+// NewLogCmd creates the log command.
+func NewLogCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "log [-n <number>] [--show-signature] [<revision>]",
+		Short: "Show commit history",
+		Long: `Show the commits reachable from <revision>, or HEAD, newest first, in git's
+medium format.
+
+--show-signature checks the SSH signature of each signed commit against the
+allowed signers file named by gpg.ssh.allowedSignersFile, as verify-commit
+does, and reports the outcome after the commit line.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runLog,
+	}
+
+	cmd.Flags().IntP("max-count", "n", -1, "show at most the given number of commits")
+	cmd.Flags().Bool("show-signature", false, "check and report the signature of signed commits")
+
+	return cmd
+}
+
+func runLog(cmd *cobra.Command, args []string) error {
+	maxCount, _ := cmd.Flags().GetInt("max-count")
+	showSignature, _ := cmd.Flags().GetBool("show-signature")
+
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+
+	var start gogit.ObjectID
+	if len(args) == 0 {
+		if start, err = repo.Head(); err != nil {
+			return err
+		}
+		if start.IsZero() {
+			branch, err := repo.CurrentBranch()
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("your current branch '%s' does not have any commits yet", branch)
+		}
+	} else if start, err = repo.ResolveCommit(args[0]); err != nil {
+		return err
+	}
+
+	var allowedSigners string
+	if showSignature {
+		if allowedSigners, err = allowedSignersFile(repo); err != nil {
+			return err
+		}
+	}
+
+	history, err := repo.History(start, maxCount)
+	if err != nil {
+		return err
+	}
+	for i, c := range history {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("commit %s\n", c.GetOID())
+		if showSignature && c.Signature != "" {
+			check, err := repo.VerifyCommit(c.GetOID(), allowedSigners)
+			switch {
+			case errors.Is(err, gogit.ErrNoSignature):
+			case err != nil:
+				fmt.Println(err)
+			default:
+				printSignatureCheck(os.Stdout, check)
+			}
+		}
+		printMediumCommit(c)
+	}
+	return nil
+}
+
+// printMediumCommit prints the body of a commit in git's medium format: the
+// lines following the commit line.
+func printMediumCommit(c *gogit.Commit) {
+	if len(c.Parents) > 1 {
+		parents := make([]string, len(c.Parents))
+		for i, parent := range c.Parents {
+			parents[i] = parent.Short()
+		}
+		fmt.Printf("Merge: %s\n", strings.Join(parents, " "))
+	}
+	fmt.Printf("Author: %s <%s>\n", c.Author.Name, c.Author.Email)
+	fmt.Printf("Date:   %s\n\n", c.Author.Time.Format(gitDateFormat))
+	for _, line := range strings.Split(strings.TrimRight(c.Message, "\n"), "\n") {
+		fmt.Printf("    %s\n", line)
+	}
+}
@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/index"
+	"github.com/shanmugharajk/gogit/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+// NewLsFilesCmd creates the ls-files command.
+func NewLsFilesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ls-files [-c] [-s] [-o] [-i] [-d] [-m] [-z] [--exclude-standard] [--full-name] [<paths>...]",
+		Short: "Show information about files in the index and the workspace",
+		Long: `List the files in the index (--cached, the default), files in the workspace
+that are not in the index (--others), and index entries whose workspace file is
+deleted (--deleted) or changed (--modified). --ignored limits the listing to
+files matched by the exclude rules; --exclude-standard reads those rules from
+.gitignore files, .git/info/exclude and core.excludesFile.
+
+Run from a subdirectory, only the files beneath it are listed unless paths say
+otherwise, and names are shown relative to it; --full-name shows them relative
+to the root of the working tree.`,
+		RunE: runLsFiles,
+	}
+
+	cmd.Flags().BoolP("cached", "c", false, "show cached files in the output")
+	cmd.Flags().BoolP("stage", "s", false, "show staged contents' mode, object name and stage number")
+	cmd.Flags().BoolP("others", "o", false, "show untracked files in the output")
+	cmd.Flags().BoolP("ignored", "i", false, "show only ignored files in the output")
+	cmd.Flags().BoolP("deleted", "d", false, "show deleted files in the output")
+	cmd.Flags().BoolP("modified", "m", false, "show modified files in the output")
+	cmd.Flags().BoolP("null", "z", false, "terminate entries with NUL instead of newline")
+	cmd.Flags().Bool("exclude-standard", false, "add the standard git exclusions")
+	cmd.Flags().Bool("full-name", false, "show paths relative to the root of the working tree")
+
+	return cmd
+}
+
+// lsFilesOptions selects what ls-files shows and how.
+type lsFilesOptions struct {
+	cached   bool
+	stage    bool
+	others   bool
+	ignored  bool
+	deleted  bool
+	modified bool
+	nul      bool
+	paths    []string
+
+	// prefix is the current directory within the working tree, which
+	// names are shown relative to.
+	prefix string
+}
+
+func runLsFiles(cmd *cobra.Command, args []string) error {
+	opts := &lsFilesOptions{}
+	opts.cached, _ = cmd.Flags().GetBool("cached")
+	opts.stage, _ = cmd.Flags().GetBool("stage")
+	opts.others, _ = cmd.Flags().GetBool("others")
+	opts.ignored, _ = cmd.Flags().GetBool("ignored")
+	opts.deleted, _ = cmd.Flags().GetBool("deleted")
+	opts.modified, _ = cmd.Flags().GetBool("modified")
+	opts.nul, _ = cmd.Flags().GetBool("null")
+	excludeStandard, _ := cmd.Flags().GetBool("exclude-standard")
+	fullName, _ := cmd.Flags().GetBool("full-name")
+
+	// With no selection at all, list the index.
+	if !opts.stage && !opts.others && !opts.deleted && !opts.modified {
+		opts.cached = true
+	}
+	if opts.ignored && !opts.others && !opts.cached && !opts.stage {
+		return fmt.Errorf("ls-files -i must be used with either -o or -c")
+	}
+	if opts.ignored && !excludeStandard {
+		return fmt.Errorf("ls-files -i needs some exclude pattern")
+	}
+
+	repo, _, err := openWorkTree()
+	if err != nil {
+		return err
+	}
+	if opts.paths, err = workTreePaths(repo, args); err != nil {
+		return err
+	}
+	prefix, err := workTreePrefix(repo)
+	if err != nil {
+		return err
+	}
+	if len(opts.paths) == 0 && prefix != "" {
+		opts.paths = []string{prefix}
+	}
+	if !fullName {
+		opts.prefix = prefix
+	}
+	idx, err := repo.ReadIndex()
+	if err != nil {
+		return err
+	}
+
+	var ignore *workspace.Ignore
+	if excludeStandard {
+		if ignore, err = repo.Ignore(); err != nil {
+			return err
+		}
+	}
+
+	var out strings.Builder
+	terminator := "\n"
+	if opts.nul {
+		terminator = "\x00"
+	}
+
+	printEntry := func(entry *index.Entry) {
+		name := formatPath(relativePath(entry.Path, opts.prefix), opts.nul)
+		if opts.stage {
+			stage := (entry.Flags >> 12) & 0x3
+			fmt.Fprintf(&out, "%06o %s %d\t%s%s", entry.Mode, entry.OID, stage, name, terminator)
+		} else {
+			fmt.Fprintf(&out, "%s%s", name, terminator)
+		}
+	}
+
+	if opts.others {
+		others, err := repo.UntrackedFiles(idx, ignore, opts.ignored)
+		if err != nil {
+			return err
+		}
+		for _, name := range others {
+			if opts.matches(name) {
+				fmt.Fprintf(&out, "%s%s", formatPath(relativePath(name, opts.prefix), opts.nul), terminator)
+			}
+		}
+	}
+
+	for _, entry := range idx.Entries() {
+		if !opts.matches(entry.Path) {
+			continue
+		}
+
+		if opts.cached || opts.stage {
+			show := true
+			if opts.ignored {
+				if show, err = ignore.IsIgnored(entry.Path, false); err != nil {
+					return err
+				}
+			}
+			if show {
+				printEntry(entry)
+			}
+		}
+
+		if opts.deleted || opts.modified {
+			modified, exists, err := repo.WorkTreeModified(entry)
+			if err != nil {
+				return err
+			}
+			if opts.deleted && !exists {
+				printEntry(entry)
+			}
+			if opts.modified && (modified || !exists) {
+				printEntry(entry)
+			}
+		}
+	}
+
+	fmt.Fprint(cmd.OutOrStdout(), out.String())
+	return nil
+}
+
+// matches reports whether name is selected by the command line paths.
+func (opts *lsFilesOptions) matches(name string) bool {
+	if len(opts.paths) == 0 {
+		return true
+	}
+	for _, p := range opts.paths {
+		if pathMatches(name, p) {
+			return true
+		}
+	}
+	return false
+}
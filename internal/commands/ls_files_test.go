@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// newSubdirWorkTree commits a, d/b and d/e/c and changes into d.
+func newSubdirWorkTree(t *testing.T) {
+	t.Helper()
+	newTestWorkTree(t)
+	if err := os.MkdirAll("d/e", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, map[string]string{"a": "a\n", "d/b": "b\n", "d/e/c": "c\n"})
+	runCommand(t, NewAddCmd(), ".")
+	runCommand(t, NewCommitCmd(), "-m", "first")
+	t.Chdir("d")
+}
+
+func TestLsFilesFromSubdirectory(t *testing.T) {
+	newSubdirWorkTree(t)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "b\ne/c\n"},
+		{[]string{".."}, "../a\nb\ne/c\n"},
+		{[]string{"--full-name"}, "d/b\nd/e/c\n"},
+		{[]string{"--full-name", "../a"}, "a\n"},
+	}
+	for _, tt := range tests {
+		cmd := NewLsFilesCmd()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		runCommand(t, cmd, tt.args...)
+		if got := out.String(); got != tt.want {
+			t.Errorf("ls-files %v =\n%s\nwant\n%s", tt.args, got, tt.want)
+		}
+	}
+}
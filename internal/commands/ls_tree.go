@@ -0,0 +1,254 @@
+package commands
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/object"
+	"github.com/shanmugharajk/gogit/internal/refs"
+	"github.com/shanmugharajk/gogit/internal/revision"
+	"github.com/shanmugharajk/gogit/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// NewLsTreeCmd creates the ls-tree command.
+func NewLsTreeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ls-tree [-r] [-t] [-l] [--name-only] [-z] [--full-name | --full-tree] <tree-ish> [<paths>...]",
+		Short: "List the contents of a tree object",
+		Long: `List the entries of the tree named by <tree-ish>, which may be a tree or
+anything resolving to a commit. Paths limit the listing to matching entries; a
+path ending in a slash lists the contents of that directory. With -r subtrees
+are listed recursively, and -t still shows the subtrees themselves.
+
+Run from a subdirectory, only the entries beneath it are listed unless paths
+say otherwise, and names are shown relative to it; --full-name shows them
+relative to the root of the working tree, and --full-tree lists the whole tree
+as if run from the root.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runLsTree,
+	}
+
+	cmd.Flags().BoolP("recursive", "r", false, "recurse into subtrees")
+	cmd.Flags().BoolP("show-trees", "t", false, "show tree entries even when recursing")
+	cmd.Flags().BoolP("long", "l", false, "show the size of blob entries")
+	cmd.Flags().Bool("name-only", false, "list only the names of entries")
+	cmd.Flags().BoolP("null", "z", false, "terminate entries with NUL instead of newline")
+	cmd.Flags().Bool("full-name", false, "show paths relative to the root of the working tree")
+	cmd.Flags().Bool("full-tree", false, "list the whole tree, regardless of the current directory")
+	cmd.MarkFlagsMutuallyExclusive("full-name", "full-tree")
+
+	return cmd
+}
+
+// lsTreeOptions selects what ls-tree shows and how.
+type lsTreeOptions struct {
+	recursive bool
+	showTrees bool
+	long      bool
+	nameOnly  bool
+	nul       bool
+	paths     []lsTreePath
+
+	// prefix is the current directory within the working tree, which
+	// names are shown relative to.
+	prefix string
+}
+
+// lsTreePath is a path argument; a trailing slash asks for the contents of
+// a directory rather than the directory entry itself, as does a path ending
+// in "." or "..". The root, named "", always stands for its contents.
+type lsTreePath struct {
+	name     string
+	contents bool
+}
+
+func runLsTree(cmd *cobra.Command, args []string) error {
+	opts := &lsTreeOptions{}
+	opts.recursive, _ = cmd.Flags().GetBool("recursive")
+	opts.showTrees, _ = cmd.Flags().GetBool("show-trees")
+	opts.long, _ = cmd.Flags().GetBool("long")
+	opts.nameOnly, _ = cmd.Flags().GetBool("name-only")
+	opts.nul, _ = cmd.Flags().GetBool("null")
+	fullName, _ := cmd.Flags().GetBool("full-name")
+	fullTree, _ := cmd.Flags().GetBool("full-tree")
+
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+
+	prefix := ""
+	if !fullTree {
+		if prefix, err = workTreePrefix(repo); err != nil {
+			return err
+		}
+	}
+	if !fullName {
+		opts.prefix = prefix
+	}
+
+	// Paths are relative to the current directory, unless there is no
+	// working tree for them to be relative to or --full-tree is given.
+	for _, arg := range args[1:] {
+		p := filepath.ToSlash(arg)
+		name := path.Clean(p)
+		if repo.WorkTree() != "" && !fullTree {
+			if name, err = repo.WorkTreePath(arg); err != nil {
+				return err
+			}
+		}
+		if name == "." {
+			name = ""
+		}
+		opts.paths = append(opts.paths, lsTreePath{
+			name:     name,
+			contents: strings.HasSuffix(p, "/") || path.Base(p) == "." || path.Base(p) == "..",
+		})
+	}
+	if len(opts.paths) == 0 && prefix != "" {
+		opts.paths = []lsTreePath{{name: prefix, contents: true}}
+	}
+	db := repo.Objects()
+	refsStore := repo.Refs()
+
+	treeOID, err := resolveTreeish(refsStore, db, args[0])
+	if err != nil {
+		return err
+	}
+
+	var out strings.Builder
+	if err := listTree(db, opts, treeOID, "", &out); err != nil {
+		return err
+	}
+
+	fmt.Fprint(cmd.OutOrStdout(), out.String())
+	return nil
+}
+
+// resolveTreeish resolves expr to a tree, peeling commits to their tree.
+func resolveTreeish(refsStore refs.Refs, db storage.ObjectStore, expr string) (object.ObjectID, error) {
+	oid, err := revision.New(refsStore, db, expr).Resolve()
+	if err != nil {
+		return object.ObjectID{}, fmt.Errorf("not a valid object name %s", expr)
+	}
+
+	objType, _, err := db.ReadObject(oid)
+	if err != nil {
+		return object.ObjectID{}, err
+	}
+	switch objType {
+	case "tree":
+		return oid, nil
+	case "commit":
+		c, err := db.LoadCommit(oid)
+		if err != nil {
+			return object.ObjectID{}, err
+		}
+		return c.TreeOID, nil
+	default:
+		return object.ObjectID{}, fmt.Errorf("not a tree object: %s", expr)
+	}
+}
+
+// listTree writes the entries of the tree treeOID, found at prefix, that are
+// selected by the options, descending into subtrees as required.
+func listTree(db storage.ObjectStore, opts *lsTreeOptions, treeOID object.ObjectID, prefix string, out *strings.Builder) error {
+	tree, err := db.LoadTree(treeOID)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range tree.Names() {
+		entry := tree.Get(name)
+		fullPath := path.Join(prefix, name)
+		isTree := entry.Mode() == object.DirectoryMode
+
+		show, descend := opts.selects(fullPath, isTree)
+		if show {
+			if err := writeTreeEntry(db, opts, entry, fullPath, out); err != nil {
+				return err
+			}
+		}
+		if descend {
+			if err := listTree(db, opts, entry.GetOID(), fullPath, out); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// selects decides whether the entry at name is printed and, for trees,
+// whether its contents are listed.
+func (opts *lsTreeOptions) selects(name string, isTree bool) (show bool, descend bool) {
+	if len(opts.paths) == 0 {
+		descend = isTree && opts.recursive
+		return !descend || opts.showTrees, descend
+	}
+
+	for _, p := range opts.paths {
+		switch {
+		case p.name == "", name == p.name && !p.contents, strings.HasPrefix(name, p.name+"/"):
+			// The entry itself is selected.
+			if isTree && opts.recursive {
+				descend = true
+				show = show || opts.showTrees
+			} else {
+				show = true
+			}
+		case isTree && (name == p.name || strings.HasPrefix(p.name, name+"/")):
+			// A selected path lies beneath this tree.
+			descend = true
+			show = show || opts.showTrees
+		}
+	}
+
+	return show, descend
+}
+
+// writeTreeEntry prints one ls-tree line for entry.
+func writeTreeEntry(db storage.ObjectStore, opts *lsTreeOptions, entry object.TreeEntry, name string, out *strings.Builder) error {
+	terminator := "\n"
+	if opts.nul {
+		terminator = "\x00"
+	}
+	name = formatPath(relativePath(name, opts.prefix), opts.nul)
+
+	if opts.nameOnly {
+		fmt.Fprintf(out, "%s%s", name, terminator)
+		return nil
+	}
+
+	objType := "blob"
+	switch entry.Mode() {
+	case object.DirectoryMode:
+		objType = "tree"
+	case object.GitlinkMode:
+		objType = "commit"
+	}
+
+	mode := entry.Mode()
+	if m, err := object.ParseMode(mode); err == nil {
+		mode = fmt.Sprintf("%06o", m)
+	}
+
+	if !opts.long {
+		fmt.Fprintf(out, "%s %s %s\t%s%s", mode, objType, entry.GetOID(), name, terminator)
+		return nil
+	}
+
+	size := "-"
+	if objType == "blob" {
+		_, data, err := db.ReadObject(entry.GetOID())
+		if err != nil {
+			return err
+		}
+		size = fmt.Sprintf("%d", len(data))
+	}
+	fmt.Fprintf(out, "%s %s %s %7s\t%s%s", mode, objType, entry.GetOID(), size, name, terminator)
+	return nil
+}
@@ -0,0 +1,30 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLsTreeFromSubdirectory(t *testing.T) {
+	newSubdirWorkTree(t)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-r", "HEAD"}, "b\ne/c\n"},
+		{[]string{"HEAD"}, "b\ne\n"},
+		{[]string{"HEAD", ".."}, "../a\n./\n"},
+		{[]string{"--full-name", "-r", "HEAD"}, "d/b\nd/e/c\n"},
+		{[]string{"--full-tree", "HEAD"}, "a\nd\n"},
+	}
+	for _, tt := range tests {
+		cmd := NewLsTreeCmd()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		runCommand(t, cmd, append([]string{"--name-only"}, tt.args...)...)
+		if got := out.String(); got != tt.want {
+			t.Errorf("ls-tree %v =\n%s\nwant\n%s", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/index"
+	"github.com/shanmugharajk/gogit/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+// NewMvCmd creates the mv command.
+func NewMvCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mv [-f] [-n] <source>... <destination>",
+		Short: "Move or rename a file or directory",
+		Long: `Rename <source> to <destination>, or move every <source> into the existing
+directory <destination>, updating the index to match. An existing destination
+is only overwritten with -f.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: runMv,
+	}
+
+	cmd.Flags().BoolP("force", "f", false, "overwrite an existing destination")
+	cmd.Flags().BoolP("dry-run", "n", false, "do not move anything, just show what would happen")
+	cmd.Flags().BoolP("verbose", "v", false, "report the names of files as they are moved")
+
+	return cmd
+}
+
+// move is a single validated source to destination rename.
+type move struct {
+	source      string
+	destination string
+}
+
+func runMv(cmd *cobra.Command, args []string) error {
+	force, _ := cmd.Flags().GetBool("force")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	repo, ws, err := openWorkTree()
+	if err != nil {
+		return err
+	}
+	paths, err := workTreePaths(repo, args)
+	if err != nil {
+		return err
+	}
+
+	idx, err := repo.LockIndex()
+	if err != nil {
+		return fmt.Errorf("failed to load index: %w", err)
+	}
+
+	moves, err := planMoves(ws, idx, paths, force)
+	if err != nil {
+		idx.Release()
+		return err
+	}
+
+	for _, m := range moves {
+		if dryRun {
+			fmt.Printf("Checking rename of '%s' to '%s'\n", m.source, m.destination)
+		}
+		if dryRun || verbose {
+			fmt.Printf("Renaming %s to %s\n", m.source, m.destination)
+		}
+		if dryRun {
+			continue
+		}
+
+		if err := ws.Rename(m.source, m.destination); err != nil {
+			idx.Release()
+			return fmt.Errorf("renaming '%s' failed: %w", m.source, err)
+		}
+
+		for _, entry := range idx.EntriesUnder(m.source) {
+			newPath := m.destination + strings.TrimPrefix(entry.Path, m.source)
+			stat, err := ws.StatFile(newPath)
+			if err != nil {
+				idx.Release()
+				return fmt.Errorf("failed to stat file %s: %w", newPath, err)
+			}
+			idx.Remove(entry.Path)
+			idx.Add(newPath, entry.OID, stat)
+		}
+	}
+
+	if dryRun {
+		return idx.Release()
+	}
+	if err := idx.WriteUpdates(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// planMoves validates every source against the workspace and index before
+// anything is touched, mirroring git mv's checks and messages. Paths are
+// relative to the root of the working tree.
+func planMoves(ws *workspace.Workspace, idx *index.Index, args []string, force bool) ([]move, error) {
+	sources := args[:len(args)-1]
+	dest := path.Clean(filepath.ToSlash(args[len(args)-1]))
+
+	destStat, err := ws.StatFile(dest)
+	destIsDir := err == nil && destStat.IsDir()
+	if len(sources) > 1 && !destIsDir {
+		return nil, fmt.Errorf("destination '%s' is not a directory", dest)
+	}
+
+	var moves []move
+	for _, arg := range sources {
+		src := path.Clean(filepath.ToSlash(arg))
+		target := dest
+		if destIsDir {
+			target = path.Join(dest, path.Base(src))
+		}
+
+		fail := func(reason string) error {
+			return fmt.Errorf("%s, source=%s, destination=%s", reason, src, target)
+		}
+
+		srcStat, err := ws.StatFile(src)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, fail("bad source")
+			}
+			return nil, err
+		}
+		if !idx.IsTracked(src) {
+			return nil, fail("not under version control")
+		}
+		if target == src || strings.HasPrefix(target, src+"/") {
+			return nil, fail("can not move directory into itself")
+		}
+
+		if targetStat, err := ws.StatFile(target); err == nil {
+			if !force || srcStat.IsDir() || targetStat.IsDir() {
+				return nil, fail("destination exists")
+			}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		if parent := path.Dir(target); parent != "." {
+			if stat, err := ws.StatFile(parent); err != nil || !stat.IsDir() {
+				return nil, fail("destination directory does not exist")
+			}
+		}
+
+		for _, m := range moves {
+			if m.destination == target {
+				return nil, fail("multiple sources for the same target")
+			}
+		}
+
+		moves = append(moves, move{source: src, destination: target})
+	}
+
+	return moves, nil
+}
@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// NewPackRefsCmd creates the pack-refs command.
+func NewPackRefsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pack-refs",
+		Short: "Pack heads and tags for efficient repository access",
+		Long: `Move loose references into the packed-refs file.
+By default tags and refs that are already packed are moved; --all packs every
+ref under refs/. Loose files are removed once packed unless --no-prune is given.
+Repositories using reftable compact their table stack into a single table.`,
+		Args: cobra.NoArgs,
+		RunE: runPackRefs,
+	}
+
+	cmd.Flags().Bool("all", false, "pack all refs, not only tags and already packed refs")
+	cmd.Flags().Bool("no-prune", false, "keep the loose refs after packing them")
+
+	return cmd
+}
+
+func runPackRefs(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	noPrune, _ := cmd.Flags().GetBool("no-prune")
+
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+
+	if err := repo.Refs().PackRefs(all, !noPrune); err != nil {
+		return fmt.Errorf("failed to pack refs: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shanmugharajk/gogit/internal/object"
+	"github.com/shanmugharajk/gogit/internal/reachable"
+	"github.com/shanmugharajk/gogit/pkg/gogit"
+	"github.com/spf13/cobra"
+)
+
+// NewPruneCmd creates the prune command.
+func NewPruneCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prune [-n] [-v] [--expire <time>]",
+		Short: "Prune all unreachable objects from the object database",
+		Long: `Remove loose objects that cannot be reached from HEAD, any ref, any reflog
+entry or the index, together with loose objects that are also packed,
+temporary files left behind by interrupted writes and lock files that have
+not been touched for an hour.
+--expire only removes objects and temporary files older than <time>, such as
+"2.weeks.ago", "now" or "2024-01-31", which protects objects that a concurrent
+command has written but not yet referenced. By default everything unreachable
+is removed.`,
+		Args: cobra.NoArgs,
+		RunE: runPrune,
+	}
+
+	cmd.Flags().BoolP("dry-run", "n", false, "do not remove anything; just report what would be removed")
+	cmd.Flags().BoolP("verbose", "v", false, "report all removed objects")
+	cmd.Flags().String("expire", "now", "only expire loose objects older than <time>")
+
+	return cmd
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	expireText, _ := cmd.Flags().GetString("expire")
+
+	expire, err := parseExpiry(expireText, time.Now())
+	if err != nil {
+		return err
+	}
+
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	db, err := repo.Database()
+	if err != nil {
+		return err
+	}
+
+	live, err := reachableObjects(repo)
+	if err != nil {
+		return err
+	}
+
+	pruned, err := db.PruneLoose(func(oid object.ObjectID) bool { return live[oid] }, expire, dryRun)
+	if dryRun || verbose {
+		for _, oid := range pruned {
+			fmt.Println(oid)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to prune objects: %w", err)
+	}
+
+	if _, err := db.PrunePacked(dryRun); err != nil {
+		return fmt.Errorf("failed to prune packed objects: %w", err)
+	}
+
+	removed, err := db.RemoveTempFiles(expire, dryRun)
+	if dryRun || verbose {
+		for _, path := range removed {
+			fmt.Printf("Removing stale temporary file %s\n", path)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove temporary files: %w", err)
+	}
+
+	// Lock files are held briefly by live commands, so only old ones are
+	// removed whatever --expire says.
+	locks, err := removeStaleLocks(repo.GitDir(), time.Now().Add(-staleLockAge), dryRun)
+	if dryRun || verbose {
+		for _, path := range locks {
+			fmt.Printf("Removing stale lock file %s\n", path)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove stale locks: %w", err)
+	}
+
+	return nil
+}
+
+// reachableObjects returns every object reachable from the refs, reflogs
+// and index of repo.
+func reachableObjects(repo *gogit.Repository) (map[object.ObjectID]bool, error) {
+	idx, err := repo.ReadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	roots, err := reachable.Roots(repo.Refs(), idx)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := reachable.Walk(repo.Objects(), roots)
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk reachable objects: %w", err)
+	}
+	return live, nil
+}
+
+// expiryUnits maps the units accepted in relative expiry dates to their
+// length. Months and years are approximated as git does.
+var expiryUnits = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+	"month":  30 * 24 * time.Hour,
+	"year":   365 * 24 * time.Hour,
+}
+
+// parseExpiry converts an expiry date to the time before which files are
+// old enough to remove. It accepts "now", "never", relative dates such as
+// "2.weeks.ago" or "3 days ago", Unix timestamps written "@1700000000", and
+// absolute dates. "never" yields the zero time, before which nothing is.
+func parseExpiry(value string, now time.Time) (time.Time, error) {
+	text := strings.ToLower(strings.TrimSpace(value))
+
+	switch text {
+	case "now", "all":
+		return now, nil
+	case "never", "false":
+		return time.Time{}, nil
+	}
+
+	if seconds, ok := strings.CutPrefix(text, "@"); ok {
+		unix, err := strconv.ParseInt(seconds, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("malformed expiration date '%s'", value)
+		}
+		return time.Unix(unix, 0), nil
+	}
+
+	if fields := strings.Fields(strings.ReplaceAll(text, ".", " ")); len(fields) == 3 && fields[2] == "ago" {
+		count, err := strconv.Atoi(fields[0])
+		unit, ok := expiryUnits[strings.TrimSuffix(fields[1], "s")]
+		if err != nil || !ok || count < 0 {
+			return time.Time{}, fmt.Errorf("malformed expiration date '%s'", value)
+		}
+		return now.Add(-time.Duration(count) * unit), nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("malformed expiration date '%s'", value)
+}
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// quotePath formats a path for line-oriented output the way git does with
+// core.quotePath enabled: names containing control characters, quotes,
+// backslashes or non-ASCII bytes are wrapped in double quotes with C-style
+// escapes. Other names are returned unchanged.
+func quotePath(name string) string {
+	needsQuoting := false
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; c < 0x20 || c >= 0x7f || c == '"' || c == '\\' {
+			needsQuoting = true
+			break
+		}
+	}
+	if !needsQuoting {
+		return name
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; c {
+		case '\a':
+			b.WriteString(`\a`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\v':
+			b.WriteString(`\v`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			if c < 0x20 || c >= 0x7f {
+				fmt.Fprintf(&b, "\\%03o", c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// formatPath returns name as it should appear in listing output, quoted
+// unless entries are NUL-terminated.
+func formatPath(name string, nulTerminated bool) string {
+	if nulTerminated {
+		return name
+	}
+	return quotePath(name)
+}
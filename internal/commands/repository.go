@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/shanmugharajk/gogit/internal/config"
+	"github.com/shanmugharajk/gogit/internal/workspace"
+	"github.com/shanmugharajk/gogit/pkg/gogit"
+)
+
+// Environment variables that change how the repository is found.
+const (
+	gitDirEnv               = "GIT_DIR"
+	gitWorkTreeEnv          = "GIT_WORK_TREE"
+	gitCeilingDirsEnv       = "GIT_CEILING_DIRECTORIES"
+	gitDiscoveryAcrossFSEnv = "GIT_DISCOVERY_ACROSS_FILESYSTEM"
+)
+
+// openRepository opens the repository containing the current directory, or
+// the one GIT_DIR names.
+func openRepository() (*gogit.Repository, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	opts := &gogit.DiscoverOptions{
+		GitDir:   os.Getenv(gitDirEnv),
+		WorkTree: os.Getenv(gitWorkTreeEnv),
+	}
+	if ceilings := os.Getenv(gitCeilingDirsEnv); ceilings != "" {
+		opts.CeilingDirectories = filepath.SplitList(ceilings)
+	}
+	if value := os.Getenv(gitDiscoveryAcrossFSEnv); value != "" {
+		if opts.AcrossFilesystems, err = config.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("bad boolean value '%s' for %s", value, gitDiscoveryAcrossFSEnv)
+		}
+	}
+
+	return gogit.DiscoverWithOptions(cwd, opts)
+}
+
+// openWorkTree is openRepository for commands that need a working tree. It
+// fails for bare repositories.
+func openWorkTree() (*gogit.Repository, *workspace.Workspace, error) {
+	repo, err := openRepository()
+	if err != nil {
+		return nil, nil, err
+	}
+	if repo.IsBare() {
+		return nil, nil, gogit.ErrNoWorkTree
+	}
+	return repo, workspace.New(repo.WorkTree()), nil
+}
+
+// workTreePaths converts paths given on the command line, relative to the
+// current directory, into paths relative to the root of the working tree.
+func workTreePaths(repo *gogit.Repository, args []string) ([]string, error) {
+	paths := make([]string, 0, len(args))
+	for _, arg := range args {
+		p, err := repo.WorkTreePath(arg)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+// workTreePrefix returns the current directory relative to the root of the
+// working tree, or "" at the root and when there is no working tree. Like
+// git, commands run from a subdirectory limit themselves to it.
+func workTreePrefix(repo *gogit.Repository) (string, error) {
+	if repo.IsBare() {
+		return "", nil
+	}
+	prefix, err := repo.WorkTreePath(".")
+	if err != nil {
+		return "", err
+	}
+	if prefix == "." {
+		return "", nil
+	}
+	return prefix, nil
+}
+
+// relativePath returns name, relative to the root of the working tree, as
+// a path relative to prefix, as git prints paths in a subdirectory: "./"
+// names prefix itself.
+func relativePath(name string, prefix string) string {
+	if prefix == "" {
+		return name
+	}
+	if name == prefix {
+		return "./"
+	}
+	rel, err := filepath.Rel(filepath.FromSlash(prefix), filepath.FromSlash(name))
+	if err != nil {
+		return name
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,290 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/index"
+	"github.com/shanmugharajk/gogit/internal/object"
+	"github.com/shanmugharajk/gogit/internal/refs"
+	"github.com/shanmugharajk/gogit/internal/revision"
+	"github.com/shanmugharajk/gogit/internal/storage"
+	"github.com/shanmugharajk/gogit/internal/workspace"
+	"github.com/shanmugharajk/gogit/pkg/gogit"
+	"github.com/spf13/cobra"
+)
+
+// NewResetCmd creates the reset command.
+func NewResetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reset [--soft | --mixed | --hard] [<commit>] [--] [<paths>...]",
+		Short: "Reset current HEAD to the specified state",
+		Long: `Move the current branch to <commit>, which defaults to HEAD.
+--soft only moves the branch, --mixed (the default) also resets the index from
+the commit's tree, and --hard resets both the index and the workspace.
+With paths, the index entries for those paths are restored from <commit>
+without touching HEAD.`,
+		RunE: runReset,
+	}
+
+	cmd.Flags().Bool("soft", false, "only move HEAD")
+	cmd.Flags().Bool("mixed", false, "move HEAD and reset the index")
+	cmd.Flags().Bool("hard", false, "move HEAD and reset the index and workspace")
+	cmd.MarkFlagsMutuallyExclusive("soft", "mixed", "hard")
+
+	return cmd
+}
+
+func runReset(cmd *cobra.Command, args []string) error {
+	soft, _ := cmd.Flags().GetBool("soft")
+	hard, _ := cmd.Flags().GetBool("hard")
+
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	if repo.IsBare() && !soft {
+		if hard {
+			return gogit.ErrNoWorkTree
+		}
+		return fmt.Errorf("mixed reset is not allowed in a bare repository")
+	}
+	db := repo.Objects()
+	refsStore := repo.Refs()
+
+	// Separate the optional revision from the paths, as git does: anything
+	// before "--" is a revision, otherwise the first argument is one only if
+	// it resolves.
+	rev := ""
+	paths := args
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		if dash > 1 {
+			return usageErrorf("too many revisions before '--'")
+		}
+		if dash == 1 {
+			rev = args[0]
+		}
+		paths = args[dash:]
+	} else if len(args) > 0 {
+		if _, err := revision.New(refsStore, db, args[0]).Resolve(); err == nil {
+			rev, paths = args[0], args[1:]
+		} else if _, statErr := os.Stat(args[0]); statErr != nil {
+			return fmt.Errorf("ambiguous argument '%s': unknown revision or path not in the working tree", args[0])
+		}
+	}
+
+	headOID, err := refsStore.ReadHead()
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD: %w", err)
+	}
+
+	if len(paths) > 0 {
+		if soft {
+			return fmt.Errorf("cannot do soft reset with paths")
+		}
+		if hard {
+			return fmt.Errorf("cannot do hard reset with paths")
+		}
+		return resetPaths(repo, headOID, rev, paths)
+	}
+
+	// With no commit yet, reset means unstaging everything for the first
+	if rev == "" && headOID.IsZero() {
+		if soft {
+			return fmt.Errorf("cannot do a soft reset on an unborn branch")
+		}
+		return resetUnborn(repo, hard)
+	}
+	if rev == "" {
+		rev = refs.HeadRef
+	}
+	targetOID, err := revision.New(refsStore, db, rev).ResolveCommit()
+	if err != nil {
+		return fmt.Errorf("failed to resolve '%s' as a valid ref: %w", rev, err)
+	}
+	target, err := db.LoadCommit(targetOID)
+	if err != nil {
+		return err
+	}
+
+	if !soft {
+		idx, err := repo.LockIndex()
+		if err != nil {
+			return err
+		}
+		ws := workspace.New(repo.WorkTree())
+		if err := resetIndex(db, ws, idx, target.TreeOID, hard); err != nil {
+			idx.Release()
+			return err
+		}
+		if err := idx.WriteUpdates(); err != nil {
+			return fmt.Errorf("failed to write index: %w", err)
+		}
+	}
+
+	if !headOID.IsZero() {
+		if err := refsStore.UpdateRef("ORIG_HEAD", headOID, ""); err != nil {
+			return fmt.Errorf("failed to update ORIG_HEAD: %w", err)
+		}
+	}
+	if err := refsStore.UpdateHead(targetOID, "reset: moving to "+rev); err != nil {
+		return fmt.Errorf("failed to update HEAD: %w", err)
+	}
+
+	if hard {
+		fmt.Printf("HEAD is now at %s %s\n", targetOID.Short(), target.Title())
+	}
+
+	return nil
+}
+
+// resetUnborn empties the index of a branch with no commits, as resetting
+// to the empty tree, leaving HEAD as it is.
+func resetUnborn(repo *gogit.Repository, hard bool) error {
+	idx, err := repo.LockIndex()
+	if err != nil {
+		return err
+	}
+	ws := workspace.New(repo.WorkTree())
+	if err := resetIndex(repo.Objects(), ws, idx, object.ObjectID{}, hard); err != nil {
+		idx.Release()
+		return err
+	}
+	if err := idx.WriteUpdates(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// resetPaths restores the index entries at and below each path from the tree
+// of rev (HEAD by default), removing entries the tree does not have.
+func resetPaths(repo *gogit.Repository, headOID object.ObjectID, rev string, paths []string) error {
+	db := repo.Objects()
+	treeFiles := map[string]*object.StoredEntry{}
+
+	if rev == "" && !headOID.IsZero() {
+		rev = refs.HeadRef
+	}
+	if rev != "" {
+		oid, err := repo.ResolveCommit(rev)
+		if err != nil {
+			return fmt.Errorf("failed to resolve '%s' as a valid ref: %w", rev, err)
+		}
+		c, err := db.LoadCommit(oid)
+		if err != nil {
+			return err
+		}
+		if treeFiles, err = db.ListTree(c.TreeOID, ""); err != nil {
+			return err
+		}
+	}
+
+	idx, err := repo.LockIndex()
+	if err != nil {
+		return err
+	}
+
+	for _, arg := range paths {
+		p, err := repo.WorkTreePath(arg)
+		if err != nil {
+			idx.Release()
+			return err
+		}
+
+		for _, entry := range idx.Entries() {
+			if _, ok := treeFiles[entry.Path]; !ok && pathMatches(entry.Path, p) {
+				idx.Remove(entry.Path)
+			}
+		}
+		for name, entry := range treeFiles {
+			if !pathMatches(name, p) {
+				continue
+			}
+			mode, err := object.ParseMode(entry.Mode())
+			if err != nil {
+				idx.Release()
+				return fmt.Errorf("invalid mode for %s: %w", name, err)
+			}
+			idx.AddFromDB(name, entry.OID, mode)
+		}
+	}
+
+	if err := idx.WriteUpdates(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// resetIndex replaces the index with the contents of the tree treeOID. With
+// updateWorkspace, tracked files are also rewritten to match the tree and
+// files that are no longer tracked are deleted. The zero treeOID stands for
+// the empty tree.
+func resetIndex(db storage.ObjectStore, ws *workspace.Workspace, idx *index.Index, treeOID object.ObjectID, updateWorkspace bool) error {
+	treeFiles := map[string]*object.StoredEntry{}
+	if !treeOID.IsZero() {
+		var err error
+		if treeFiles, err = db.ListTree(treeOID, ""); err != nil {
+			return err
+		}
+	}
+
+	// Entries the tree also has are replaced below, keeping the stat
+	// information of those that are unchanged
+	for _, entry := range idx.Entries() {
+		if _, ok := treeFiles[entry.Path]; ok {
+			continue
+		}
+		if updateWorkspace {
+			if err := ws.RemoveFile(entry.Path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", entry.Path, err)
+			}
+		}
+		idx.Remove(entry.Path)
+	}
+
+	for name, entry := range treeFiles {
+		mode, err := object.ParseMode(entry.Mode())
+		if err != nil {
+			return fmt.Errorf("invalid mode for %s: %w", name, err)
+		}
+
+		if !updateWorkspace {
+			idx.AddFromDB(name, entry.OID, mode)
+			continue
+		}
+
+		if err := checkoutBlob(db, ws, name, entry); err != nil {
+			return err
+		}
+		stat, err := ws.StatFile(name)
+		if err != nil {
+			return fmt.Errorf("failed to stat file %s: %w", name, err)
+		}
+		idx.Add(name, entry.OID, stat)
+	}
+
+	return nil
+}
+
+// checkoutBlob streams the blob for entry into the workspace file name.
+func checkoutBlob(db storage.ObjectStore, ws *workspace.Workspace, name string, entry *object.StoredEntry) error {
+	blob, err := db.OpenObject(entry.OID)
+	if err != nil {
+		return fmt.Errorf("failed to load blob for %s: %w", name, err)
+	}
+	defer blob.Close()
+
+	if err := ws.WriteStream(name, blob, entry.Mode() == object.ExecutableMode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// pathMatches reports whether name is pathspec itself or lies beneath it.
+func pathMatches(name string, pathspec string) bool {
+	if pathspec == "." {
+		return true
+	}
+	return name == pathspec || strings.HasPrefix(name, pathspec+"/")
+}
@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"os"
+	"testing"
+)
+
+func TestResetOnUnbornBranchUnstagesEverything(t *testing.T) {
+	repo := newTestWorkTree(t)
+	writeFiles(t, map[string]string{"a": "a\n", "b": "b\n"})
+	runCommand(t, NewAddCmd(), "a", "b")
+
+	runCommand(t, NewResetCmd())
+
+	idx, err := repo.ReadIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := idx.Entries(); len(entries) != 0 {
+		t.Errorf("index still has %d entries after reset", len(entries))
+	}
+	if _, err := os.Stat("a"); err != nil {
+		t.Errorf("reset removed a from the working tree: %v", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !head.IsZero() {
+		t.Errorf("reset moved HEAD of an unborn branch to %s", head)
+	}
+}
+
+func TestResetSoftOnUnbornBranchFails(t *testing.T) {
+	newTestWorkTree(t)
+	writeFiles(t, map[string]string{"a": "a\n"})
+	runCommand(t, NewAddCmd(), "a")
+
+	cmd := NewResetCmd()
+	cmd.SetArgs([]string{"--soft"})
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	if err := cmd.Execute(); err == nil {
+		t.Errorf("reset --soft succeeded on an unborn branch")
+	}
+}
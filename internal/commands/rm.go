@@ -0,0 +1,178 @@
+package commands
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/index"
+	"github.com/shanmugharajk/gogit/pkg/gogit"
+	"github.com/spf13/cobra"
+)
+
+// NewRmCmd creates the rm command.
+func NewRmCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rm [-f] [-n] [-r] [--cached] <paths>...",
+		Short: "Remove files from the workspace and from the index",
+		Long: `Remove files from the index, and from the workspace unless --cached is given.
+Files whose staged or workspace contents differ from HEAD are refused unless -f
+is given, so that no uncommitted work is lost. Directories require -r.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runRm,
+	}
+
+	cmd.Flags().Bool("cached", false, "only remove from the index")
+	cmd.Flags().BoolP("recursive", "r", false, "allow recursive removal of directories")
+	cmd.Flags().BoolP("force", "f", false, "override the up-to-date check")
+	cmd.Flags().BoolP("dry-run", "n", false, "do not remove anything, just show what would be removed")
+	cmd.Flags().BoolP("quiet", "q", false, "do not list removed files")
+
+	return cmd
+}
+
+func runRm(cmd *cobra.Command, args []string) error {
+	cached, _ := cmd.Flags().GetBool("cached")
+	recursive, _ := cmd.Flags().GetBool("recursive")
+	force, _ := cmd.Flags().GetBool("force")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	quiet, _ := cmd.Flags().GetBool("quiet")
+
+	repo, ws, err := openWorkTree()
+	if err != nil {
+		return err
+	}
+	paths, err := workTreePaths(repo, args)
+	if err != nil {
+		return err
+	}
+
+	idx, err := repo.LockIndex()
+	if err != nil {
+		return fmt.Errorf("failed to load index: %w", err)
+	}
+
+	entries, err := selectRmEntries(idx, paths, recursive)
+	if err != nil {
+		idx.Release()
+		return err
+	}
+
+	if !force {
+		if err := checkRmSafety(repo, entries, cached); err != nil {
+			idx.Release()
+			return err
+		}
+	}
+
+	for _, entry := range entries {
+		if !quiet {
+			fmt.Printf("rm '%s'\n", entry.Path)
+		}
+		if dryRun {
+			continue
+		}
+
+		idx.Remove(entry.Path)
+		if !cached {
+			if err := ws.RemoveFile(entry.Path); err != nil {
+				idx.Release()
+				return fmt.Errorf("failed to remove %s: %w", entry.Path, err)
+			}
+		}
+	}
+
+	if dryRun {
+		return idx.Release()
+	}
+	if err := idx.WriteUpdates(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// selectRmEntries expands each path to the index entries it names, refusing
+// unmatched paths and directories without recursive.
+func selectRmEntries(idx *index.Index, args []string, recursive bool) ([]*index.Entry, error) {
+	seen := make(map[string]bool)
+	var entries []*index.Entry
+
+	for _, arg := range args {
+		p := path.Clean(filepath.ToSlash(arg))
+
+		matched := idx.EntriesUnder(p)
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("pathspec '%s' did not match any files", arg)
+		}
+		if idx.IsTrackedDirectory(p) && !recursive {
+			return nil, fmt.Errorf("not removing '%s' recursively without -r", arg)
+		}
+
+		for _, entry := range matched {
+			if !seen[entry.Path] {
+				seen[entry.Path] = true
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// checkRmSafety refuses removals that would lose work, using the same rules
+// and messages as git rm.
+func checkRmSafety(repo *gogit.Repository, entries []*index.Entry, cached bool) error {
+	headFiles, err := repo.HeadFiles()
+	if err != nil {
+		return err
+	}
+
+	var bothDiffer, staged, modified []string
+	for _, entry := range entries {
+		wsModified, exists, err := repo.WorkTreeModified(entry)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			// Nothing left in the workspace to lose.
+			continue
+		}
+		stagedChange := gogit.IndexDiffersFromTree(headFiles[entry.Path], entry)
+
+		switch {
+		case stagedChange && wsModified:
+			bothDiffer = append(bothDiffer, entry.Path)
+		case cached:
+		case stagedChange:
+			staged = append(staged, entry.Path)
+		case wsModified:
+			modified = append(modified, entry.Path)
+		}
+	}
+
+	var msg strings.Builder
+	report := func(paths []string, problem string, hint string) {
+		if len(paths) == 0 {
+			return
+		}
+		noun := "file has"
+		if len(paths) > 1 {
+			noun = "files have"
+		}
+		fmt.Fprintf(&msg, "the following %s %s:\n", noun, problem)
+		for _, p := range paths {
+			fmt.Fprintf(&msg, "    %s\n", p)
+		}
+		fmt.Fprintf(&msg, "%s\n", hint)
+	}
+
+	report(bothDiffer, "staged content different from both the\nfile and the HEAD", "(use -f to force removal)")
+	report(staged, "changes staged in the index", "(use --cached to keep the file, or -f to force removal)")
+	report(modified, "local modifications", "(use --cached to keep the file, or -f to force removal)")
+
+	if msg.Len() > 0 {
+		return fmt.Errorf("%s", strings.TrimSuffix(msg.String(), "\n"))
+	}
+	return nil
+}
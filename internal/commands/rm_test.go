@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shanmugharajk/gogit/pkg/gogit"
+	"github.com/spf13/cobra"
+)
+
+// newTestWorkTree initializes a repository in a temporary directory, makes
+// it the current directory and isolates the commands from the user's
+// configuration.
+func newTestWorkTree(t *testing.T) *gogit.Repository {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := gogit.Init(dir, nil)
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	t.Chdir(dir)
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(dir, "global-config"))
+	t.Setenv("GIT_AUTHOR_NAME", "A U Thor")
+	t.Setenv("GIT_AUTHOR_EMAIL", "author@example.com")
+	return repo
+}
+
+// runCommand runs cmd with args, failing the test if it fails.
+func runCommand(t *testing.T, cmd *cobra.Command, args ...string) {
+	t.Helper()
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("%s %v: %v", cmd.Name(), args, err)
+	}
+}
+
+// writeFiles creates each file named in files with its contents.
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRmCachedLeavesPathOutOfNextCommit(t *testing.T) {
+	repo := newTestWorkTree(t)
+	writeFiles(t, map[string]string{"keep": "keep\n", "drop": "drop\n"})
+	runCommand(t, NewAddCmd(), "keep", "drop")
+	runCommand(t, NewCommitCmd(), "-m", "first")
+
+	runCommand(t, NewRmCmd(), "-q", "--cached", "drop")
+	runCommand(t, NewCommitCmd(), "-m", "second")
+
+	files, err := repo.HeadFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := files["drop"]; ok {
+		t.Errorf("drop is still committed after rm --cached")
+	}
+	if _, ok := files["keep"]; !ok {
+		t.Errorf("keep is missing from the commit")
+	}
+	if _, err := os.Stat("drop"); err != nil {
+		t.Errorf("rm --cached removed the file from the working tree: %v", err)
+	}
+}
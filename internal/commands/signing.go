@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/config"
+	"github.com/shanmugharajk/gogit/pkg/gogit"
+	"github.com/spf13/cobra"
+)
+
+// Config keys for signing and verifying commits and tags.
+const (
+	gpgFormatConfigKey      = "gpg.format"
+	signingKeyConfigKey     = "user.signingKey"
+	commitSignConfigKey     = "commit.gpgSign"
+	allowedSignersConfigKey = "gpg.ssh.allowedSignersFile"
+)
+
+// sshFormat is the gpg.format value for signing with SSH keys, the only one
+// gogit supports.
+const sshFormat = "ssh"
+
+// addSignFlags registers -S and --no-gpg-sign. A key can only be given as
+// --gpg-sign=<key>: pflag takes -S<key> for -S followed by more shorthands.
+func addSignFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("gpg-sign", "S", "", "sign the commit, with the key file given as --gpg-sign=<key> instead of user.signingKey")
+	cmd.Flags().Lookup("gpg-sign").NoOptDefVal = " "
+	cmd.Flags().Bool("no-gpg-sign", false, "do not sign the commit, overriding commit.gpgSign")
+}
+
+// commitSigner returns the signer for a new commit, or nil when it is not
+// to be signed: without -S, commit.gpgSign decides.
+func commitSigner(cmd *cobra.Command, repo *gogit.Repository) (gogit.Signer, error) {
+	if noSign, _ := cmd.Flags().GetBool("no-gpg-sign"); noSign {
+		return nil, nil
+	}
+
+	key, _ := cmd.Flags().GetString("gpg-sign")
+	key = strings.TrimSpace(key)
+	if !cmd.Flags().Changed("gpg-sign") {
+		value, ok, err := lookupConfig(repo, commitSignConfigKey)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, nil
+		}
+		sign, err := config.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("bad boolean config value '%s' for '%s'", value, commitSignConfigKey)
+		}
+		if !sign {
+			return nil, nil
+		}
+	}
+	return signer(repo, key)
+}
+
+// signer returns the signer for key, or for user.signingKey when key is
+// empty. Only gpg.format=ssh is supported.
+func signer(repo *gogit.Repository, key string) (gogit.Signer, error) {
+	format, ok, err := lookupConfig(repo, gpgFormatConfigKey)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		format = "openpgp"
+	}
+	if format != sshFormat {
+		return nil, fmt.Errorf("unsupported %s '%s': only ssh signing is supported", gpgFormatConfigKey, format)
+	}
+
+	if key == "" {
+		if key, _, err = lookupConfig(repo, signingKeyConfigKey); err != nil {
+			return nil, err
+		}
+	}
+	if key == "" {
+		return nil, fmt.Errorf("either %s or -S must be set to a key file for ssh signing", signingKeyConfigKey)
+	}
+	if strings.HasPrefix(key, "key::") || strings.HasPrefix(key, "ssh-") {
+		return nil, fmt.Errorf("%s must name a key file; keys given inline need ssh-agent, which is not supported", signingKeyConfigKey)
+	}
+	return gogit.NewSSHSigner(expandHome(key))
+}
+
+// allowedSignersFile returns the allowed signers file verification checks
+// keys against, or "" when none is configured.
+func allowedSignersFile(repo *gogit.Repository) (string, error) {
+	path, _, err := lookupConfig(repo, allowedSignersConfigKey)
+	if err != nil {
+		return "", err
+	}
+	return expandHome(path), nil
+}
@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSigningKey writes a new ed25519 private key to a file and returns
+// its path.
+func writeSigningKey(t *testing.T) string {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCommitSignFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		// flag returns the signing option given for the key file.
+		flag       func(key string) string
+		signingKey bool
+	}{
+		{"key given to --gpg-sign", func(key string) string { return "--gpg-sign=" + key }, false},
+		{"-S with user.signingKey", func(string) string { return "-S" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestWorkTree(t)
+			key := writeSigningKey(t)
+			repo.Config().Set(gpgFormatConfigKey, sshFormat)
+			if tt.signingKey {
+				repo.Config().Set(signingKeyConfigKey, key)
+			}
+			if err := repo.Config().Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			writeFiles(t, map[string]string{"a": "a\n"})
+			runCommand(t, NewAddCmd(), "a")
+			runCommand(t, NewCommitCmd(), "-m", "signed", tt.flag(key))
+
+			head, err := repo.Head()
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := repo.Objects().LoadCommit(head)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Signature == "" {
+				t.Errorf("commit made with %s is not signed", tt.flag(key))
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/config"
+	"github.com/shanmugharajk/gogit/internal/file"
+	"github.com/shanmugharajk/gogit/internal/refs"
+	"github.com/shanmugharajk/gogit/pkg/gogit"
+	"github.com/spf13/cobra"
+)
+
+// tagEditMsgFile holds the message of the tag being made while it is
+// edited.
+const tagEditMsgFile = "TAG_EDITMSG"
+
+// tagSignConfigKey makes every new tag a signed annotated tag.
+const tagSignConfigKey = "tag.gpgSign"
+
+// NewTagCmd creates the tag command.
+func NewTagCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tag [-a | -s | -u <key>] [-f] [-m <msg>]... [-F <file>] [--no-sign] <tagname> [<commit>] | -d <tagname>... | [-l [<pattern>...]]",
+		Short: "Create, list or delete tags",
+		Long: `Create refs/tags/<tagname> pointing at <commit>, or HEAD.
+
+With -a, -m or -F the tag is annotated: the ref points at a tag object that
+records the tagger and a message. The message is taken from -m, each one a
+paragraph, or from -F, where "-" is standard input; otherwise it is edited in
+$GIT_EDITOR, core.editor, $VISUAL or $EDITOR. Comment lines and surplus
+whitespace are stripped, and an empty message aborts the tag.
+
+-s, -u or tag.gpgSign makes a signed annotated tag, signed with the SSH key
+file given to -u or named by user.signingKey; gpg.format must be ssh.
+--no-sign overrides tag.gpgSign.
+
+An existing tag is only replaced with -f. -d deletes the named tags. With no
+arguments, or with -l, the tags matching any of the given shell patterns are
+listed.`,
+		RunE: runTag,
+	}
+
+	cmd.Flags().BoolP("annotate", "a", false, "make an unsigned, annotated tag object")
+	cmd.Flags().BoolP("sign", "s", false, "make a signed annotated tag")
+	cmd.Flags().StringP("local-user", "u", "", "make a signed annotated tag with the given key file")
+	cmd.Flags().Bool("no-sign", false, "do not sign the tag, overriding tag.gpgSign")
+	cmd.Flags().StringArrayP("message", "m", nil, "use the given message as a paragraph of the tag message")
+	cmd.Flags().StringP("file", "F", "", "read the tag message from the given file, or - for standard input")
+	cmd.Flags().BoolP("force", "f", false, "replace the tag if it exists")
+	cmd.Flags().BoolP("delete", "d", false, "delete tags")
+	cmd.Flags().BoolP("list", "l", false, "list tag names matching the given patterns")
+
+	return cmd
+}
+
+func runTag(cmd *cobra.Command, args []string) error {
+	del, _ := cmd.Flags().GetBool("delete")
+	list, _ := cmd.Flags().GetBool("list")
+	if del && list {
+		return usageErrorf("options '-d' and '-l' cannot be used together")
+	}
+
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case del:
+		return deleteTags(cmd, repo, args)
+	case list || len(args) == 0:
+		return listTags(repo, args)
+	case len(args) > 2:
+		return usageErrorf("too many arguments")
+	default:
+		return createTag(cmd, repo, args)
+	}
+}
+
+// listTags prints the names of the tags matching any of patterns, or of
+// every tag when there are none.
+func listTags(repo *gogit.Repository, patterns []string) error {
+	all, err := repo.Refs().ListRefs()
+	if err != nil {
+		return err
+	}
+	for _, ref := range all {
+		name, ok := strings.CutPrefix(ref.Name, refs.TagsPrefix)
+		if !ok {
+			continue
+		}
+		match := len(patterns) == 0
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, name); ok {
+				match = true
+				break
+			}
+		}
+		if match {
+			fmt.Println(name)
+		}
+	}
+	return nil
+}
+
+// deleteTags deletes the named tags, reporting those that do not exist and
+// carrying on with the rest.
+func deleteTags(cmd *cobra.Command, repo *gogit.Repository, names []string) error {
+	failed := false
+	for _, name := range names {
+		oid, err := repo.DeleteTag(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Deleted tag '%s' (was %s)\n", name, oid.Short())
+	}
+
+	if failed {
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		return fmt.Errorf("failed to delete tags")
+	}
+	return nil
+}
+
+func createTag(cmd *cobra.Command, repo *gogit.Repository, args []string) error {
+	opts := gogit.TagOptions{Name: args[0]}
+	opts.Force, _ = cmd.Flags().GetBool("force")
+
+	target := "HEAD"
+	if len(args) > 1 {
+		target = args[1]
+	}
+	var err error
+	if opts.Target, err = repo.Resolve(target); err != nil {
+		return fmt.Errorf("failed to resolve '%s' as a valid ref.", target)
+	}
+
+	if opts.Signer, err = tagSigner(cmd, repo); err != nil {
+		return err
+	}
+	annotate, _ := cmd.Flags().GetBool("annotate")
+	opts.Annotated = annotate || opts.Signer != nil ||
+		cmd.Flags().Changed("message") || cmd.Flags().Changed("file")
+
+	if opts.Annotated {
+		if opts.Message, err = tagMessage(cmd, repo, opts.Name); err != nil {
+			return err
+		}
+	}
+
+	previous, err := repo.Refs().ReadRef(refs.TagsPrefix + opts.Name)
+	if err != nil {
+		return err
+	}
+	oid, err := repo.CreateTag(opts)
+	if err != nil {
+		return err
+	}
+	if !previous.IsZero() && previous != oid {
+		fmt.Printf("Updated tag '%s' (was %s)\n", opts.Name, previous.Short())
+	}
+	return nil
+}
+
+// tagSigner returns the signer for a new tag, or nil when it is not to be
+// signed: without -s or -u, tag.gpgSign decides.
+func tagSigner(cmd *cobra.Command, repo *gogit.Repository) (gogit.Signer, error) {
+	if noSign, _ := cmd.Flags().GetBool("no-sign"); noSign {
+		return nil, nil
+	}
+
+	sign, _ := cmd.Flags().GetBool("sign")
+	key, _ := cmd.Flags().GetString("local-user")
+	if !sign && !cmd.Flags().Changed("local-user") {
+		value, ok, err := lookupConfig(repo, tagSignConfigKey)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, nil
+		}
+		if sign, err = config.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("bad boolean config value '%s' for '%s'", value, tagSignConfigKey)
+		}
+		if !sign {
+			return nil, nil
+		}
+	}
+	return signer(repo, key)
+}
+
+// tagMessage returns the message of a new annotated tag, from -m or -F or
+// else edited in TAG_EDITMSG, and stripped of comments and surplus
+// whitespace.
+func tagMessage(cmd *cobra.Command, repo *gogit.Repository, name string) (string, error) {
+	messages, _ := cmd.Flags().GetStringArray("message")
+	msgFile, _ := cmd.Flags().GetString("file")
+
+	var text string
+	switch {
+	case cmd.Flags().Changed("message") && cmd.Flags().Changed("file"):
+		return "", usageErrorf("options '-m' and '-F' cannot be used together")
+	case cmd.Flags().Changed("message"):
+		text = strings.Join(messages, "\n\n")
+	case cmd.Flags().Changed("file"):
+		var data []byte
+		var err error
+		if msgFile == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(msgFile)
+		}
+		if err != nil {
+			return "", fmt.Errorf("could not open or read '%s': %w", msgFile, err)
+		}
+		text = string(data)
+	default:
+		editPath := filepath.Join(repo.GitDir(), tagEditMsgFile)
+		template := fmt.Sprintf("\n%c\n%c Write a message for tag:\n%c   %s\n%c Lines starting with '%c' will be ignored.\n",
+			gogit.CommentChar, gogit.CommentChar, gogit.CommentChar, name, gogit.CommentChar, gogit.CommentChar)
+		if err := os.WriteFile(editPath, []byte(template), file.ModeFile); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", tagEditMsgFile, err)
+		}
+		if err := launchEditor(repo, editPath); err != nil {
+			return "", fmt.Errorf("%w\nPlease supply the message using either -m or -F option.", err)
+		}
+		data, err := os.ReadFile(editPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", tagEditMsgFile, err)
+		}
+		text = string(data)
+	}
+
+	message := gogit.CleanupMessage(text, gogit.CleanupStrip, true)
+	if strings.TrimSpace(message) == "" {
+		return "", fmt.Errorf("no tag message?")
+	}
+	return message, nil
+}
@@ -0,0 +1,19 @@
+package commands
+
+import "fmt"
+
+// UsageError is a mistake in the options or arguments given to a command
+// that the command itself finds, once cobra has accepted them. Like cobra's
+// own usage errors, it exits with git's usage status.
+type UsageError struct {
+	msg string
+}
+
+func (e *UsageError) Error() string {
+	return e.msg
+}
+
+// usageErrorf returns a UsageError with the formatted message.
+func usageErrorf(format string, args ...any) error {
+	return &UsageError{msg: fmt.Sprintf(format, args...)}
+}
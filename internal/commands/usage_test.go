@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestConflictingOptionsAreUsageErrors(t *testing.T) {
+	tests := []struct {
+		cmd  *cobra.Command
+		args []string
+	}{
+		{NewCommitCmd(), []string{"-m", "x", "-F", "msg"}},
+		{NewCommitCmd(), []string{"--reset-author", "-m", "x"}},
+		{NewTagCmd(), []string{"-d", "-l"}},
+	}
+	for _, tt := range tests {
+		newTestWorkTree(t)
+		writeFiles(t, map[string]string{"msg": "x\n"})
+
+		tt.cmd.SetArgs(tt.args)
+		tt.cmd.SilenceErrors = true
+		tt.cmd.SilenceUsage = true
+		var usageErr *UsageError
+		if err := tt.cmd.Execute(); !errors.As(err, &usageErr) {
+			t.Errorf("%s %v: got %v, want a usage error", tt.cmd.Name(), tt.args, err)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package commit
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -26,3 +28,33 @@ func NewAuthor(name string, email string, time time.Time) *Author {
 		Time:  time,
 	}
 }
+
+// ParseAuthor decodes an identity of the form "Name <email> 1234567890 +0100".
+func ParseAuthor(s string) (*Author, error) {
+	open := strings.LastIndexByte(s, '<')
+	closing := strings.LastIndexByte(s, '>')
+	if open < 0 || closing < open {
+		return nil, fmt.Errorf("invalid identity %q", s)
+	}
+
+	fields := strings.Fields(s[closing+1:])
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("invalid timestamp in %q", s)
+	}
+
+	unix, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp in %q", s)
+	}
+
+	when := time.Unix(unix, 0).UTC()
+	if zone, err := time.Parse("-0700", fields[1]); err == nil {
+		when = when.In(zone.Location())
+	}
+
+	return &Author{
+		Name:  strings.TrimSpace(s[:open]),
+		Email: s[open+1 : closing],
+		Time:  when,
+	}, nil
+}
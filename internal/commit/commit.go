@@ -1,21 +1,41 @@
 package commit
 
-import "fmt"
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+// Headers that hold the signature of a commit. Repositories using SHA-256
+// sign under their own header so that the SHA-1 one stays free.
+const (
+	SignatureHeader       = "gpgsig"
+	SignatureHeaderSHA256 = "gpgsig-sha256"
+)
 
 type Commit struct {
-	oid string
+	oid object.ObjectID
 
-	ParentOID string
-	TreeOID   string
+	Parents   []object.ObjectID
+	TreeOID   object.ObjectID
 	Author    *Author
+	Committer *Author
 	Message   string
+
+	// Signature is the armored signature over the rest of the commit, if
+	// any, stored under SignatureHeader unless another header is named.
+	Signature       string
+	SignatureHeader string
 }
 
-func (c *Commit) SetOID(oid string) {
+func (c *Commit) SetOID(oid object.ObjectID) {
 	c.oid = oid
 }
 
-func (c *Commit) GetOID() string {
+func (c *Commit) GetOID() object.ObjectID {
 	return c.oid
 }
 
@@ -23,29 +43,176 @@ func (c *Commit) Type() string {
 	return "commit"
 }
 
+// ParentOID returns the first parent, or the zero ID for a root commit.
+func (c *Commit) ParentOID() object.ObjectID {
+	if len(c.Parents) == 0 {
+		return object.ObjectID{}
+	}
+	return c.Parents[0]
+}
+
+// Title returns the first line of the commit message.
+func (c *Commit) Title() string {
+	title, _, _ := strings.Cut(c.Message, "\n")
+	return title
+}
+
 func (c *Commit) Bytes() []byte {
-	authorBytes := c.Author.Bytes()
+	committer := c.Committer
+	if committer == nil {
+		committer = c.Author
+	}
 
 	var result []byte
 	result = fmt.Appendf(result, "tree %s\n", c.TreeOID)
 
-	if c.ParentOID != "" {
-		result = fmt.Appendf(result, "parent %s\n", c.ParentOID)
+	for _, parent := range c.Parents {
+		result = fmt.Appendf(result, "parent %s\n", parent)
 	}
 
-	result = fmt.Appendf(result, "author %s\ncommitter %s\n\n%s",
-		string(authorBytes),
-		string(authorBytes),
-		c.Message)
+	result = fmt.Appendf(result, "author %s\ncommitter %s\n",
+		string(c.Author.Bytes()),
+		string(committer.Bytes()))
+
+	if c.Signature != "" {
+		header := c.SignatureHeader
+		if header == "" {
+			header = SignatureHeader
+		}
+		// Lines after the first continue the header with a leading space
+		lines := strings.Split(strings.TrimSuffix(c.Signature, "\n"), "\n")
+		result = fmt.Appendf(result, "%s %s\n", header, strings.Join(lines, "\n "))
+	}
+
+	result = fmt.Appendf(result, "\n%s", c.Message)
 
 	return result
 }
 
-func NewCommit(parentOID string, treeOID string, author *Author, message string) *Commit {
+// SignatureHeaderFor returns the header commits in a repository using algo
+// are signed under.
+func SignatureHeaderFor(algo *hashalgo.Algorithm) string {
+	if algo == hashalgo.SHA256 {
+		return SignatureHeaderSHA256
+	}
+	return SignatureHeader
+}
+
+// ExtractSignature splits the raw contents of a commit into the signature
+// stored under header and the payload it was made over: everything else.
+// The signature is empty when there is none.
+func ExtractSignature(data []byte, header string) ([]byte, string) {
+	headers, _, found := bytes.Cut(data, []byte("\n\n"))
+	if !found {
+		headers = data
+	}
+
+	var payload []byte
+	var signature strings.Builder
+	inSignature := false
+	for offset := 0; offset < len(data); {
+		end := bytes.IndexByte(data[offset:], '\n') + 1
+		if end == 0 {
+			end = len(data) - offset
+		}
+		line := data[offset : offset+end]
+		inHeaders := offset < len(headers)
+		offset += end
+
+		if inHeaders {
+			if value, ok := bytes.CutPrefix(line, []byte(header+" ")); ok {
+				inSignature = true
+				signature.Write(value)
+				continue
+			}
+			if inSignature && line[0] == ' ' {
+				signature.Write(line[1:])
+				continue
+			}
+		}
+		inSignature = false
+		payload = append(payload, line...)
+	}
+	return payload, signature.String()
+}
+
+func NewCommit(parentOID object.ObjectID, treeOID object.ObjectID, author *Author, message string) *Commit {
+	var parents []object.ObjectID
+	if !parentOID.IsZero() {
+		parents = append(parents, parentOID)
+	}
+
 	return &Commit{
-		ParentOID: parentOID,
+		Parents:   parents,
 		TreeOID:   treeOID,
 		Author:    author,
+		Committer: author,
 		Message:   message,
 	}
 }
+
+// Parse decodes the raw contents of a commit object whose tree and parents
+// are object IDs of algo.
+func Parse(data []byte, algo *hashalgo.Algorithm) (*Commit, error) {
+	header, message, found := bytes.Cut(data, []byte("\n\n"))
+	if !found {
+		header, message = bytes.TrimSuffix(data, []byte("\n")), nil
+	}
+
+	c := &Commit{Message: string(message)}
+
+	inSignature := false
+	for _, line := range strings.Split(string(header), "\n") {
+		if rest, ok := strings.CutPrefix(line, " "); ok {
+			// Continuation of a multi-line header such as gpgsig.
+			if c.Signature != "" && inSignature {
+				c.Signature += rest + "\n"
+			}
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		inSignature = false
+		switch key {
+		case SignatureHeader, SignatureHeaderSHA256:
+			if c.Signature == "" || key == SignatureHeaderFor(algo) {
+				c.Signature, c.SignatureHeader = value+"\n", key
+				inSignature = true
+			}
+		case "tree":
+			oid, err := object.ParseOID(value, algo)
+			if err != nil {
+				return nil, fmt.Errorf("malformed tree line: %w", err)
+			}
+			c.TreeOID = oid
+		case "parent":
+			oid, err := object.ParseOID(value, algo)
+			if err != nil {
+				return nil, fmt.Errorf("malformed parent line: %w", err)
+			}
+			c.Parents = append(c.Parents, oid)
+		case "author", "committer":
+			ident, err := ParseAuthor(value)
+			if err != nil {
+				return nil, fmt.Errorf("malformed %s line: %w", key, err)
+			}
+			if key == "author" {
+				c.Author = ident
+			} else {
+				c.Committer = ident
+			}
+		}
+	}
+
+	if c.TreeOID.IsZero() {
+		return nil, fmt.Errorf("commit has no tree")
+	}
+	if c.Author == nil {
+		return nil, fmt.Errorf("commit has no author")
+	}
+	if c.Committer == nil {
+		c.Committer = c.Author
+	}
+
+	return c, nil
+}
@@ -0,0 +1,420 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/file"
+)
+
+// Config is a git configuration file such as .git/config. Lines are kept as
+// read so that saving preserves comments and formatting of untouched entries.
+type Config struct {
+	path  string
+	lines []*line
+}
+
+// line is one physical line of the file together with the section it belongs
+// to and, for variable lines, the normalized key and decoded value.
+type line struct {
+	text    string
+	section *section
+	key     string
+	value   string
+}
+
+// section identifies a "[name]" or "[name "subsection"]" header.
+type section struct {
+	name       string
+	subsection string
+}
+
+// Open reads the config file at path. A missing file yields an empty config
+// that will be created on Save.
+func Open(path string) (*Config, error) {
+	cfg := &Config{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+
+	if err := cfg.parse(data); err != nil {
+		return nil, fmt.Errorf("bad config file %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Get returns the last value set for key, written as "section.name" or
+// "section.subsection.name".
+func (c *Config) Get(key string) (string, bool) {
+	values := c.GetAll(key)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[len(values)-1], true
+}
+
+// GetAll returns every value set for key in file order.
+func (c *Config) GetAll(key string) []string {
+	key = normalizeKey(key)
+
+	var values []string
+	for _, l := range c.lines {
+		if l.key == key {
+			values = append(values, l.value)
+		}
+	}
+	return values
+}
+
+// Bool returns key interpreted as a git boolean, or def when it is unset.
+func (c *Config) Bool(key string, def bool) (bool, error) {
+	value, ok := c.Get(key)
+	if !ok {
+		return def, nil
+	}
+	return ParseBool(value)
+}
+
+// Int returns key interpreted as an integer with an optional k, m or g
+// suffix, or def when it is unset.
+func (c *Config) Int(key string, def int) (int, error) {
+	value, ok := c.Get(key)
+	if !ok {
+		return def, nil
+	}
+	return ParseInt(value)
+}
+
+// Set replaces the last value of key, adding it to its section (or a new
+// section) when it is not present yet.
+func (c *Config) Set(key string, value string) {
+	norm := normalizeKey(key)
+	for i := len(c.lines) - 1; i >= 0; i-- {
+		if c.lines[i].key == norm {
+			c.lines[i] = newVariableLine(c.lines[i].section, key, value)
+			return
+		}
+	}
+	c.Add(key, value)
+}
+
+// Add appends a new value for key without touching existing ones.
+func (c *Config) Add(key string, value string) {
+	norm := normalizeKey(key)
+	sec, _ := splitKey(norm)
+
+	// Insert after the last line of the last matching section.
+	insertAt := -1
+	var target *section
+	for i, l := range c.lines {
+		if l.section != nil && l.section.name == sec.name && l.section.subsection == sec.subsection {
+			insertAt = i
+			target = l.section
+		}
+	}
+
+	if insertAt < 0 {
+		target = sec
+		c.lines = append(c.lines, &line{text: sec.header(), section: target})
+		c.lines = append(c.lines, newVariableLine(target, key, value))
+		return
+	}
+
+	l := newVariableLine(target, key, value)
+	c.lines = append(c.lines[:insertAt+1], append([]*line{l}, c.lines[insertAt+1:]...)...)
+}
+
+// Unset removes every value of key and reports whether anything was removed.
+func (c *Config) Unset(key string) bool {
+	norm := normalizeKey(key)
+
+	kept := c.lines[:0]
+	removed := false
+	for _, l := range c.lines {
+		if l.key == norm {
+			removed = true
+			continue
+		}
+		kept = append(kept, l)
+	}
+	c.lines = kept
+
+	return removed
+}
+
+// Save writes the config back to disk atomically.
+func (c *Config) Save() error {
+	lock := file.NewLockfile(c.path)
+	acquired, err := lock.HoldForUpdate()
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return fmt.Errorf("could not lock config file %s", c.path)
+	}
+
+	var b strings.Builder
+	for _, l := range c.lines {
+		b.WriteString(l.text)
+		b.WriteByte('\n')
+	}
+
+	if err := lock.Write(b.String()); err != nil {
+		lock.Rollback()
+		return err
+	}
+
+	return lock.Commit()
+}
+
+func (c *Config) parse(data []byte) error {
+	var current *section
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		text := scanner.Text()
+
+		// A trailing backslash continues the logical line.
+		for strings.HasSuffix(text, "\\") && !strings.HasSuffix(text, "\\\\") && scanner.Scan() {
+			lineNo++
+			text += "\n" + scanner.Text()
+		}
+
+		trimmed := strings.TrimSpace(text)
+		switch {
+		case trimmed == "" || trimmed[0] == '#' || trimmed[0] == ';':
+			c.lines = append(c.lines, &line{text: text, section: current})
+		case trimmed[0] == '[':
+			sec, err := parseSection(trimmed)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			current = sec
+			c.lines = append(c.lines, &line{text: text, section: current})
+		default:
+			if current == nil {
+				return fmt.Errorf("line %d: variable outside of a section", lineNo)
+			}
+			name, value, err := parseVariable(trimmed)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			c.lines = append(c.lines, &line{
+				text:    text,
+				section: current,
+				key:     current.name + keySubsection(current) + "." + name,
+				value:   value,
+			})
+		}
+	}
+
+	return scanner.Err()
+}
+
+// parseSection parses "[name]", "[name "sub"]" and the legacy "[name.sub]".
+func parseSection(text string) (*section, error) {
+	end := strings.LastIndexByte(text, ']')
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated section header %q", text)
+	}
+	inner := strings.TrimSpace(text[1:end])
+
+	if name, rest, ok := strings.Cut(inner, " "); ok {
+		rest = strings.TrimSpace(rest)
+		if len(rest) < 2 || rest[0] != '"' || rest[len(rest)-1] != '"' {
+			return nil, fmt.Errorf("invalid subsection in %q", text)
+		}
+		sub := strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(rest[1 : len(rest)-1])
+		return &section{name: strings.ToLower(name), subsection: sub}, nil
+	}
+
+	if name, sub, ok := strings.Cut(inner, "."); ok {
+		return &section{name: strings.ToLower(name), subsection: strings.ToLower(sub)}, nil
+	}
+
+	if inner == "" {
+		return nil, fmt.Errorf("empty section header")
+	}
+	return &section{name: strings.ToLower(inner)}, nil
+}
+
+// parseVariable parses "name = value" or a bare "name", which means true.
+func parseVariable(text string) (string, string, error) {
+	name, raw, hasValue := strings.Cut(text, "=")
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", "", fmt.Errorf("missing variable name in %q", text)
+	}
+	if !hasValue {
+		return name, "true", nil
+	}
+
+	value, err := decodeValue(raw)
+	if err != nil {
+		return "", "", err
+	}
+	return name, value, nil
+}
+
+// decodeValue handles quoting, escapes, line continuations and trailing
+// comments in a raw value.
+func decodeValue(raw string) (string, error) {
+	var b strings.Builder
+	var pendingSpace strings.Builder
+	inQuotes := false
+	started := false
+
+	for i := 0; i < len(raw); i++ {
+		ch := raw[i]
+		switch {
+		case ch == '\\':
+			if i+1 >= len(raw) {
+				return "", fmt.Errorf("trailing backslash in value")
+			}
+			i++
+			b.WriteString(pendingSpace.String())
+			pendingSpace.Reset()
+			switch raw[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'b':
+				b.WriteByte('\b')
+			case '\\', '"':
+				b.WriteByte(raw[i])
+			case '\n':
+				// Line continuation.
+			default:
+				return "", fmt.Errorf("invalid escape sequence \\%c", raw[i])
+			}
+			started = true
+		case ch == '"':
+			inQuotes = !inQuotes
+			b.WriteString(pendingSpace.String())
+			pendingSpace.Reset()
+			started = true
+		case !inQuotes && (ch == '#' || ch == ';'):
+			i = len(raw)
+		case !inQuotes && (ch == ' ' || ch == '\t'):
+			if started {
+				pendingSpace.WriteByte(ch)
+			}
+		default:
+			b.WriteString(pendingSpace.String())
+			pendingSpace.Reset()
+			b.WriteByte(ch)
+			started = true
+		}
+	}
+
+	if inQuotes {
+		return "", fmt.Errorf("unterminated quoted value")
+	}
+	return b.String(), nil
+}
+
+// encodeValue quotes and escapes a value so that decodeValue returns it as is.
+func encodeValue(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`).Replace(value)
+	if value != strings.TrimSpace(value) || strings.ContainsAny(value, "#;") {
+		return `"` + escaped + `"`
+	}
+	return escaped
+}
+
+// newVariableLine renders a variable, keeping the caller's spelling of the
+// name (e.g. "refStorage") while indexing it by the normalized key.
+func newVariableLine(sec *section, key string, value string) *line {
+	_, name := splitKey(key)
+	return &line{
+		text:    fmt.Sprintf("\t%s = %s", name, encodeValue(value)),
+		section: sec,
+		key:     normalizeKey(key),
+		value:   value,
+	}
+}
+
+func (s *section) header() string {
+	if s.subsection == "" {
+		return fmt.Sprintf("[%s]", s.name)
+	}
+	sub := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s.subsection)
+	return fmt.Sprintf("[%s \"%s\"]", s.name, sub)
+}
+
+func keySubsection(s *section) string {
+	if s.subsection == "" {
+		return ""
+	}
+	return "." + s.subsection
+}
+
+// splitKey separates a normalized key into its section and variable name.
+func splitKey(key string) (*section, string) {
+	first := strings.IndexByte(key, '.')
+	last := strings.LastIndexByte(key, '.')
+	if first < 0 {
+		return &section{name: key}, ""
+	}
+
+	sec := &section{name: key[:first]}
+	if last > first {
+		sec.subsection = key[first+1 : last]
+	}
+	return sec, key[last+1:]
+}
+
+// normalizeKey lower-cases the section and variable name of key while
+// leaving the subsection untouched, as git does.
+func normalizeKey(key string) string {
+	sec, name := splitKey(key)
+	sec.name = strings.ToLower(sec.name)
+	return sec.name + keySubsection(sec) + "." + strings.ToLower(name)
+}
+
+// ParseBool interprets a git boolean value.
+func ParseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("bad boolean config value '%s'", value)
+}
+
+// ParseInt interprets a git integer value with an optional k, m or g suffix.
+func ParseInt(value string) (int, error) {
+	multiplier := 1
+	if n := len(value); n > 0 {
+		switch value[n-1] {
+		case 'k', 'K':
+			multiplier = 1 << 10
+		case 'm', 'M':
+			multiplier = 1 << 20
+		case 'g', 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier != 1 {
+			value = value[:n-1]
+		}
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("bad numeric config value '%s'", value)
+	}
+	return n * multiplier, nil
+}
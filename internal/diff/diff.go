@@ -0,0 +1,78 @@
+package diff
+
+import "bytes"
+
+// binaryCheckSize is how much of a file is searched for a NUL byte to
+// decide whether it is binary, as git does.
+const binaryCheckSize = 8000
+
+// IsBinary reports whether data is treated as binary rather than text: it
+// has a NUL byte near the start.
+func IsBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), binaryCheckSize)], 0) >= 0
+}
+
+// Lines splits data into lines, each keeping its newline. A last line
+// without one is still a line.
+func Lines(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		end := bytes.IndexByte(data, '\n') + 1
+		if end == 0 {
+			end = len(data)
+		}
+		lines = append(lines, string(data[:end]))
+		data = data[end:]
+	}
+	return lines
+}
+
+// Count returns how many lines a shortest edit script turning a into b
+// inserts and deletes.
+func Count(a []string, b []string) (insertions int, deletions int) {
+	// Lines shared at either end are never part of the script
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	// Every shortest script inserts and deletes the same number of lines,
+	// so its length d, together with the difference in length, is enough.
+	d := editDistance(a, b)
+	insertions = (d + len(b) - len(a)) / 2
+	return insertions, d - insertions
+}
+
+// editDistance returns the length of a shortest edit script turning a into
+// b, counting insertions and deletions, by Myers' O(ND) algorithm.
+func editDistance(a []string, b []string) int {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit == 0 {
+		return 0
+	}
+
+	// v[k+limit] is the furthest x reached on diagonal k = x - y
+	v := make([]int, 2*limit+2)
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+limit] < v[k+1+limit]) {
+				x = v[k+1+limit]
+			} else {
+				x = v[k-1+limit] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[k+limit] = x
+			if x >= n && y >= m {
+				return d
+			}
+		}
+	}
+	return limit
+}
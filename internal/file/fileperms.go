@@ -3,6 +3,7 @@ package file
 import "os"
 
 const (
-	ModeFile = os.FileMode(0o644)
-	ModeDir  = os.FileMode(0o755)
+	ModeFile       = os.FileMode(0o644)
+	ModeExecutable = os.FileMode(0o755)
+	ModeDir        = os.FileMode(0o755)
 )
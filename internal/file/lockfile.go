@@ -91,6 +91,25 @@ func (l *Lockfile) Commit() error {
 	return nil
 }
 
+// Rollback releases the lock by closing the handle and removing the lock file,
+// leaving the original file untouched.
+func (l *Lockfile) Rollback() error {
+	if err := l.ensureLock(); err != nil {
+		return err
+	}
+
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(l.lockPath); err != nil {
+		return err
+	}
+
+	l.file = nil
+	return nil
+}
+
 func (l *Lockfile) ensureLock() error {
 	if l.file == nil {
 		return fmt.Errorf("not holding lock on file: %s", l.lockPath)
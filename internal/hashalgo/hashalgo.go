@@ -0,0 +1,90 @@
+package hashalgo
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"path/filepath"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/config"
+)
+
+const (
+	// ConfigKey is the config key naming the object format of a repository.
+	// Repositories without it use SHA-1.
+	ConfigKey = "extensions.objectFormat"
+
+	// MaxSize is the raw size of the longest digest of any algorithm.
+	MaxSize = sha256.Size
+)
+
+// Algorithm is a hash function used to name objects. Object IDs, tree
+// entries, index entries and ref values all take their size from it.
+type Algorithm struct {
+	// Name is the object format as spelled in config and on the command line.
+	Name string
+	// Size is the length of a raw object ID in bytes.
+	Size int
+	// FormatID is the four byte identifier used by binary formats such as
+	// reftable to record the hash in use.
+	FormatID string
+
+	newHash func() hash.Hash
+}
+
+var (
+	// SHA1 is the default object format.
+	SHA1 = &Algorithm{Name: "sha1", Size: sha1.Size, FormatID: "sha1", newHash: sha1.New}
+	// SHA256 is the object format of repositories created with
+	// --object-format=sha256.
+	SHA256 = &Algorithm{Name: "sha256", Size: sha256.Size, FormatID: "s256", newHash: sha256.New}
+)
+
+// Lookup returns the algorithm called name.
+func Lookup(name string) (*Algorithm, error) {
+	switch strings.ToLower(name) {
+	case SHA1.Name:
+		return SHA1, nil
+	case SHA256.Name:
+		return SHA256, nil
+	default:
+		return nil, fmt.Errorf("unknown hash algorithm '%s'", name)
+	}
+}
+
+// Load returns the object format configured for the repository at gitPath.
+func Load(gitPath string) (*Algorithm, error) {
+	cfg, err := config.Open(filepath.Join(gitPath, "config"))
+	if err != nil {
+		return nil, err
+	}
+	return FromConfig(cfg)
+}
+
+// FromConfig returns the object format named by cfg, SHA-1 when unset.
+func FromConfig(cfg *config.Config) (*Algorithm, error) {
+	value, ok := cfg.Get(ConfigKey)
+	if !ok {
+		return SHA1, nil
+	}
+	return Lookup(value)
+}
+
+// New returns a fresh hash.Hash computing the algorithm.
+func (a *Algorithm) New() hash.Hash {
+	return a.newHash()
+}
+
+// Sum returns the raw digest of data.
+func (a *Algorithm) Sum(data []byte) []byte {
+	h := a.newHash()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// HexSize is the length of an object ID in hex digits.
+func (a *Algorithm) HexSize() int {
+	return 2 * a.Size
+}
@@ -1,10 +1,14 @@
 package index
 
 import (
+	"bytes"
 	"encoding/binary"
-	"encoding/hex"
+	"fmt"
 	"os"
 	"syscall"
+
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/object"
 )
 
 const (
@@ -16,6 +20,10 @@ const (
 	MaxPathSize = 0xfff
 	// EntryBlock is the block size for entry padding (8 bytes)
 	EntryBlock = 8
+	// statSize is the part of an entry before the OID: 10 uint32s
+	statSize = 10 * 4
+	// extendedFlag marks version 3 entries carrying a second flags word
+	extendedFlag = 0x4000
 )
 
 // Entry represents an entry in the git index file.
@@ -30,13 +38,13 @@ type Entry struct {
 	UID       uint32
 	GID       uint32
 	Size      uint32
-	OID       string
+	OID       object.ObjectID
 	Flags     uint16
 	Path      string
 }
 
 // CreateEntry creates a new Entry from a pathname, OID, and file stat.
-func CreateEntry(pathname string, oid string, stat os.FileInfo) *Entry {
+func CreateEntry(pathname string, oid object.ObjectID, stat os.FileInfo) *Entry {
 	path := pathname
 	mode := RegularMode
 	if stat.Mode()&0111 != 0 {
@@ -90,11 +98,106 @@ func CreateEntry(pathname string, oid string, stat os.FileInfo) *Entry {
 	}
 }
 
+// CreateEntryFromDB creates an Entry for a blob read from a tree, with no
+// stat information.
+func CreateEntryFromDB(pathname string, oid object.ObjectID, mode uint32) *Entry {
+	flags := uint16(len(pathname))
+	if flags > MaxPathSize {
+		flags = MaxPathSize
+	}
+
+	return &Entry{
+		Mode:  mode,
+		OID:   oid,
+		Flags: flags,
+		Path:  pathname,
+	}
+}
+
+// ParseEntry decodes an entry from the start of data, whose OID was written
+// by algo, and returns it along with the number of bytes it occupied,
+// including padding.
+func ParseEntry(data []byte, algo *hashalgo.Algorithm) (*Entry, int, error) {
+	// The fixed part of an entry: 10 uint32s, the OID and flags
+	flagsStart := statSize + algo.Size
+	entryMinSize := flagsStart + 2
+	if len(data) < entryMinSize {
+		return nil, 0, fmt.Errorf("truncated entry")
+	}
+
+	field := func(i int) uint32 {
+		return binary.BigEndian.Uint32(data[i*4:])
+	}
+
+	oid, err := object.OIDFromBytes(data[statSize:flagsStart], algo)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entry := &Entry{
+		CTime:     int64(field(0)),
+		CTimeNsec: int32(field(1)),
+		MTime:     int64(field(2)),
+		MTimeNsec: int32(field(3)),
+		Dev:       field(4),
+		Ino:       field(5),
+		Mode:      field(6),
+		UID:       field(7),
+		GID:       field(8),
+		Size:      field(9),
+		OID:       oid,
+		Flags:     binary.BigEndian.Uint16(data[flagsStart:entryMinSize]),
+	}
+
+	// Extended flags are dropped since entries are always written as version 2.
+	pathStart := entryMinSize
+	if entry.Flags&extendedFlag != 0 {
+		pathStart += 2
+		entry.Flags &^= extendedFlag
+	}
+	if pathStart > len(data) {
+		return nil, 0, fmt.Errorf("truncated entry")
+	}
+
+	nul := bytes.IndexByte(data[pathStart:], 0)
+	if nul < 0 {
+		return nil, 0, fmt.Errorf("unterminated entry path")
+	}
+	entry.Path = string(data[pathStart : pathStart+nul])
+
+	// The path is followed by 1-8 NUL bytes so the entry size is a multiple of 8.
+	size := pathStart + nul + 1
+	for size%EntryBlock != 0 {
+		size++
+	}
+	if size > len(data) {
+		return nil, 0, fmt.Errorf("truncated entry padding")
+	}
+
+	return entry, size, nil
+}
+
+// StatMatch reports whether the size and executable bit recorded in the entry
+// agree with stat. Entries without stat information always match on size.
+func (e *Entry) StatMatch(stat os.FileInfo) bool {
+	other := CreateEntry(e.Path, e.OID, stat)
+	sizeMatch := e.Size == 0 || e.Size == other.Size
+	return sizeMatch && e.Mode == other.Mode
+}
+
+// TimesMatch reports whether the entry's ctime and mtime equal those in stat,
+// in which case the file is assumed to be unchanged.
+func (e *Entry) TimesMatch(stat os.FileInfo) bool {
+	other := CreateEntry(e.Path, e.OID, stat)
+	return e.CTime == other.CTime && e.CTimeNsec == other.CTimeNsec &&
+		e.MTime == other.MTime && e.MTimeNsec == other.MTimeNsec
+}
+
 // Bytes returns the binary representation of the entry.
-// Format: N10H40nZ* (10 uint32s, 40 hex bytes, 1 uint16, null-terminated string)
+// Format: 10 uint32s, raw OID, 1 uint16, null-terminated string
 // Padded to multiples of EntryBlock (8) bytes.
 func (e *Entry) Bytes() []byte {
-	buf := make([]byte, 0, 64+len(e.Path)+8)
+	buf := make([]byte, 0, statSize+e.OID.Size()+2+len(e.Path)+8)
 
 	// Write 10 uint32 values (big-endian)
 	buf = binary.BigEndian.AppendUint32(buf, uint32(e.CTime))
@@ -108,19 +211,8 @@ func (e *Entry) Bytes() []byte {
 	buf = binary.BigEndian.AppendUint32(buf, e.GID)
 	buf = binary.BigEndian.AppendUint32(buf, e.Size)
 
-	// Write OID as 40 hex bytes
-	oidBytes, err := hex.DecodeString(e.OID)
-	if err != nil {
-		// If OID is not valid hex, pad with zeros
-		oidBytes = make([]byte, 20)
-	}
-	if len(oidBytes) < 20 {
-		// Pad to 20 bytes if needed
-		padded := make([]byte, 20)
-		copy(padded, oidBytes)
-		oidBytes = padded
-	}
-	buf = append(buf, oidBytes...)
+	// Write the raw OID
+	buf = append(buf, e.OID.Bytes()...)
 
 	// Write flags as uint16 (big-endian)
 	buf = binary.BigEndian.AppendUint16(buf, e.Flags)
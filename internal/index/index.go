@@ -1,13 +1,16 @@
 package index
 
 import (
-	"crypto/sha1"
+	"bytes"
 	"encoding/binary"
 	"fmt"
-	"hash"
 	"os"
+	"path"
+	"sort"
+	"strings"
 
-	"github.com/shanmugharajk/gogit/internal/file"
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/object"
 )
 
 const (
@@ -16,78 +19,256 @@ const (
 	// Version = 2 (uint32, big-endian)
 	// Entry count = uint32 (big-endian)
 	headerSize = 4 + 4 + 4 // "DIRC" + version + entry count
+
+	signature = "DIRC"
+	version   = 2
 )
 
 // Index represents the git index file.
 type Index struct {
-	entries  map[string]*Entry
-	lockfile *file.Lockfile
-	digest   hash.Hash
+	store   Store
+	algo    *hashalgo.Algorithm
+	entries map[string]*Entry
+	locked  bool
+}
+
+// New creates a new Index at the specified pathname for a repository whose
+// objects are named by algo.
+func New(pathname string, algo *hashalgo.Algorithm) *Index {
+	return NewWithStore(NewFileStore(pathname), algo)
 }
 
-// New creates a new Index at the specified pathname.
-func New(pathname string) *Index {
+// NewWithStore creates a new Index kept in store for a repository whose
+// objects are named by algo.
+func NewWithStore(store Store, algo *hashalgo.Algorithm) *Index {
 	return &Index{
-		entries:  make(map[string]*Entry),
-		lockfile: file.NewLockfile(pathname),
+		store:   store,
+		algo:    algo,
+		entries: make(map[string]*Entry),
 	}
 }
 
-// Add adds an entry to the index.
-func (idx *Index) Add(pathname string, oid string, stat os.FileInfo) {
-	entry := CreateEntry(pathname, oid, stat)
-	idx.entries[pathname] = entry
+// Load reads the index from its store, replacing any entries in memory.
+// A missing index yields an empty index.
+func (idx *Index) Load() error {
+	idx.entries = make(map[string]*Entry)
+
+	data, err := idx.store.Read()
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return nil
+	}
+
+	return idx.parse(data)
 }
 
-// WriteUpdates writes the index to disk.
-func (idx *Index) WriteUpdates() error {
-	held, err := idx.lockfile.HoldForUpdate()
+// LoadForUpdate acquires the index lock and then loads the index, so that
+// the entries cannot change before WriteUpdates or Release is called.
+func (idx *Index) LoadForUpdate() error {
+	if err := idx.lock(); err != nil {
+		return err
+	}
+
+	if err := idx.Load(); err != nil {
+		idx.Release()
+		return err
+	}
+	return nil
+}
+
+// Release drops the lock taken by LoadForUpdate without writing anything.
+func (idx *Index) Release() error {
+	if !idx.locked {
+		return fmt.Errorf("not holding the index lock")
+	}
+	idx.locked = false
+	return idx.store.Rollback()
+}
+
+// lock takes the index lock unless it is already held.
+func (idx *Index) lock() error {
+	if idx.locked {
+		return nil
+	}
+	held, err := idx.store.Lock()
 	if err != nil {
 		return fmt.Errorf("failed to hold lock: %w", err)
 	}
 	if !held {
 		return fmt.Errorf("index lock is held by another process")
 	}
+	idx.locked = true
+	return nil
+}
+
+// Add adds an entry to the index.
+func (idx *Index) Add(pathname string, oid object.ObjectID, stat os.FileInfo) {
+	entry := CreateEntry(pathname, oid, stat)
+	idx.discardConflicts(pathname)
+	idx.entries[pathname] = entry
+}
+
+// AddFromDB adds an entry for a blob taken from a tree in the object
+// database. An entry already staged with the same blob and mode is kept
+// with its stat information; otherwise the new entry has none, so the next
+// comparison with the workspace falls back to comparing contents.
+func (idx *Index) AddFromDB(pathname string, oid object.ObjectID, mode uint32) {
+	if entry, ok := idx.entries[pathname]; ok && entry.OID == oid && entry.Mode == mode {
+		return
+	}
+	idx.discardConflicts(pathname)
+	idx.entries[pathname] = CreateEntryFromDB(pathname, oid, mode)
+}
 
-	// Begin write - initialize SHA1 digest
-	idx.digest = sha1.New()
+// Remove deletes the entry for pathname or, when pathname is a directory,
+// every entry beneath it. It reports whether anything was removed.
+func (idx *Index) Remove(pathname string) bool {
+	entries := idx.EntriesUnder(pathname)
+	for _, entry := range entries {
+		delete(idx.entries, entry.Path)
+	}
+	return len(entries) > 0
+}
 
-	// Write header: "DIRC" + version (2) + entry count
-	header := make([]byte, headerSize)
-	copy(header[0:4], []byte("DIRC"))
-	binary.BigEndian.PutUint32(header[4:8], 2) // version
-	binary.BigEndian.PutUint32(header[8:12], uint32(len(idx.entries)))
+// IsTracked reports whether pathname is a file in the index or a directory
+// containing indexed files.
+func (idx *Index) IsTracked(pathname string) bool {
+	return len(idx.EntriesUnder(pathname)) > 0
+}
 
-	if err := idx.write(header); err != nil {
+// IsTrackedDirectory reports whether pathname is a directory containing
+// indexed files.
+func (idx *Index) IsTrackedDirectory(pathname string) bool {
+	_, isFile := idx.entries[pathname]
+	return !isFile && idx.IsTracked(pathname)
+}
+
+// EntriesUnder returns the entry for pathname itself, or the entries of all
+// files beneath it when pathname is a directory, sorted by path.
+func (idx *Index) EntriesUnder(pathname string) []*Entry {
+	if entry, ok := idx.entries[pathname]; ok {
+		return []*Entry{entry}
+	}
+
+	prefix := pathname + "/"
+	var entries []*Entry
+	for _, entry := range idx.Entries() {
+		if pathname == "." || strings.HasPrefix(entry.Path, prefix) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// discardConflicts removes entries that cannot coexist with a file at
+// pathname: files named like one of its parent directories, and files
+// beneath pathname when it used to be a directory.
+func (idx *Index) discardConflicts(pathname string) {
+	for dir := path.Dir(pathname); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		delete(idx.entries, dir)
+	}
+
+	prefix := pathname + "/"
+	for name := range idx.entries {
+		if strings.HasPrefix(name, prefix) {
+			delete(idx.entries, name)
+		}
+	}
+}
+
+// Clear removes every entry from the index.
+func (idx *Index) Clear() {
+	idx.entries = make(map[string]*Entry)
+}
+
+// Entry returns the entry stored for pathname.
+func (idx *Index) Entry(pathname string) (*Entry, bool) {
+	entry, ok := idx.entries[pathname]
+	return entry, ok
+}
+
+// Entries returns all entries sorted by path, the order git stores them in.
+func (idx *Index) Entries() []*Entry {
+	entries := make([]*Entry, 0, len(idx.entries))
+	for _, entry := range idx.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return entries
+}
+
+// WriteUpdates writes the index to its store and releases the lock.
+func (idx *Index) WriteUpdates() error {
+	if err := idx.lock(); err != nil {
 		return err
 	}
 
-	// Write entries
+	// Entries with IDs of another hash algorithm would corrupt the file
 	for _, entry := range idx.entries {
-		entryBytes := entry.Bytes()
-		if err := idx.write(entryBytes); err != nil {
-			return err
+		if entry.OID.Size() != idx.algo.Size {
+			idx.Release()
+			return fmt.Errorf("index entry %s has an invalid %s object ID", entry.Path, idx.algo.Name)
 		}
 	}
 
-	// Finish write - append SHA1 checksum
-	checksum := idx.digest.Sum(nil)
-	if err := idx.lockfile.WriteBytes(checksum); err != nil {
-		return fmt.Errorf("failed to write checksum: %w", err)
+	var buf bytes.Buffer
+
+	// Write header: "DIRC" + version (2) + entry count
+	header := make([]byte, headerSize)
+	copy(header[0:4], []byte(signature))
+	binary.BigEndian.PutUint32(header[4:8], version)
+	binary.BigEndian.PutUint32(header[8:12], uint32(len(idx.entries)))
+	buf.Write(header)
+
+	// Write entries
+	for _, entry := range idx.Entries() {
+		buf.Write(entry.Bytes())
 	}
 
-	if err := idx.lockfile.Commit(); err != nil {
+	// Finish write - append the checksum
+	buf.Write(idx.algo.Sum(buf.Bytes()))
+
+	idx.locked = false
+	if err := idx.store.Commit(buf.Bytes()); err != nil {
 		return fmt.Errorf("failed to commit index: %w", err)
 	}
 
 	return nil
 }
 
-// write writes data to the lockfile and updates the digest.
-func (idx *Index) write(data []byte) error {
-	if err := idx.lockfile.WriteBytes(data); err != nil {
-		return err
+// parse decodes the header, entries and trailing checksum of an index file.
+// Extensions written by other tools are skipped.
+func (idx *Index) parse(data []byte) error {
+	if len(data) < headerSize+idx.algo.Size {
+		return fmt.Errorf("index file is too short")
+	}
+
+	body := data[:len(data)-idx.algo.Size]
+	if !bytes.Equal(idx.algo.Sum(body), data[len(body):]) {
+		return fmt.Errorf("index checksum does not match its contents")
+	}
+
+	if string(body[0:4]) != signature {
+		return fmt.Errorf("index signature: expected %q but found %q", signature, body[0:4])
 	}
-	idx.digest.Write(data)
+	if v := binary.BigEndian.Uint32(body[4:8]); v != 2 && v != 3 {
+		return fmt.Errorf("unsupported index version %d", v)
+	}
+	count := binary.BigEndian.Uint32(body[8:12])
+
+	offset := headerSize
+	for i := uint32(0); i < count; i++ {
+		entry, size, err := ParseEntry(body[offset:], idx.algo)
+		if err != nil {
+			return fmt.Errorf("index entry %d: %w", i, err)
+		}
+		idx.entries[entry.Path] = entry
+		offset += size
+	}
+
 	return nil
 }
@@ -0,0 +1,96 @@
+package index
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+// indexStores returns, for every kind of store, two handles on one fresh
+// index, as two processes would hold them, so that MemoryStore is held to
+// the behavior of FileStore.
+func indexStores(t *testing.T) map[string][2]Store {
+	t.Helper()
+	memory := NewMemoryStore()
+	path := filepath.Join(t.TempDir(), "index")
+	return map[string][2]Store{
+		"memory": {memory, memory},
+		"file":   {NewFileStore(path), NewFileStore(path)},
+	}
+}
+
+func TestStoreLocking(t *testing.T) {
+	for name, stores := range indexStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store, other := stores[0], stores[1]
+			if data, err := store.Read(); err != nil || data != nil {
+				t.Fatalf("Read before any Commit = %q, %v", data, err)
+			}
+
+			if held, err := store.Lock(); err != nil || !held {
+				t.Fatalf("Lock = %v, %v", held, err)
+			}
+			if held, err := other.Lock(); err != nil || held {
+				t.Fatalf("Lock by another holder = %v, %v, want false", held, err)
+			}
+			if err := store.Commit([]byte("first")); err != nil {
+				t.Fatal(err)
+			}
+			if data, err := other.Read(); err != nil || string(data) != "first" {
+				t.Errorf("Read after Commit = %q, %v", data, err)
+			}
+
+			if held, err := other.Lock(); err != nil || !held {
+				t.Fatalf("Lock after Commit = %v, %v", held, err)
+			}
+			if err := other.Rollback(); err != nil {
+				t.Fatal(err)
+			}
+			if data, err := store.Read(); err != nil || string(data) != "first" {
+				t.Errorf("Read after Rollback = %q, %v", data, err)
+			}
+			if held, err := store.Lock(); err != nil || !held {
+				t.Errorf("Lock after Rollback = %v, %v", held, err)
+			}
+		})
+	}
+}
+
+func TestIndexRoundTripsThroughStore(t *testing.T) {
+	oid, err := object.ParseOID(strings.Repeat("a", 40), hashalgo.SHA1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, stores := range indexStores(t) {
+		t.Run(name, func(t *testing.T) {
+			idx := NewWithStore(stores[0], hashalgo.SHA1)
+			if err := idx.LoadForUpdate(); err != nil {
+				t.Fatal(err)
+			}
+			idx.AddFromDB("dir/file.txt", oid, 0o100644)
+			idx.AddFromDB("run.sh", oid, 0o100755)
+			if err := idx.WriteUpdates(); err != nil {
+				t.Fatal(err)
+			}
+
+			reloaded := NewWithStore(stores[1], hashalgo.SHA1)
+			if err := reloaded.Load(); err != nil {
+				t.Fatal(err)
+			}
+			entries := reloaded.Entries()
+			if len(entries) != 2 || entries[0].Path != "dir/file.txt" || entries[1].Path != "run.sh" {
+				t.Fatalf("Entries = %+v", entries)
+			}
+			if entries[1].OID != oid || entries[1].Mode != 0o100755 {
+				t.Errorf("run.sh = %s %o", entries[1].OID, entries[1].Mode)
+			}
+			if !reloaded.IsTrackedDirectory("dir") {
+				t.Errorf("dir is not tracked as a directory")
+			}
+		})
+	}
+}
@@ -8,24 +8,23 @@ type Object interface {
 	// Bytes returns the raw data of the object.
 	Bytes() []byte
 
-	// SetOID sets the object identifier (SHA1 hash).
-	SetOID(oid string)
+	// SetOID sets the object identifier, the hash of the serialized object.
+	SetOID(oid ObjectID)
 
 	// GetOID returns the object identifier.
-	GetOID() string
+	GetOID() ObjectID
 }
 
 // Blob represents a git blob object containing arbitrary data.
 type Blob struct {
 	data []byte
-	oid  string
+	oid  ObjectID
 }
 
 // NewBlob creates a new Blob with the given data.
 func NewBlob(data []byte) *Blob {
 	return &Blob{
 		data: data,
-		oid:  "",
 	}
 }
 
@@ -40,11 +39,11 @@ func (b *Blob) Bytes() []byte {
 }
 
 // SetOID sets the object identifier for this blob.
-func (b *Blob) SetOID(oid string) {
+func (b *Blob) SetOID(oid ObjectID) {
 	b.oid = oid
 }
 
 // GetOID returns the object identifier of this blob.
-func (b *Blob) GetOID() string {
+func (b *Blob) GetOID() ObjectID {
 	return b.oid
 }
@@ -3,21 +3,25 @@ package object
 import (
 	"os"
 	"path/filepath"
+	"strconv"
 )
 
 const (
 	RegularMode    = "100644"
 	ExecutableMode = "100755"
 	DirectoryMode  = "40000"
+	SymlinkMode    = "120000"
+	GitlinkMode    = "160000"
 )
 
 type Entry struct {
 	Name string
-	OID  string
+	OID  ObjectID
 	stat os.FileInfo
+	mode string
 }
 
-func NewEntry(name string, oid string, stat os.FileInfo) *Entry {
+func NewEntry(name string, oid ObjectID, stat os.FileInfo) *Entry {
 	return &Entry{
 		Name: name,
 		OID:  oid,
@@ -25,18 +29,31 @@ func NewEntry(name string, oid string, stat os.FileInfo) *Entry {
 	}
 }
 
+// NewEntryWithMode creates an entry whose mode is known without a file
+// stat, such as one taken from the index.
+func NewEntryWithMode(name string, oid ObjectID, mode string) *Entry {
+	return &Entry{
+		Name: name,
+		OID:  oid,
+		mode: mode,
+	}
+}
+
 // Basename returns the base name of the entry path.
 func (e *Entry) Basename() string {
 	return filepath.Base(e.Name)
 }
 
-// GetOID returns the object ID (SHA-1 hash) of the entry.
-func (e *Entry) GetOID() string {
+// GetOID returns the object ID of the entry.
+func (e *Entry) GetOID() ObjectID {
 	return e.OID
 }
 
 // Mode returns the file mode as a string (e.g., "100644" or "100755").
 func (e *Entry) Mode() string {
+	if e.stat == nil {
+		return e.mode
+	}
 	if e.stat.Mode()&0111 != 0 {
 		return ExecutableMode
 	}
@@ -60,3 +77,54 @@ func (e *Entry) ParentDirectories() []string {
 
 	return parents
 }
+
+// StoredEntry is a tree entry read back from the object database, which
+// carries its mode directly rather than a file stat.
+type StoredEntry struct {
+	name     string
+	OID      ObjectID
+	FileMode string
+}
+
+// NewStoredEntry creates an entry for a tree read from the database.
+func NewStoredEntry(name string, oid ObjectID, mode string) *StoredEntry {
+	return &StoredEntry{
+		name:     name,
+		OID:      oid,
+		FileMode: mode,
+	}
+}
+
+// Basename returns the name of the entry within its tree.
+func (e *StoredEntry) Basename() string {
+	return e.name
+}
+
+// GetOID returns the object ID of the entry.
+func (e *StoredEntry) GetOID() ObjectID {
+	return e.OID
+}
+
+// Mode returns the file mode as stored in the tree.
+func (e *StoredEntry) Mode() string {
+	return e.FileMode
+}
+
+// IsTree reports whether the entry refers to a subtree.
+func (e *StoredEntry) IsTree() bool {
+	return e.FileMode == DirectoryMode
+}
+
+// ParseMode converts an octal mode string such as "100644" to its numeric form.
+func ParseMode(mode string) (uint32, error) {
+	n, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(n), nil
+}
+
+// FormatMode converts a numeric mode to the octal string used in trees.
+func FormatMode(mode uint32) string {
+	return strconv.FormatUint(uint64(mode), 8)
+}
@@ -0,0 +1,91 @@
+package object
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+)
+
+// ShortOIDLength is the number of hex digits shown for abbreviated object IDs.
+const ShortOIDLength = 7
+
+// ObjectID names an object by the hash of its serialized form. IDs are
+// values: they compare with == and can be used as map keys. The zero value
+// names no object.
+type ObjectID struct {
+	raw  [hashalgo.MaxSize]byte
+	size uint8
+}
+
+// ParseOID decodes s, which must be a full hex object ID of algo.
+func ParseOID(s string, algo *hashalgo.Algorithm) (ObjectID, error) {
+	var id ObjectID
+	if len(s) != algo.HexSize() {
+		return id, fmt.Errorf("invalid %s object ID '%s'", algo.Name, s)
+	}
+	if _, err := hex.Decode(id.raw[:algo.Size], []byte(s)); err != nil {
+		return id, fmt.Errorf("invalid %s object ID '%s'", algo.Name, s)
+	}
+	id.size = uint8(algo.Size)
+	return id, nil
+}
+
+// OIDFromBytes returns the object ID whose raw form is raw, which must be
+// exactly as long as a digest of algo.
+func OIDFromBytes(raw []byte, algo *hashalgo.Algorithm) (ObjectID, error) {
+	var id ObjectID
+	if len(raw) != algo.Size {
+		return id, fmt.Errorf("invalid %s object ID: %d bytes", algo.Name, len(raw))
+	}
+	copy(id.raw[:], raw)
+	id.size = uint8(algo.Size)
+	return id, nil
+}
+
+// NullOID returns the all-zero object ID of algo, which stands for a missing
+// object in reflogs and similar records.
+func NullOID(algo *hashalgo.Algorithm) ObjectID {
+	return ObjectID{size: uint8(algo.Size)}
+}
+
+// IsZero reports whether id names no object: it is either the zero value or
+// a null ID.
+func (id ObjectID) IsZero() bool {
+	return id.raw == [hashalgo.MaxSize]byte{}
+}
+
+// Size returns the length of the raw ID in bytes, zero for the zero value.
+func (id ObjectID) Size() int {
+	return int(id.size)
+}
+
+// Bytes returns the raw form of id.
+func (id ObjectID) Bytes() []byte {
+	return id.raw[:id.size]
+}
+
+// String returns the hex form of id, or an empty string for the zero value.
+func (id ObjectID) String() string {
+	return hex.EncodeToString(id.raw[:id.size])
+}
+
+// Abbrev returns the first n hex digits of id.
+func (id ObjectID) Abbrev(n int) string {
+	s := id.String()
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// Short abbreviates id for display.
+func (id ObjectID) Short() string {
+	return id.Abbrev(ShortOIDLength)
+}
+
+// Compare orders IDs by their raw bytes, as git sorts them.
+func (id ObjectID) Compare(other ObjectID) int {
+	return bytes.Compare(id.Bytes(), other.Bytes())
+}
@@ -0,0 +1,37 @@
+package object
+
+// Raw is an object of any type kept as its undecoded contents. It lets
+// plumbing commands hash and store objects without parsing them.
+type Raw struct {
+	objType string
+	data    []byte
+	oid     ObjectID
+}
+
+// NewRaw creates a Raw object of the given type holding data.
+func NewRaw(objType string, data []byte) *Raw {
+	return &Raw{
+		objType: objType,
+		data:    data,
+	}
+}
+
+// Type returns the type the object was created with.
+func (r *Raw) Type() string {
+	return r.objType
+}
+
+// Bytes returns the object's contents.
+func (r *Raw) Bytes() []byte {
+	return r.data
+}
+
+// SetOID sets the object identifier for this object.
+func (r *Raw) SetOID(oid ObjectID) {
+	r.oid = oid
+}
+
+// GetOID returns the object identifier of this object.
+func (r *Raw) GetOID() ObjectID {
+	return r.oid
+}
@@ -1,21 +1,23 @@
 package object
 
 import (
-	"encoding/hex"
+	"bytes"
 	"fmt"
 	"path/filepath"
 	"sort"
+
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
 )
 
 // TreeEntry represents an entry in a tree, which can be either an Entry or another Tree.
 type TreeEntry interface {
 	Basename() string
 	Mode() string
-	GetOID() string
+	GetOID() ObjectID
 }
 
 type Tree struct {
-	oid     string
+	oid     ObjectID
 	entries map[string]TreeEntry
 }
 
@@ -29,11 +31,11 @@ func (t *Tree) Type() string {
 	return "tree"
 }
 
-func (t *Tree) SetOID(oid string) {
+func (t *Tree) SetOID(oid ObjectID) {
 	t.oid = oid
 }
 
-func (t *Tree) GetOID() string {
+func (t *Tree) GetOID() ObjectID {
 	return t.oid
 }
 
@@ -82,28 +84,98 @@ func (t *Tree) Traverse(fn func(*Tree)) {
 }
 
 func (t *Tree) Bytes() []byte {
-	// Get sorted entry names
-	names := make([]string, 0, len(t.entries))
-	for name := range t.entries {
-		names = append(names, name)
-	}
-	sort.Strings(names)
-
 	var result []byte
 
-	for _, name := range names {
+	for _, name := range t.Names() {
 		entry := t.entries[name]
 		// "<mode> <name>\0"
 		result = fmt.Appendf(result, "%s %s\x00", entry.Mode(), name)
 
-		// raw sha1 bytes (20 bytes)
-		oidBytes, _ := hex.DecodeString(entry.GetOID())
-		result = append(result, oidBytes...)
+		// raw object ID, as many bytes as the hash algorithm produces
+		result = append(result, entry.GetOID().Bytes()...)
 	}
 
 	return result
 }
 
+// Names returns the names of the tree's entries in the order git stores
+// them, where subtrees sort as if their names ended in a slash.
+func (t *Tree) Names() []string {
+	names := make([]string, 0, len(t.entries))
+	for name := range t.entries {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return t.sortKey(names[i]) < t.sortKey(names[j])
+	})
+	return names
+}
+
+// sortKey returns the name used to order the entry called name.
+func (t *Tree) sortKey(name string) string {
+	if t.entries[name].Mode() == DirectoryMode {
+		return name + "/"
+	}
+	return name
+}
+
+// Get returns the entry stored under name, or nil when there is none.
+func (t *Tree) Get(name string) TreeEntry {
+	return t.entries[name]
+}
+
+// ParseTree decodes the raw contents of a tree object whose entries hold
+// object IDs of algo. Subtrees are returned as StoredEntry values; load them
+// from the database to descend into them.
+func ParseTree(data []byte, algo *hashalgo.Algorithm) (*Tree, error) {
+	entries, err := ReadTreeEntries(data, algo)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := NewTree()
+	for _, entry := range entries {
+		tree.entries[entry.Basename()] = entry
+	}
+	return tree, nil
+}
+
+// ReadTreeEntries decodes the raw contents of a tree object into its entries
+// in the order they are stored, keeping duplicates. Unlike ParseTree it does
+// not assume the tree is well formed, which makes it suitable for checking.
+func ReadTreeEntries(data []byte, algo *hashalgo.Algorithm) ([]*StoredEntry, error) {
+	var entries []*StoredEntry
+
+	for len(data) > 0 {
+		space := bytes.IndexByte(data, ' ')
+		if space < 0 {
+			return nil, fmt.Errorf("malformed tree entry mode")
+		}
+		mode := string(data[:space])
+		data = data[space+1:]
+
+		nul := bytes.IndexByte(data, 0)
+		if nul < 0 {
+			return nil, fmt.Errorf("malformed tree entry name")
+		}
+		name := string(data[:nul])
+		data = data[nul+1:]
+
+		if len(data) < algo.Size {
+			return nil, fmt.Errorf("truncated tree entry %s", name)
+		}
+		oid, err := OIDFromBytes(data[:algo.Size], algo)
+		if err != nil {
+			return nil, err
+		}
+		data = data[algo.Size:]
+
+		entries = append(entries, NewStoredEntry(name, oid, mode))
+	}
+
+	return entries, nil
+}
+
 // Build constructs a tree hierarchy from a flat list of entries.
 func Build(entries []*Entry) *Tree {
 	// Sort entries by name
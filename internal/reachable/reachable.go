@@ -0,0 +1,184 @@
+package reachable
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/shanmugharajk/gogit/internal/commit"
+	"github.com/shanmugharajk/gogit/internal/index"
+	"github.com/shanmugharajk/gogit/internal/object"
+	"github.com/shanmugharajk/gogit/internal/refs"
+	"github.com/shanmugharajk/gogit/internal/storage"
+)
+
+// Roots returns the objects that keep others alive: the targets of HEAD and
+// every ref, every object named in their reflogs, and the blobs staged in
+// idx, which must already be loaded. Submodule commits are left out, as
+// they are stored in another repository.
+func Roots(refsStore refs.Refs, idx *index.Index) ([]object.ObjectID, error) {
+	var roots []object.ObjectID
+	add := func(oid object.ObjectID) {
+		if !oid.IsZero() {
+			roots = append(roots, oid)
+		}
+	}
+
+	head, err := refsStore.ReadHead()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	add(head)
+
+	refList, err := refsStore.ListRefs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refs: %w", err)
+	}
+
+	names := []string{refs.HeadRef}
+	for _, ref := range refList {
+		add(ref.OID)
+		names = append(names, ref.Name)
+	}
+
+	for _, name := range names {
+		entries, err := refsStore.ReadLog(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read reflog: %w", err)
+		}
+		for _, entry := range entries {
+			add(entry.OldOID)
+			add(entry.NewOID)
+		}
+	}
+
+	for _, entry := range idx.Entries() {
+		if object.FormatMode(entry.Mode) != object.GitlinkMode {
+			add(entry.OID)
+		}
+	}
+
+	return roots, nil
+}
+
+// Walk returns every object reachable from roots, following commits to
+// their trees and parents, trees to their entries and tags to their targets.
+// A missing object is an error, since callers use the result to decide what
+// may be deleted.
+func Walk(db storage.ObjectStore, roots []object.ObjectID) (map[object.ObjectID]bool, error) {
+	seen := make(map[object.ObjectID]bool)
+	stack := make([]link, 0, len(roots))
+	for _, oid := range roots {
+		stack = append(stack, link{oid: oid})
+	}
+
+	for len(stack) > 0 {
+		next := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[next.oid] {
+			continue
+		}
+
+		// Blobs named by trees have no links, so checking that they exist
+		// avoids inflating them.
+		if next.blob {
+			exists, err := db.Exists(next.oid)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				return nil, &storage.ObjectNotFoundError{OID: next.oid}
+			}
+			seen[next.oid] = true
+			continue
+		}
+
+		links, err := readLinks(db, next.oid)
+		if err != nil {
+			return nil, err
+		}
+		seen[next.oid] = true
+
+		for _, l := range links {
+			if !seen[l.oid] {
+				stack = append(stack, l)
+			}
+		}
+	}
+
+	return seen, nil
+}
+
+// link is an object referred to by another, and whether it is known to be a
+// blob.
+type link struct {
+	oid  object.ObjectID
+	blob bool
+}
+
+// readLinks returns the objects oid refers to directly.
+func readLinks(db storage.ObjectStore, oid object.ObjectID) ([]link, error) {
+	obj, err := db.OpenObject(oid)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+
+	if obj.Type == "blob" {
+		return nil, nil
+	}
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate object %s: %w", oid, err)
+	}
+
+	var links []link
+	switch obj.Type {
+	case "tree":
+		entries, err := object.ReadTreeEntries(data, db.Algorithm())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tree %s: %w", oid, err)
+		}
+		for _, entry := range entries {
+			switch entry.Mode() {
+			case object.GitlinkMode:
+			case object.DirectoryMode:
+				links = append(links, link{oid: entry.OID})
+			default:
+				links = append(links, link{oid: entry.OID, blob: true})
+			}
+		}
+
+	case "commit":
+		c, err := commit.Parse(data, db.Algorithm())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse commit %s: %w", oid, err)
+		}
+		links = append(links, link{oid: c.TreeOID})
+		for _, parent := range c.Parents {
+			links = append(links, link{oid: parent})
+		}
+
+	case "tag":
+		target, err := tagTarget(data, db)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tag %s: %w", oid, err)
+		}
+		links = append(links, link{oid: target})
+
+	default:
+		return nil, fmt.Errorf("object %s has unsupported type %s", oid, obj.Type)
+	}
+
+	return links, nil
+}
+
+// tagTarget returns the object named by the "object" header of a tag.
+func tagTarget(data []byte, db storage.ObjectStore) (object.ObjectID, error) {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	value, ok := bytes.CutPrefix(line, []byte("object "))
+	if !ok {
+		return object.ObjectID{}, fmt.Errorf("missing object header")
+	}
+	return object.ParseOID(string(value), db.Algorithm())
+}
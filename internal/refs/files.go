@@ -0,0 +1,595 @@
+package refs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shanmugharajk/gogit/internal/file"
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+// LogsDir is the directory holding reflogs of the files backend.
+const LogsDir = "logs"
+
+// FileRefs stores refs as loose files under .git with a packed-refs fallback.
+type FileRefs struct {
+	gitPath string
+	algo    *hashalgo.Algorithm
+}
+
+// NewFileRefs creates a files backend scoped to the given .git directory,
+// whose objects are named by algo.
+func NewFileRefs(gitPath string, algo *hashalgo.Algorithm) *FileRefs {
+	return &FileRefs{gitPath: gitPath, algo: algo}
+}
+
+// UpdateHead updates HEAD, or the branch it points at, with the supplied commit OID.
+func (r *FileRefs) UpdateHead(oid object.ObjectID, message string) error {
+	target, err := r.resolveSymref(HeadRef)
+	if err != nil {
+		return err
+	}
+	return r.UpdateRef(target, oid, message)
+}
+
+// ReadHead returns the currently stored HEAD OID, or the zero ID when it does not exist.
+func (r *FileRefs) ReadHead() (object.ObjectID, error) {
+	return r.ReadRef(HeadRef)
+}
+
+// ReadRef returns the OID stored for the fully qualified reference name, or
+// the zero ID when it does not exist. Symbolic refs are followed, and loose
+// references take precedence over entries in the packed-refs file.
+func (r *FileRefs) ReadRef(name string) (object.ObjectID, error) {
+	target, err := r.resolveSymref(name)
+	if err != nil {
+		return object.ObjectID{}, err
+	}
+	return r.readRawRef(target)
+}
+
+// UpdateRef writes the OID for the fully qualified reference name as a loose
+// reference, shadowing any packed entry of the same name.
+func (r *FileRefs) UpdateRef(name string, oid object.ObjectID, message string) error {
+	if oid.Size() != r.algo.Size {
+		return fmt.Errorf("cannot update ref '%s': invalid %s object ID", name, r.algo.Name)
+	}
+
+	path := r.refPath(name)
+	if err := os.MkdirAll(filepath.Dir(path), file.ModeDir); err != nil {
+		return err
+	}
+
+	lock := file.NewLockfile(path)
+	if err := r.holdLock(lock, path); err != nil {
+		return err
+	}
+
+	oldOID, err := r.readRawRef(name)
+	if err != nil {
+		lock.Rollback()
+		return err
+	}
+
+	if err := lock.Write(oid.String() + "\n"); err != nil {
+		lock.Rollback()
+		return err
+	}
+	if err := lock.Commit(); err != nil {
+		return err
+	}
+
+	entry := newLogEntry(r.algo, oldOID, oid, message)
+	if err := r.appendLog(name, entry); err != nil {
+		return err
+	}
+
+	if updatesHeadLog(name, r.resolveSymref) {
+		return r.appendLog(HeadRef, entry)
+	}
+
+	return nil
+}
+
+// ReadSymref returns the ref name finally points at after following
+// symbolic refs.
+func (r *FileRefs) ReadSymref(name string) (string, error) {
+	return r.resolveSymref(name)
+}
+
+// SetSymref writes name as a symbolic ref pointing at target.
+func (r *FileRefs) SetSymref(name string, target string) error {
+	path := r.refPath(name)
+	if err := os.MkdirAll(filepath.Dir(path), file.ModeDir); err != nil {
+		return err
+	}
+
+	lock := file.NewLockfile(path)
+	if err := r.holdLock(lock, path); err != nil {
+		return err
+	}
+	if err := lock.Write(symrefPrefix + target + "\n"); err != nil {
+		lock.Rollback()
+		return err
+	}
+	return lock.Commit()
+}
+
+// DeleteRef removes the reference from both the loose refs and the packed-refs
+// file. The loose ref and packed-refs are locked for the duration so that a
+// concurrent update cannot resurrect the packed value.
+func (r *FileRefs) DeleteRef(name string) error {
+	refPath := r.refPath(name)
+	refsRoot := filepath.Join(r.gitPath, RefsDir)
+
+	// The lock is taken beside the loose ref, whose directory may be gone
+	// when the ref is only packed
+	if err := os.MkdirAll(filepath.Dir(refPath), file.ModeDir); err != nil {
+		return err
+	}
+	refLock := file.NewLockfile(refPath)
+	if err := r.holdLock(refLock, refPath); err != nil {
+		r.pruneEmptyParents(refPath, refsRoot)
+		return err
+	}
+
+	err := r.deleteLockedRef(name, refPath)
+	if rollbackErr := refLock.Rollback(); err == nil {
+		err = rollbackErr
+	}
+	// Only once the lock is gone can its directories be empty
+	r.pruneEmptyParents(refPath, refsRoot)
+	if err != nil {
+		return err
+	}
+
+	logPath := r.logPath(name)
+	if err := os.Remove(logPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	r.pruneEmptyParents(logPath, filepath.Join(r.gitPath, LogsDir, RefsDir))
+
+	return nil
+}
+
+// deleteLockedRef removes name from packed-refs and its loose file at
+// refPath, which the caller has locked.
+func (r *FileRefs) deleteLockedRef(name string, refPath string) error {
+	packedPath := r.packedRefsPath()
+	packedLock := file.NewLockfile(packedPath)
+	if err := r.holdLock(packedLock, packedPath); err != nil {
+		return err
+	}
+
+	packed, err := r.readPackedRefs()
+	if err != nil {
+		packedLock.Rollback()
+		return err
+	}
+
+	loose, err := r.readLooseRef(refPath)
+	if err != nil {
+		packedLock.Rollback()
+		return err
+	}
+
+	_, inPacked := packed.find(name)
+	if loose == "" && !inPacked {
+		packedLock.Rollback()
+		return fmt.Errorf("ref not found: %s", name)
+	}
+
+	if inPacked {
+		packed.remove(name)
+		if err := packedLock.Write(packed.String()); err != nil {
+			packedLock.Rollback()
+			return err
+		}
+		if err := packedLock.Commit(); err != nil {
+			return err
+		}
+	} else if err := packedLock.Rollback(); err != nil {
+		return err
+	}
+
+	if loose != "" {
+		if err := os.Remove(refPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListRefs returns every reference under refs/, merging loose and packed
+// entries, sorted by name.
+func (r *FileRefs) ListRefs() ([]*Ref, error) {
+	packed, err := r.readPackedRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*Ref, len(packed.refs))
+	for _, ref := range packed.refs {
+		byName[ref.Name] = ref
+	}
+
+	loose, err := r.listLooseRefs()
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range loose {
+		byName[ref.Name] = ref
+	}
+
+	result := make([]*Ref, 0, len(byName))
+	for _, ref := range byName {
+		result = append(result, ref)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+// ReadLog returns the entries of logs/<name>, oldest first.
+func (r *FileRefs) ReadLog(name string) ([]*LogEntry, error) {
+	f, err := os.Open(r.logPath(name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []*LogEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		entry, err := parseLogLine(scanner.Text(), r.algo)
+		if err != nil {
+			return nil, fmt.Errorf("reflog %s: %w", name, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// PackRefs moves loose references into the packed-refs file. Tags and refs
+// that are already packed are always included; all selects every ref under
+// refs/. When prune is set the packed loose files are removed afterwards.
+func (r *FileRefs) PackRefs(all bool, prune bool) error {
+	packedPath := r.packedRefsPath()
+	packedLock := file.NewLockfile(packedPath)
+	if err := r.holdLock(packedLock, packedPath); err != nil {
+		return err
+	}
+
+	packed, err := r.readPackedRefs()
+	if err != nil {
+		packedLock.Rollback()
+		return err
+	}
+
+	loose, err := r.listLooseRefs()
+	if err != nil {
+		packedLock.Rollback()
+		return err
+	}
+
+	var moved []*Ref
+	for _, ref := range loose {
+		_, inPacked := packed.find(ref.Name)
+		if !all && !inPacked && !strings.HasPrefix(ref.Name, TagsPrefix) {
+			continue
+		}
+		packed.set(ref)
+		moved = append(moved, ref)
+	}
+
+	if err := packedLock.Write(packed.String()); err != nil {
+		packedLock.Rollback()
+		return err
+	}
+	if err := packedLock.Commit(); err != nil {
+		return err
+	}
+
+	if !prune {
+		return nil
+	}
+
+	for _, ref := range moved {
+		if err := r.pruneLooseRef(ref); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pruneLooseRef removes a loose ref that has just been packed, provided
+// nobody updated it in the meantime.
+func (r *FileRefs) pruneLooseRef(ref *Ref) error {
+	refPath := r.refPath(ref.Name)
+	lock := file.NewLockfile(refPath)
+
+	acquired, err := lock.HoldForUpdate()
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		// Somebody else is updating it; leave the loose value in place.
+		return nil
+	}
+
+	current, err := r.readLooseRef(refPath)
+	if err != nil {
+		lock.Rollback()
+		return err
+	}
+	if current != ref.OID.String() {
+		return lock.Rollback()
+	}
+
+	if err := os.Remove(refPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		lock.Rollback()
+		return err
+	}
+	// Only once the lock is gone can its directories be empty
+	if err := lock.Rollback(); err != nil {
+		return err
+	}
+	r.pruneEmptyParents(refPath, filepath.Join(r.gitPath, RefsDir))
+
+	return nil
+}
+
+// listLooseRefs walks the refs directory and returns every loose ref holding
+// an object ID.
+func (r *FileRefs) listLooseRefs() ([]*Ref, error) {
+	var result []*Ref
+
+	root := filepath.Join(r.gitPath, RefsDir)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(path, ".lock") {
+			return nil
+		}
+
+		content, err := r.readLooseRef(path)
+		if err != nil {
+			return err
+		}
+		if content == "" || strings.HasPrefix(content, symrefPrefix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(r.gitPath, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		oid, err := r.parseRefContent(name, content)
+		if err != nil {
+			return err
+		}
+		result = append(result, &Ref{Name: name, OID: oid})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// resolveSymref follows symbolic refs starting at name and returns the name
+// of the ref that finally holds an OID, whether or not it exists yet.
+func (r *FileRefs) resolveSymref(name string) (string, error) {
+	for depth := 0; depth < maxSymrefDepth; depth++ {
+		content, err := r.readLooseRef(r.refPath(name))
+		if err != nil {
+			return "", err
+		}
+		target, ok := strings.CutPrefix(content, symrefPrefix)
+		if !ok {
+			return name, nil
+		}
+		name = strings.TrimSpace(target)
+	}
+	return "", fmt.Errorf("symbolic ref nesting too deep at %s", name)
+}
+
+// readRawRef reads a single ref without following symbolic refs, consulting
+// packed-refs when no loose file exists.
+func (r *FileRefs) readRawRef(name string) (object.ObjectID, error) {
+	content, err := r.readLooseRef(r.refPath(name))
+	if err != nil {
+		return object.ObjectID{}, err
+	}
+	if content != "" {
+		return r.parseRefContent(name, content)
+	}
+
+	packed, err := r.readPackedRefs()
+	if err != nil {
+		return object.ObjectID{}, err
+	}
+	if ref, ok := packed.find(name); ok {
+		return ref.OID, nil
+	}
+
+	return object.ObjectID{}, nil
+}
+
+// parseRefContent decodes the OID held by the loose ref name.
+func (r *FileRefs) parseRefContent(name string, content string) (object.ObjectID, error) {
+	oid, err := object.ParseOID(content, r.algo)
+	if err != nil {
+		return oid, fmt.Errorf("ref %s is corrupt: %w", name, err)
+	}
+	return oid, nil
+}
+
+// pruneEmptyParents removes now-empty directories left behind by a deleted
+// file, stopping at the namespace level below stop (e.g. refs/heads).
+func (r *FileRefs) pruneEmptyParents(path string, stop string) {
+	dir := filepath.Dir(path)
+	for filepath.Dir(dir) != stop && strings.HasPrefix(dir, stop) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// appendLog adds entry to the reflog of name when that ref keeps a log.
+func (r *FileRefs) appendLog(name string, entry *LogEntry) error {
+	path := r.logPath(name)
+	if !r.shouldLog(name, path) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), file.ModeDir); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, file.ModeFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(formatLogLine(entry))
+	return err
+}
+
+// shouldLog mirrors core.logAllRefUpdates=true: HEAD, branches, remotes and
+// notes are logged, as is any ref whose log already exists.
+func (r *FileRefs) shouldLog(name string, path string) bool {
+	if name == HeadRef {
+		return true
+	}
+	for _, prefix := range []string{HeadsPrefix, "refs/remotes/", "refs/notes/"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func (r *FileRefs) readLooseRef(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (r *FileRefs) holdLock(lock *file.Lockfile, path string) error {
+	acquired, err := lock.HoldForUpdate()
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return &LockDeniedError{Path: path}
+	}
+	return nil
+}
+
+func (r *FileRefs) refPath(name string) string {
+	return filepath.Join(r.gitPath, filepath.FromSlash(name))
+}
+
+func (r *FileRefs) logPath(name string) string {
+	return filepath.Join(r.gitPath, LogsDir, filepath.FromSlash(name))
+}
+
+// formatLogLine renders entry as "<old> <new> <name> <<email>> <time> <tz>\t<message>\n".
+func formatLogLine(entry *LogEntry) string {
+	message := strings.ReplaceAll(strings.TrimRight(entry.Message, "\n"), "\n", " ")
+	return fmt.Sprintf("%s %s %s <%s> %d %s\t%s\n",
+		entry.OldOID,
+		entry.NewOID,
+		entry.Name,
+		entry.Email,
+		entry.Time.Unix(),
+		entry.Time.Format("-0700"),
+		message)
+}
+
+// parseLogLine is the inverse of formatLogLine, for OIDs of algo.
+func parseLogLine(line string, algo *hashalgo.Algorithm) (*LogEntry, error) {
+	header, message, _ := strings.Cut(line, "\t")
+
+	fields := strings.SplitN(header, " ", 3)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("malformed entry %q", line)
+	}
+
+	ident := fields[2]
+	open := strings.LastIndexByte(ident, '<')
+	closing := strings.LastIndexByte(ident, '>')
+	if open < 0 || closing < open {
+		return nil, fmt.Errorf("malformed identity in %q", line)
+	}
+
+	when, err := parseIdentTime(strings.TrimSpace(ident[closing+1:]))
+	if err != nil {
+		return nil, fmt.Errorf("malformed time in %q: %w", line, err)
+	}
+
+	oldOID, err := object.ParseOID(fields[0], algo)
+	if err != nil {
+		return nil, err
+	}
+	newOID, err := object.ParseOID(fields[1], algo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LogEntry{
+		OldOID:  oldOID,
+		NewOID:  newOID,
+		Name:    strings.TrimSpace(ident[:open]),
+		Email:   ident[open+1 : closing],
+		Time:    when,
+		Message: message,
+	}, nil
+}
+
+// parseIdentTime parses the "<unix seconds> <+hhmm>" suffix of an identity.
+func parseIdentTime(s string) (time.Time, error) {
+	secs, tz, _ := strings.Cut(s, " ")
+	unix, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	zone, err := time.Parse("-0700", tz)
+	if err != nil {
+		return time.Unix(unix, 0).UTC(), nil
+	}
+	return time.Unix(unix, 0).In(zone.Location()), nil
+}
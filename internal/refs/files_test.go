@@ -0,0 +1,69 @@
+package refs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+// testOID returns an OID whose hex digits are all c.
+func testOID(t *testing.T, c byte) object.ObjectID {
+	t.Helper()
+	oid, err := object.ParseOID(strings.Repeat(string(c), hashalgo.SHA1.HexSize()), hashalgo.SHA1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return oid
+}
+
+func TestFileRefsDeletePackedRefWithoutLooseDirectory(t *testing.T) {
+	gitPath := t.TempDir()
+	r := NewFileRefs(gitPath, hashalgo.SHA1)
+	if err := r.UpdateRef("refs/heads/feature/x", testOID(t, 'a'), "create"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.PackRefs(true, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(gitPath, "refs", "heads", "feature")); !os.IsNotExist(err) {
+		t.Fatalf("pack-refs --prune left refs/heads/feature behind: %v", err)
+	}
+
+	if err := r.DeleteRef("refs/heads/feature/x"); err != nil {
+		t.Fatalf("DeleteRef: %v", err)
+	}
+	oid, err := r.ReadRef("refs/heads/feature/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !oid.IsZero() {
+		t.Errorf("refs/heads/feature/x still resolves to %s", oid)
+	}
+	if _, err := os.Stat(filepath.Join(gitPath, "refs", "heads", "feature")); !os.IsNotExist(err) {
+		t.Errorf("DeleteRef left refs/heads/feature behind: %v", err)
+	}
+}
+
+func TestFileRefsDeleteRefPrunesEmptyDirectories(t *testing.T) {
+	gitPath := t.TempDir()
+	r := NewFileRefs(gitPath, hashalgo.SHA1)
+	if err := r.UpdateRef("refs/heads/topic/a/b", testOID(t, 'b'), "create"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.DeleteRef("refs/heads/topic/a/b"); err != nil {
+		t.Fatalf("DeleteRef: %v", err)
+	}
+	for _, dir := range []string{"refs/heads/topic", "logs/refs/heads/topic"} {
+		if _, err := os.Stat(filepath.Join(gitPath, dir)); !os.IsNotExist(err) {
+			t.Errorf("DeleteRef left %s behind: %v", dir, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(gitPath, "refs", "heads")); err != nil {
+		t.Errorf("DeleteRef removed refs/heads: %v", err)
+	}
+}
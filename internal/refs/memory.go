@@ -0,0 +1,159 @@
+package refs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+// MemoryRefs keeps refs and their logs in memory. Every ref keeps a log. It
+// is safe for concurrent use.
+type MemoryRefs struct {
+	algo *hashalgo.Algorithm
+
+	mu      sync.Mutex
+	refs    map[string]object.ObjectID
+	symrefs map[string]string
+	logs    map[string][]*LogEntry
+}
+
+// NewMemoryRefs creates an empty in-memory ref store whose objects are
+// named by algo. HEAD points at refs/heads/master, which is unborn.
+func NewMemoryRefs(algo *hashalgo.Algorithm) *MemoryRefs {
+	return &MemoryRefs{
+		algo:    algo,
+		refs:    make(map[string]object.ObjectID),
+		symrefs: map[string]string{HeadRef: HeadsPrefix + "master"},
+		logs:    make(map[string][]*LogEntry),
+	}
+}
+
+// ReadHead returns the OID HEAD resolves to, or the zero ID when unborn.
+func (r *MemoryRefs) ReadHead() (object.ObjectID, error) {
+	return r.ReadRef(HeadRef)
+}
+
+// UpdateHead points HEAD, or the branch it refers to, at oid.
+func (r *MemoryRefs) UpdateHead(oid object.ObjectID, message string) error {
+	target, err := r.ReadSymref(HeadRef)
+	if err != nil {
+		return err
+	}
+	return r.UpdateRef(target, oid, message)
+}
+
+// ReadRef returns the OID the fully qualified name resolves to, or the zero
+// ID when it does not exist.
+func (r *MemoryRefs) ReadRef(name string) (object.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	target, err := r.resolveSymref(name)
+	if err != nil {
+		return object.ObjectID{}, err
+	}
+	return r.refs[target], nil
+}
+
+// UpdateRef sets name to oid and records the update in its log. A symbolic
+// ref of that name is replaced.
+func (r *MemoryRefs) UpdateRef(name string, oid object.ObjectID, message string) error {
+	if oid.Size() != r.algo.Size {
+		return fmt.Errorf("cannot update ref '%s': invalid %s object ID", name, r.algo.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry := newLogEntry(r.algo, r.refs[name], oid, message)
+	delete(r.symrefs, name)
+	r.refs[name] = oid
+	r.logs[name] = append(r.logs[name], entry)
+
+	if updatesHeadLog(name, r.resolveSymref) {
+		r.logs[HeadRef] = append(r.logs[HeadRef], entry)
+	}
+	return nil
+}
+
+// ReadSymref returns the ref name finally points at after following
+// symbolic refs.
+func (r *MemoryRefs) ReadSymref(name string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.resolveSymref(name)
+}
+
+// SetSymref points name at target, e.g. HEAD at refs/heads/main.
+func (r *MemoryRefs) SetSymref(name string, target string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.refs, name)
+	r.symrefs[name] = target
+	return nil
+}
+
+// DeleteRef removes name and its log.
+func (r *MemoryRefs) DeleteRef(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, isRef := r.refs[name]
+	_, isSymref := r.symrefs[name]
+	if !isRef && !isSymref {
+		return fmt.Errorf("ref not found: %s", name)
+	}
+	delete(r.refs, name)
+	delete(r.symrefs, name)
+	delete(r.logs, name)
+	return nil
+}
+
+// ListRefs returns every non-symbolic ref under refs/ sorted by name.
+func (r *MemoryRefs) ListRefs() ([]*Ref, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []*Ref
+	for name, oid := range r.refs {
+		if strings.HasPrefix(name, RefsDir+"/") {
+			result = append(result, &Ref{Name: name, OID: oid})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// ReadLog returns the log of name, oldest entry first.
+func (r *MemoryRefs) ReadLog(name string) ([]*LogEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]*LogEntry, len(r.logs[name]))
+	copy(entries, r.logs[name])
+	return entries, nil
+}
+
+// PackRefs does nothing, as there is no storage to consolidate.
+func (r *MemoryRefs) PackRefs(all bool, prune bool) error {
+	return nil
+}
+
+// resolveSymref follows symbolic refs starting at name. r.mu must be held.
+func (r *MemoryRefs) resolveSymref(name string) (string, error) {
+	for depth := 0; depth < maxSymrefDepth; depth++ {
+		target, ok := r.symrefs[name]
+		if !ok {
+			return name, nil
+		}
+		name = target
+	}
+	return "", fmt.Errorf("symbolic ref nesting too deep at %s", name)
+}
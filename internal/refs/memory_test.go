@@ -0,0 +1,99 @@
+package refs
+
+import (
+	"testing"
+
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+)
+
+// refsBackends returns a fresh store of every backend, so that the in-memory
+// one is held to the behavior of the ones on disk.
+func refsBackends(t *testing.T) map[string]Refs {
+	t.Helper()
+	return map[string]Refs{
+		"memory":   NewMemoryRefs(hashalgo.SHA1),
+		"files":    NewFileRefs(t.TempDir(), hashalgo.SHA1),
+		"reftable": newTestReftable(t),
+	}
+}
+
+func TestRefsUpdateReadAndDelete(t *testing.T) {
+	for name, r := range refsBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			first, second := testOID(t, '1'), testOID(t, '2')
+			if err := r.SetSymref(HeadRef, "refs/heads/main"); err != nil {
+				t.Fatal(err)
+			}
+			if oid, err := r.ReadHead(); err != nil || !oid.IsZero() {
+				t.Fatalf("ReadHead on an unborn branch = %s, %v", oid, err)
+			}
+
+			if err := r.UpdateHead(first, "commit (initial): one"); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.UpdateRef("refs/heads/topic", second, "branch: Created from HEAD"); err != nil {
+				t.Fatal(err)
+			}
+
+			if target, err := r.ReadSymref(HeadRef); err != nil || target != "refs/heads/main" {
+				t.Errorf("ReadSymref(HEAD) = %q, %v", target, err)
+			}
+			if oid, err := r.ReadRef("refs/heads/main"); err != nil || oid != first {
+				t.Errorf("ReadRef(refs/heads/main) = %s, %v", oid, err)
+			}
+			if oid, err := r.ReadHead(); err != nil || oid != first {
+				t.Errorf("ReadHead = %s, %v", oid, err)
+			}
+
+			list, err := r.ListRefs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list) != 2 || list[0].Name != "refs/heads/main" || list[1].Name != "refs/heads/topic" || list[1].OID != second {
+				t.Errorf("ListRefs = %+v", list)
+			}
+
+			if err := r.DeleteRef("refs/heads/topic"); err != nil {
+				t.Fatal(err)
+			}
+			if oid, err := r.ReadRef("refs/heads/topic"); err != nil || !oid.IsZero() {
+				t.Errorf("ReadRef after DeleteRef = %s, %v", oid, err)
+			}
+			if list, err := r.ListRefs(); err != nil || len(list) != 1 {
+				t.Errorf("ListRefs after DeleteRef = %+v, %v", list, err)
+			}
+		})
+	}
+}
+
+func TestRefsLogUpdatesThroughHead(t *testing.T) {
+	for name, r := range refsBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := r.SetSymref(HeadRef, "refs/heads/main"); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.UpdateHead(testOID(t, '1'), "commit (initial): one"); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.UpdateHead(testOID(t, '2'), "commit: two"); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, ref := range []string{"refs/heads/main", HeadRef} {
+				entries, err := r.ReadLog(ref)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(entries) != 2 {
+					t.Fatalf("ReadLog(%s) returned %d entries, want 2", ref, len(entries))
+				}
+				if !entries[0].OldOID.IsZero() || entries[0].NewOID != testOID(t, '1') || entries[1].OldOID != testOID(t, '1') {
+					t.Errorf("ReadLog(%s) = %+v, %+v", ref, entries[0], entries[1])
+				}
+				if entries[1].Message != "commit: two" {
+					t.Errorf("ReadLog(%s) message = %q", ref, entries[1].Message)
+				}
+			}
+		})
+	}
+}
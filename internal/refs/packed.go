@@ -0,0 +1,133 @@
+package refs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+const (
+	// PackedRefsFile is the name of the file holding packed references.
+	PackedRefsFile = "packed-refs"
+
+	packedRefsHeader = "# pack-refs with: sorted \n"
+)
+
+// packedRefs is the parsed content of the packed-refs file.
+type packedRefs struct {
+	refs []*Ref
+}
+
+// readPackedRefs loads the packed-refs file, returning an empty set when it
+// does not exist.
+func (r *FileRefs) readPackedRefs() (*packedRefs, error) {
+	f, err := os.Open(r.packedRefsPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &packedRefs{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	packed := &packedRefs{}
+	var last *Ref
+
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "^"):
+			// A peeled line records the target of the annotated tag above it.
+			if last == nil {
+				return nil, fmt.Errorf("packed-refs line %d: peeled line without a ref", lineNo)
+			}
+			peeled, err := object.ParseOID(line[1:], r.algo)
+			if err != nil {
+				return nil, fmt.Errorf("packed-refs line %d: %w", lineNo, err)
+			}
+			last.Peeled = peeled
+			last = nil
+		default:
+			hexOID, name, ok := strings.Cut(line, " ")
+			if !ok || hexOID == "" || name == "" {
+				return nil, fmt.Errorf("packed-refs line %d: malformed entry %q", lineNo, line)
+			}
+			oid, err := object.ParseOID(hexOID, r.algo)
+			if err != nil {
+				return nil, fmt.Errorf("packed-refs line %d: %w", lineNo, err)
+			}
+			last = &Ref{Name: name, OID: oid}
+			packed.refs = append(packed.refs, last)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return packed, nil
+}
+
+// find returns the packed entry with the given name.
+func (p *packedRefs) find(name string) (*Ref, bool) {
+	for _, ref := range p.refs {
+		if ref.Name == name {
+			return ref, true
+		}
+	}
+	return nil, false
+}
+
+// set adds or replaces the entry for ref.Name. A peeled value is only kept
+// when the ref still points at the same object.
+func (p *packedRefs) set(ref *Ref) {
+	if existing, ok := p.find(ref.Name); ok {
+		if existing.OID != ref.OID {
+			existing.Peeled = ref.Peeled
+		}
+		existing.OID = ref.OID
+		return
+	}
+	p.refs = append(p.refs, &Ref{Name: ref.Name, OID: ref.OID, Peeled: ref.Peeled})
+}
+
+// remove drops the entry with the given name.
+func (p *packedRefs) remove(name string) {
+	for i, ref := range p.refs {
+		if ref.Name == name {
+			p.refs = append(p.refs[:i], p.refs[i+1:]...)
+			return
+		}
+	}
+}
+
+// String serializes the entries in sorted order in the packed-refs format.
+func (p *packedRefs) String() string {
+	sort.Slice(p.refs, func(i, j int) bool {
+		return p.refs[i].Name < p.refs[j].Name
+	})
+
+	var b strings.Builder
+	b.WriteString(packedRefsHeader)
+	for _, ref := range p.refs {
+		fmt.Fprintf(&b, "%s %s\n", ref.OID, ref.Name)
+		if !ref.Peeled.IsZero() {
+			fmt.Fprintf(&b, "^%s\n", ref.Peeled)
+		}
+	}
+
+	return b.String()
+}
+
+func (r *FileRefs) packedRefsPath() string {
+	return filepath.Join(r.gitPath, PackedRefsFile)
+}
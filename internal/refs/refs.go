@@ -1,64 +1,156 @@
 package refs
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
-	"github.com/shanmugharajk/gogit/internal/file"
+	"github.com/shanmugharajk/gogit/internal/config"
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/object"
 )
 
-// Refs manages reference updates such as HEAD.
-type Refs struct {
-	gitPath string
+const (
+	// HeadRef is the name of the reference pointing at the current commit.
+	HeadRef = "HEAD"
+	// RefsDir is the directory holding loose references.
+	RefsDir = "refs"
+	// HeadsPrefix is the namespace for branch references.
+	HeadsPrefix = "refs/heads/"
+	// TagsPrefix is the namespace for tag references.
+	TagsPrefix = "refs/tags/"
+
+	// StorageFiles selects loose and packed ref files.
+	StorageFiles = "files"
+	// StorageReftable selects the reftable format.
+	StorageReftable = "reftable"
+
+	// symrefPrefix marks the contents of a symbolic ref file.
+	symrefPrefix = "ref: "
+	// maxSymrefDepth bounds how many symbolic refs are followed.
+	maxSymrefDepth = 5
+)
+
+// Refs reads and updates references. Implementations store refs as loose
+// and packed files, in reftable stacks, or in memory.
+type Refs interface {
+	// ReadHead returns the OID HEAD resolves to, or the zero ID when unborn.
+	ReadHead() (object.ObjectID, error)
+
+	// UpdateHead points HEAD, or the branch it refers to, at oid.
+	UpdateHead(oid object.ObjectID, message string) error
+
+	// ReadRef returns the OID the fully qualified name resolves to, or the
+	// zero ID when it does not exist.
+	ReadRef(name string) (object.ObjectID, error)
+
+	// UpdateRef sets the fully qualified name to oid.
+	UpdateRef(name string, oid object.ObjectID, message string) error
+
+	// ReadSymref returns the ref that name, following symbolic refs, finally
+	// points at; a name that is not symbolic resolves to itself.
+	ReadSymref(name string) (string, error)
+
+	// SetSymref makes name a symbolic ref pointing at target, e.g. HEAD at
+	// refs/heads/main.
+	SetSymref(name string, target string) error
+
+	// DeleteRef removes the fully qualified name and its log.
+	DeleteRef(name string) error
+
+	// ListRefs returns every non-symbolic ref under refs/ sorted by name.
+	ListRefs() ([]*Ref, error)
+
+	// ReadLog returns the reflog of name, oldest entry first.
+	ReadLog(name string) ([]*LogEntry, error)
+
+	// PackRefs consolidates ref storage. all and prune only apply to the
+	// files backend; reftable compacts its whole stack.
+	PackRefs(all bool, prune bool) error
 }
 
-// New creates a new Refs manager scoped to the given .git directory.
-func New(gitPath string) *Refs {
-	return &Refs{gitPath: gitPath}
+var (
+	_ Refs = (*FileRefs)(nil)
+	_ Refs = (*ReftableRefs)(nil)
+	_ Refs = (*MemoryRefs)(nil)
+)
+
+// Ref is a named reference together with the object it points at.
+// Peeled holds the object an annotated tag ultimately refers to, when known.
+type Ref struct {
+	Name   string
+	OID    object.ObjectID
+	Peeled object.ObjectID
 }
 
-// UpdateHead updates the HEAD reference with the supplied commit OID.
-func (r *Refs) UpdateHead(oid string) error {
-	headPath := r.headPath()
-	lock := file.NewLockfile(headPath)
+// LogEntry is one reflog record describing a single ref update. A side of
+// the update that did not exist is recorded as the null ID.
+type LogEntry struct {
+	OldOID  object.ObjectID
+	NewOID  object.ObjectID
+	Name    string
+	Email   string
+	Time    time.Time
+	Message string
+}
 
-	acquired, err := lock.HoldForUpdate()
+// New opens the ref store of the repository at gitPath, choosing the backend
+// from extensions.refStorage and the object format from
+// extensions.objectFormat in its config.
+func New(gitPath string) (Refs, error) {
+	cfg, err := config.Open(filepath.Join(gitPath, "config"))
 	if err != nil {
-		return err
-	}
-	if !acquired {
-		return &LockDeniedError{Path: headPath}
+		return nil, err
 	}
 
-	if err := lock.Write(oid + "\n"); err != nil {
-		return err
+	algo, err := hashalgo.FromConfig(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	return lock.Commit()
+	storage, _ := cfg.Get("extensions.refStorage")
+	switch storage {
+	case "", StorageFiles:
+		return NewFileRefs(gitPath, algo), nil
+	case StorageReftable:
+		return NewReftableRefs(gitPath, algo), nil
+	default:
+		return nil, fmt.Errorf("unknown ref storage format '%s'", storage)
+	}
 }
 
-// ReadHead returns the currently stored HEAD OID, or empty string when it does not exist.
-func (r *Refs) ReadHead() (string, error) {
-	headPath := r.headPath()
-	data, err := os.ReadFile(headPath)
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return "", nil
-		}
-		return "", err
+// newLogEntry builds the reflog record for an update by the current user.
+// Missing sides of the update are recorded as the null ID of algo.
+func newLogEntry(algo *hashalgo.Algorithm, oldOID object.ObjectID, newOID object.ObjectID, message string) *LogEntry {
+	if oldOID.IsZero() {
+		oldOID = object.NullOID(algo)
+	}
+	if newOID.IsZero() {
+		newOID = object.NullOID(algo)
 	}
 
-	return strings.TrimSpace(string(data)), nil
+	return &LogEntry{
+		OldOID:  oldOID,
+		NewOID:  newOID,
+		Name:    identityEnv("GIT_COMMITTER_NAME", "GIT_AUTHOR_NAME"),
+		Email:   identityEnv("GIT_COMMITTER_EMAIL", "GIT_AUTHOR_EMAIL"),
+		Time:    time.Now(),
+		Message: message,
+	}
 }
 
-func (r *Refs) headPath() string {
-	return filepath.Join(r.gitPath, "HEAD")
+func identityEnv(keys ...string) string {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+	return ""
 }
 
-// LockDeniedError indicates that the HEAD lock could not be acquired.
+// LockDeniedError indicates that the lock on a ref file could not be acquired.
 type LockDeniedError struct {
 	Path string
 }
@@ -66,3 +158,44 @@ type LockDeniedError struct {
 func (e *LockDeniedError) Error() string {
 	return fmt.Sprintf("could not acquire lock on file: %s", e.Path)
 }
+
+// updatesHeadLog reports whether an update of name is recorded in HEAD's log
+// as well as its own: like git, updates made through HEAD's branch are.
+// resolveSymref follows symbolic refs in the backend making the update.
+func updatesHeadLog(name string, resolveSymref func(name string) (string, error)) bool {
+	if name == HeadRef {
+		return false
+	}
+	head, err := resolveSymref(HeadRef)
+	return err == nil && head == name
+}
+
+// ValidName reports whether name is a well-formed ref name, by the rules of
+// git check-ref-format: no component may be empty, begin with "." or end
+// with ".lock", and the name may not contain "..", "@{", control characters,
+// spaces or any of ~^:?*[\, nor end with "." or be "@".
+func ValidName(name string) bool {
+	if name == "" || name == "@" || strings.HasSuffix(name, ".") ||
+		strings.Contains(name, "..") || strings.Contains(name, "@{") {
+		return false
+	}
+	for _, c := range name {
+		if c < 0x20 || c == 0x7f || strings.ContainsRune(" ~^:?*[\\", c) {
+			return false
+		}
+	}
+	for _, component := range strings.Split(name, "/") {
+		if component == "" || strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidShortName reports whether name is a valid branch or tag name to
+// create under namespace, such as HeadsPrefix: the full ref must be valid
+// and, so that it cannot be taken for an option, name may not begin with
+// "-".
+func ValidShortName(namespace string, name string) bool {
+	return !strings.HasPrefix(name, "-") && ValidName(namespace+name)
+}
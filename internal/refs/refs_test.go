@@ -0,0 +1,49 @@
+package refs
+
+import "testing"
+
+func TestValidName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"refs/tags/v1.0", true},
+		{"refs/heads/feature/x", true},
+		{"refs/tags/bad..name", false},
+		{"refs/tags/.hidden", false},
+		{"refs/tags/v1.lock", false},
+		{"refs/tags/v1.", false},
+		{"refs/tags/a b", false},
+		{"refs/tags/a~1", false},
+		{"refs/tags/a@{1}", false},
+		{"refs/tags//a", false},
+		{"refs/tags/", false},
+		{"@", false},
+		{"/refs/tags/a", false},
+	}
+	for _, tt := range tests {
+		if got := ValidName(tt.name); got != tt.valid {
+			t.Errorf("ValidName(%q) = %v, want %v", tt.name, got, tt.valid)
+		}
+	}
+}
+
+func TestValidShortName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"main", true},
+		{"feature/x", true},
+		{"-main", false},
+		{"feature/", false},
+		{"a..b", false},
+	}
+	for _, tt := range tests {
+		for _, namespace := range []string{HeadsPrefix, TagsPrefix} {
+			if got := ValidShortName(namespace, tt.name); got != tt.valid {
+				t.Errorf("ValidShortName(%q, %q) = %v, want %v", namespace, tt.name, got, tt.valid)
+			}
+		}
+	}
+}
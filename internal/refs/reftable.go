@@ -0,0 +1,588 @@
+package refs
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/file"
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+const (
+	// ReftableDir is the directory holding the reftable stack.
+	ReftableDir = "reftable"
+	// TablesListFile names the tables of the stack, oldest first.
+	TablesListFile = "tables.list"
+
+	// compactionFactor is the geometric ratio auto-compaction keeps between
+	// the sizes of consecutive tables.
+	compactionFactor = 2
+)
+
+// ReftableRefs stores refs in a stack of reftable files listed in
+// reftable/tables.list. Every update appends a new table; small tables are
+// merged automatically so the stack stays logarithmic in size.
+type ReftableRefs struct {
+	gitPath string
+	dir     string
+	algo    *hashalgo.Algorithm
+}
+
+// NewReftableRefs creates a reftable backend scoped to the given .git
+// directory, whose objects are named by algo.
+func NewReftableRefs(gitPath string, algo *hashalgo.Algorithm) *ReftableRefs {
+	return &ReftableRefs{
+		gitPath: gitPath,
+		dir:     filepath.Join(gitPath, ReftableDir),
+		algo:    algo,
+	}
+}
+
+// stack is a locked view of tables.list and the tables it names.
+type stack struct {
+	lock   *file.Lockfile
+	names  []string
+	tables []*tableFile
+}
+
+// UpdateHead updates HEAD, or the branch it points at, with the supplied commit OID.
+func (r *ReftableRefs) UpdateHead(oid object.ObjectID, message string) error {
+	tables, err := r.readTables()
+	if err != nil {
+		return err
+	}
+
+	target, err := resolveReftableSymref(tables, HeadRef)
+	if err != nil {
+		return err
+	}
+	return r.UpdateRef(target, oid, message)
+}
+
+// ReadHead returns the OID HEAD resolves to, or the zero ID when unborn.
+func (r *ReftableRefs) ReadHead() (object.ObjectID, error) {
+	return r.ReadRef(HeadRef)
+}
+
+// ReadRef returns the OID the fully qualified name resolves to.
+func (r *ReftableRefs) ReadRef(name string) (object.ObjectID, error) {
+	tables, err := r.readTables()
+	if err != nil {
+		return object.ObjectID{}, err
+	}
+
+	target, err := resolveReftableSymref(tables, name)
+	if err != nil {
+		return object.ObjectID{}, err
+	}
+	rec, err := lookupRef(tables, target)
+	if err != nil || rec == nil {
+		return object.ObjectID{}, err
+	}
+	return rec.oid, nil
+}
+
+// UpdateRef appends a table setting name to oid along with its log record.
+func (r *ReftableRefs) UpdateRef(name string, oid object.ObjectID, message string) error {
+	if oid.Size() != r.algo.Size {
+		return fmt.Errorf("cannot update ref '%s': invalid %s object ID", name, r.algo.Name)
+	}
+
+	return r.transaction(func(s *stack, updateIndex uint64) (*reftable, error) {
+		var oldOID object.ObjectID
+		rec, err := lookupRef(s.tables, name)
+		if err != nil {
+			return nil, err
+		}
+		if rec != nil {
+			oldOID = rec.oid
+		}
+
+		entry := newLogEntry(r.algo, oldOID, oid, message)
+		table := &reftable{
+			refs: []*refRecord{{name: name, updateIndex: updateIndex, valueType: refValueOID, oid: oid}},
+			logs: []*logRecord{{refName: name, updateIndex: updateIndex, logType: logValueUpdate, entry: entry}},
+		}
+
+		resolveSymref := func(name string) (string, error) {
+			return resolveReftableSymref(s.tables, name)
+		}
+		if updatesHeadLog(name, resolveSymref) {
+			table.logs = append(table.logs, &logRecord{
+				refName: HeadRef, updateIndex: updateIndex, logType: logValueUpdate, entry: entry,
+			})
+		}
+
+		return table, nil
+	})
+}
+
+// ReadSymref returns the ref name finally points at after following
+// symbolic refs.
+func (r *ReftableRefs) ReadSymref(name string) (string, error) {
+	tables, err := r.readTables()
+	if err != nil {
+		return "", err
+	}
+	return resolveReftableSymref(tables, name)
+}
+
+// SetSymref points name at target, e.g. HEAD at refs/heads/main.
+func (r *ReftableRefs) SetSymref(name string, target string) error {
+	return r.transaction(func(s *stack, updateIndex uint64) (*reftable, error) {
+		return &reftable{
+			refs: []*refRecord{{name: name, updateIndex: updateIndex, valueType: refValueSymref, target: target}},
+		}, nil
+	})
+}
+
+// DeleteRef appends a table holding a deletion record for name, and deletion
+// records for every entry of its log.
+func (r *ReftableRefs) DeleteRef(name string) error {
+	return r.transaction(func(s *stack, updateIndex uint64) (*reftable, error) {
+		rec, err := lookupRef(s.tables, name)
+		if err != nil {
+			return nil, err
+		}
+		if rec == nil {
+			return nil, fmt.Errorf("ref not found: %s", name)
+		}
+
+		logs, err := mergedLogs(s.tables, name)
+		if err != nil {
+			return nil, err
+		}
+		table := &reftable{
+			refs: []*refRecord{{name: name, updateIndex: updateIndex, valueType: refValueDeletion}},
+		}
+		for _, rec := range logs {
+			table.logs = append(table.logs, &logRecord{
+				refName: name, updateIndex: rec.updateIndex, logType: logValueDeletion,
+			})
+		}
+		return table, nil
+	})
+}
+
+// ListRefs returns every non-symbolic ref under refs/ sorted by name.
+func (r *ReftableRefs) ListRefs() ([]*Ref, error) {
+	tables, err := r.readTables()
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := mergedRefs(tables)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Ref
+	for _, rec := range records {
+		if !strings.HasPrefix(rec.name, RefsDir+"/") || rec.valueType == refValueSymref {
+			continue
+		}
+		result = append(result, &Ref{Name: rec.name, OID: rec.oid, Peeled: rec.peeled})
+	}
+	return result, nil
+}
+
+// ReadLog returns the reflog of name, oldest entry first.
+func (r *ReftableRefs) ReadLog(name string) ([]*LogEntry, error) {
+	tables, err := r.readTables()
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := mergedLogs(tables, name)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*LogEntry, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		entries = append(entries, records[i].entry)
+	}
+	return entries, nil
+}
+
+// PackRefs compacts the whole stack into a single table.
+func (r *ReftableRefs) PackRefs(all bool, prune bool) error {
+	s, err := r.lockStack()
+	if err != nil {
+		return err
+	}
+	if len(s.names) < 2 {
+		return s.lock.Rollback()
+	}
+	return r.compact(s, 0, len(s.names))
+}
+
+// transaction locks the stack, lets build describe the records of the update
+// at the next update index, appends the new table and auto-compacts.
+func (r *ReftableRefs) transaction(build func(s *stack, updateIndex uint64) (*reftable, error)) error {
+	s, err := r.lockStack()
+	if err != nil {
+		return err
+	}
+
+	var updateIndex uint64 = 1
+	if n := len(s.tables); n > 0 {
+		updateIndex = s.tables[n-1].maxUpdateIndex + 1
+	}
+
+	table, err := build(s, updateIndex)
+	if err != nil {
+		s.lock.Rollback()
+		return err
+	}
+	table.minUpdateIndex = updateIndex
+	table.maxUpdateIndex = updateIndex
+
+	name, written, err := r.writeTable(table)
+	if err != nil {
+		s.lock.Rollback()
+		return err
+	}
+	s.names = append(s.names, name)
+	s.tables = append(s.tables, written)
+
+	start, end := r.compactionSegment(s)
+	if end-start < 2 {
+		return r.commitStack(s, nil)
+	}
+	return r.compact(s, start, end)
+}
+
+// compactionSegment picks the newest run of tables whose sizes break the
+// geometric sequence, so that merging them restores it.
+func (r *ReftableRefs) compactionSegment(s *stack) (int, int) {
+	n := len(s.names)
+	if n < 2 {
+		return 0, 0
+	}
+
+	sizes := make([]int, n)
+	for i, table := range s.tables {
+		sizes[i] = len(table.data)
+	}
+
+	start := n - 1
+	total := sizes[n-1]
+	for i := n - 2; i >= 0; i-- {
+		if sizes[i] >= compactionFactor*total {
+			break
+		}
+		total += sizes[i]
+		start = i
+	}
+
+	return start, n
+}
+
+// compact merges tables [start, end) into one and commits the new list.
+// Deletion records are only dropped when nothing older remains beneath them.
+func (r *ReftableRefs) compact(s *stack, start int, end int) error {
+	segment := make([]*reftable, 0, end-start)
+	for _, table := range s.tables[start:end] {
+		decoded, err := table.decode()
+		if err != nil {
+			s.lock.Rollback()
+			return err
+		}
+		segment = append(segment, decoded)
+	}
+	dropDeletions := start == 0
+
+	merged := &reftable{
+		minUpdateIndex: segment[0].minUpdateIndex,
+		maxUpdateIndex: segment[len(segment)-1].maxUpdateIndex,
+	}
+
+	newest := make(map[string]*refRecord)
+	for _, table := range segment {
+		for _, rec := range table.refs {
+			newest[rec.name] = rec
+		}
+	}
+	for _, rec := range newest {
+		if dropDeletions && rec.valueType == refValueDeletion {
+			continue
+		}
+		merged.refs = append(merged.refs, rec)
+	}
+
+	type logKey struct {
+		name  string
+		index uint64
+	}
+	logs := make(map[logKey]*logRecord)
+	for _, table := range segment {
+		for _, rec := range table.logs {
+			logs[logKey{rec.refName, rec.updateIndex}] = rec
+		}
+	}
+	for _, rec := range logs {
+		if dropDeletions && rec.logType == logValueDeletion {
+			continue
+		}
+		merged.logs = append(merged.logs, rec)
+	}
+
+	name, written, err := r.writeTable(merged)
+	if err != nil {
+		s.lock.Rollback()
+		return err
+	}
+
+	obsolete := append([]string{}, s.names[start:end]...)
+	names := append(append(append([]string{}, s.names[:start]...), name), s.names[end:]...)
+	tables := append(append(append([]*tableFile{}, s.tables[:start]...), written), s.tables[end:]...)
+	s.names, s.tables = names, tables
+
+	return r.commitStack(s, obsolete)
+}
+
+// lockStack takes the tables.list lock and loads the current stack.
+func (r *ReftableRefs) lockStack() (*stack, error) {
+	listPath := filepath.Join(r.dir, TablesListFile)
+	lock := file.NewLockfile(listPath)
+
+	acquired, err := lock.HoldForUpdate()
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, &LockDeniedError{Path: listPath}
+	}
+
+	names, err := r.readTablesList()
+	if err != nil {
+		lock.Rollback()
+		return nil, err
+	}
+	tables, err := r.loadTables(names)
+	if err != nil {
+		lock.Rollback()
+		return nil, err
+	}
+
+	return &stack{lock: lock, names: names, tables: tables}, nil
+}
+
+// commitStack writes the new tables.list and removes tables it no longer names.
+func (r *ReftableRefs) commitStack(s *stack, obsolete []string) error {
+	var content strings.Builder
+	for _, name := range s.names {
+		content.WriteString(name)
+		content.WriteByte('\n')
+	}
+
+	if err := s.lock.Write(content.String()); err != nil {
+		s.lock.Rollback()
+		return err
+	}
+	if err := s.lock.Commit(); err != nil {
+		return err
+	}
+
+	for _, name := range obsolete {
+		if err := os.Remove(filepath.Join(r.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTable encodes table into a new uniquely named file in the stack
+// directory and returns its name along with the file as written.
+func (r *ReftableRefs) writeTable(table *reftable) (string, *tableFile, error) {
+	data, err := encodeReftable(table, r.algo)
+	if err != nil {
+		return "", nil, err
+	}
+	written, err := openReftable(data, r.algo)
+	if err != nil {
+		return "", nil, err
+	}
+
+	name := fmt.Sprintf("0x%012x-0x%012x-%08x.ref", table.minUpdateIndex, table.maxUpdateIndex, rand.Uint32())
+
+	tempFile, err := os.CreateTemp(r.dir, name+".temp.")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary table: %w", err)
+	}
+	defer func() {
+		tempFile.Close()
+		os.Remove(tempFile.Name())
+	}()
+
+	if _, err := tempFile.Write(data); err != nil {
+		return "", nil, err
+	}
+	if err := tempFile.Sync(); err != nil {
+		return "", nil, err
+	}
+	if err := tempFile.Close(); err != nil {
+		return "", nil, err
+	}
+	if err := os.Chmod(tempFile.Name(), file.ModeFile); err != nil {
+		return "", nil, err
+	}
+	if err := os.Rename(tempFile.Name(), filepath.Join(r.dir, name)); err != nil {
+		return "", nil, err
+	}
+
+	return name, written, nil
+}
+
+// readTables loads the current stack without locking it. tables.list is only
+// ever replaced atomically, but compaction deletes the tables it replaces, so
+// a table named by the list just read may be gone. The list is then read
+// again, until the tables it names can all be loaded or a table is missing
+// from a list that did not change.
+func (r *ReftableRefs) readTables() ([]*tableFile, error) {
+	names, err := r.readTablesList()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tables, err := r.loadTables(names)
+		if err == nil || !errors.Is(err, os.ErrNotExist) {
+			return tables, err
+		}
+
+		current, listErr := r.readTablesList()
+		if listErr != nil {
+			return nil, listErr
+		}
+		if slices.Equal(current, names) {
+			return nil, err
+		}
+		names = current
+	}
+}
+
+func (r *ReftableRefs) readTablesList() ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(r.dir, TablesListFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, name := range strings.Split(string(data), "\n") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func (r *ReftableRefs) loadTables(names []string) ([]*tableFile, error) {
+	tables := make([]*tableFile, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(r.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		table, err := openReftable(data, r.algo)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// lookupRef returns the newest live record for name, or nil.
+func lookupRef(tables []*tableFile, name string) (*refRecord, error) {
+	for i := len(tables) - 1; i >= 0; i-- {
+		rec, err := tables[i].lookupRef(name)
+		if err != nil {
+			return nil, err
+		}
+		if rec == nil {
+			continue
+		}
+		if rec.valueType == refValueDeletion {
+			return nil, nil
+		}
+		return rec, nil
+	}
+	return nil, nil
+}
+
+// resolveReftableSymref follows symbolic ref records starting at name.
+func resolveReftableSymref(tables []*tableFile, name string) (string, error) {
+	for depth := 0; depth < maxSymrefDepth; depth++ {
+		rec, err := lookupRef(tables, name)
+		if err != nil {
+			return "", err
+		}
+		if rec == nil || rec.valueType != refValueSymref {
+			return name, nil
+		}
+		name = rec.target
+	}
+	return "", fmt.Errorf("symbolic ref nesting too deep at %s", name)
+}
+
+// mergedRefs returns the live records of the whole stack sorted by name.
+func mergedRefs(tables []*tableFile) ([]*refRecord, error) {
+	newest := make(map[string]*refRecord)
+	for _, table := range tables {
+		decoded, err := table.decode()
+		if err != nil {
+			return nil, err
+		}
+		for _, rec := range decoded.refs {
+			newest[rec.name] = rec
+		}
+	}
+
+	result := make([]*refRecord, 0, len(newest))
+	for _, rec := range newest {
+		if rec.valueType != refValueDeletion {
+			result = append(result, rec)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].name < result[j].name
+	})
+	return result, nil
+}
+
+// mergedLogs returns the live log records of name, newest first.
+func mergedLogs(tables []*tableFile, name string) ([]*logRecord, error) {
+	byIndex := make(map[uint64]*logRecord)
+	for _, table := range tables {
+		decoded, err := table.decode()
+		if err != nil {
+			return nil, err
+		}
+		for _, rec := range decoded.logs {
+			if rec.refName == name {
+				byIndex[rec.updateIndex] = rec
+			}
+		}
+	}
+
+	result := make([]*logRecord, 0, len(byIndex))
+	for _, rec := range byIndex {
+		if rec.logType != logValueDeletion {
+			result = append(result, rec)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].updateIndex > result[j].updateIndex
+	})
+	return result, nil
+}
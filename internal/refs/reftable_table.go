@@ -0,0 +1,986 @@
+package refs
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+// Reftable on-disk layout:
+//
+//	header: 'REFT' | version | uint24 block_size | uint64 min/max update index
+//	        [version 2 only: 4-byte hash ID, "sha1" or "s256"]
+//	ref blocks, padded to block_size; the first one shares the header's block
+//	ref index blocks: the last name of each ref block and its position, in as
+//	        many levels as it takes for the root to fit in one block
+//	log blocks: 'g' | uint24 uncompressed length | zlib(records, restarts)
+//	footer: header | ref index, obj, obj index, log, log index positions | crc32
+//
+// Records within a block are prefix-compressed against the previous key, with
+// an uncompressed restart point every reftableRestartInterval records, so that
+// a block can be binary searched. Tables of SHA-1 repositories are written as
+// version 1, others as version 2.
+const (
+	reftableMagic           = "REFT"
+	reftableHeaderSize      = 24
+	reftableFooterSize      = 68
+	reftableBlockSize       = 4096
+	reftableRestartInterval = 16
+	// reftableIndexThreshold is the number of ref blocks from which a ref
+	// index is written; fewer are as quick to search without one.
+	reftableIndexThreshold = 4
+
+	blockTypeRef   = 'r'
+	blockTypeLog   = 'g'
+	blockTypeIndex = 'i'
+	blockTypeObj   = 'o'
+
+	refValueDeletion = 0
+	refValueOID      = 1
+	refValuePeeled   = 2
+	refValueSymref   = 3
+
+	logValueDeletion = 0
+	logValueUpdate   = 1
+)
+
+// refRecord is a single ref entry of a table.
+type refRecord struct {
+	name        string
+	updateIndex uint64
+	valueType   byte
+	oid         object.ObjectID
+	peeled      object.ObjectID
+	target      string
+}
+
+// logRecord is a single reflog entry of a table.
+type logRecord struct {
+	refName     string
+	updateIndex uint64
+	logType     byte
+	entry       *LogEntry
+}
+
+// reftable is the decoded content of one table file.
+type reftable struct {
+	minUpdateIndex uint64
+	maxUpdateIndex uint64
+	refs           []*refRecord
+	logs           []*logRecord
+}
+
+// indexRecord points at a block by the last key in it.
+type indexRecord struct {
+	lastKey  []byte
+	position uint64
+}
+
+// tableFile is a table file held in memory, whose records are only decoded
+// as they are needed.
+type tableFile struct {
+	data []byte
+	algo *hashalgo.Algorithm
+
+	headerSize     int
+	blockSize      int
+	minUpdateIndex uint64
+	maxUpdateIndex uint64
+
+	// refEnd is where the ref blocks end and footerStart where the footer
+	// begins.
+	refEnd      int
+	footerStart int
+	// refIndex and logPosition locate the root ref index block and the
+	// first log block, or are zero when the table has none.
+	refIndex    uint64
+	logPosition uint64
+}
+
+func (rec *logRecord) key() []byte {
+	key := append([]byte(rec.refName), 0)
+	return binary.BigEndian.AppendUint64(key, ^rec.updateIndex)
+}
+
+// encodeReftable serializes table into the reftable format, with object IDs
+// of algo.
+func encodeReftable(table *reftable, algo *hashalgo.Algorithm) ([]byte, error) {
+	sort.Slice(table.refs, func(i, j int) bool {
+		return table.refs[i].name < table.refs[j].name
+	})
+	sort.Slice(table.logs, func(i, j int) bool {
+		return bytes.Compare(table.logs[i].key(), table.logs[j].key()) < 0
+	})
+
+	header := reftableHeader(table.minUpdateIndex, table.maxUpdateIndex, algo)
+	out := append([]byte{}, header...)
+
+	// Ref blocks. The first one starts at the beginning of the file.
+	var refBlocks []indexRecord
+	blockStart := 0
+	bw := newBlockWriter(blockTypeRef, len(header))
+	finishRefBlock := func() {
+		refBlocks = append(refBlocks, indexRecord{lastKey: bw.prevKey, position: uint64(blockStart)})
+		out = bw.finish(out, true)
+		blockStart = len(out)
+	}
+	for _, rec := range table.refs {
+		value, err := encodeRefValue(rec, table.minUpdateIndex, algo)
+		if err != nil {
+			return nil, err
+		}
+		if !bw.add([]byte(rec.name), rec.valueType, value) {
+			finishRefBlock()
+			bw = newBlockWriter(blockTypeRef, 0)
+			bw.add([]byte(rec.name), rec.valueType, value)
+		}
+	}
+	if !bw.empty() {
+		finishRefBlock()
+	}
+
+	var refIndex uint64
+	if len(refBlocks) >= reftableIndexThreshold {
+		out, refIndex = writeIndex(out, refBlocks)
+	}
+
+	// Log blocks.
+	var logPosition uint64
+	if len(table.logs) > 0 {
+		logPosition = uint64(len(out))
+		lw := newBlockWriter(blockTypeLog, 0)
+		lw.limit = 2 * reftableBlockSize
+		for _, rec := range table.logs {
+			value, err := encodeLogValue(rec, algo)
+			if err != nil {
+				return nil, err
+			}
+			if !lw.add(rec.key(), rec.logType, value) {
+				if out, err = lw.finishCompressed(out); err != nil {
+					return nil, err
+				}
+				lw = newBlockWriter(blockTypeLog, 0)
+				lw.limit = 2 * reftableBlockSize
+				lw.add(rec.key(), rec.logType, value)
+			}
+		}
+		var err error
+		if out, err = lw.finishCompressed(out); err != nil {
+			return nil, err
+		}
+	}
+
+	// Footer: the header again, section positions and a checksum.
+	footer := append([]byte{}, header...)
+	footer = binary.BigEndian.AppendUint64(footer, refIndex)
+	footer = binary.BigEndian.AppendUint64(footer, 0) // obj position and id length
+	footer = binary.BigEndian.AppendUint64(footer, 0) // obj index
+	footer = binary.BigEndian.AppendUint64(footer, logPosition)
+	footer = binary.BigEndian.AppendUint64(footer, 0) // log index
+	footer = binary.BigEndian.AppendUint32(footer, crc32.ChecksumIEEE(footer))
+
+	return append(out, footer...), nil
+}
+
+// writeIndex appends index blocks pointing at blocks, adding levels until a
+// single block is the root, and returns the position of the root.
+func writeIndex(out []byte, blocks []indexRecord) ([]byte, uint64) {
+	for {
+		var level []indexRecord
+		blockStart := len(out)
+		iw := newBlockWriter(blockTypeIndex, 0)
+		finishIndexBlock := func() {
+			level = append(level, indexRecord{lastKey: iw.prevKey, position: uint64(blockStart)})
+			out = iw.finish(out, true)
+			blockStart = len(out)
+		}
+		for _, block := range blocks {
+			value := putVarint(nil, block.position)
+			if !iw.add(block.lastKey, 0, value) {
+				finishIndexBlock()
+				iw = newBlockWriter(blockTypeIndex, 0)
+				iw.add(block.lastKey, 0, value)
+			}
+		}
+		finishIndexBlock()
+
+		if len(level) == 1 {
+			return out, level[0].position
+		}
+		blocks = level
+	}
+}
+
+// openReftable checks the header and footer of a table file and locates its
+// sections. The table must hold object IDs of algo.
+func openReftable(data []byte, algo *hashalgo.Algorithm) (*tableFile, error) {
+	if len(data) < reftableHeaderSize+reftableFooterSize {
+		return nil, errors.New("reftable: file too short")
+	}
+	if string(data[:4]) != reftableMagic {
+		return nil, errors.New("reftable: bad magic")
+	}
+
+	version := data[4]
+	headerSize, footerSize := reftableHeaderSize, reftableFooterSize
+	switch version {
+	case 1:
+		if algo != hashalgo.SHA1 {
+			return nil, fmt.Errorf("reftable: version 1 table in a %s repository", algo.Name)
+		}
+	case 2:
+		// Version 2 appends a hash ID to the header.
+		headerSize += 4
+		footerSize += 4
+		if hashID := string(data[24:28]); hashID != algo.FormatID {
+			return nil, fmt.Errorf("reftable: hash %q does not match repository format %s", hashID, algo.Name)
+		}
+	default:
+		return nil, fmt.Errorf("reftable: unsupported version %d", version)
+	}
+	if len(data) < headerSize+footerSize {
+		return nil, errors.New("reftable: file too short")
+	}
+
+	footerStart := len(data) - footerSize
+	footer := data[footerStart:]
+	if !bytes.Equal(footer[:headerSize], data[:headerSize]) {
+		return nil, errors.New("reftable: footer does not match header")
+	}
+	crcAt := footerSize - 4
+	if crc32.ChecksumIEEE(footer[:crcAt]) != binary.BigEndian.Uint32(footer[crcAt:]) {
+		return nil, errors.New("reftable: footer checksum mismatch")
+	}
+
+	t := &tableFile{
+		data:           data,
+		algo:           algo,
+		headerSize:     headerSize,
+		blockSize:      int(getUint24(data[5:8])),
+		minUpdateIndex: binary.BigEndian.Uint64(data[8:16]),
+		maxUpdateIndex: binary.BigEndian.Uint64(data[16:24]),
+		footerStart:    footerStart,
+	}
+
+	positions := footer[headerSize:crcAt]
+	t.refIndex = binary.BigEndian.Uint64(positions[0:8])
+	objPosition := binary.BigEndian.Uint64(positions[8:16]) >> 5
+	t.logPosition = binary.BigEndian.Uint64(positions[24:32])
+	for _, pos := range []uint64{t.refIndex, objPosition, t.logPosition} {
+		if pos >= uint64(footerStart) {
+			return nil, errors.New("reftable: section position beyond the footer")
+		}
+	}
+
+	// The ref section runs until the first of the following sections.
+	t.refEnd = footerStart
+	for _, pos := range []uint64{t.refIndex, objPosition, t.logPosition} {
+		if pos > 0 && int(pos) < t.refEnd {
+			t.refEnd = int(pos)
+		}
+	}
+	return t, nil
+}
+
+// blockAt reads the header of the block at off, within the section ending
+// at end.
+func (t *tableFile) blockAt(off int, end int) (*blockReader, error) {
+	typeAt := off
+	if off == 0 {
+		// The first block shares its bytes with the file header
+		typeAt = t.headerSize
+	}
+	if typeAt >= end {
+		return nil, nil
+	}
+	return newBlockReader(t.data[:end], off, typeAt, t.algo.Size)
+}
+
+// decode reads every ref and log block of the table.
+func (t *tableFile) decode() (*reftable, error) {
+	table := &reftable{
+		minUpdateIndex: t.minUpdateIndex,
+		maxUpdateIndex: t.maxUpdateIndex,
+	}
+
+	for off := 0; off < t.refEnd; {
+		block, err := t.blockAt(off, t.refEnd)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil || block.blockType != blockTypeRef {
+			break
+		}
+
+		records, err := block.records()
+		if err != nil {
+			return nil, err
+		}
+		for _, raw := range records {
+			rec, err := decodeRefRecord(raw, t.minUpdateIndex, t.algo)
+			if err != nil {
+				return nil, err
+			}
+			table.refs = append(table.refs, rec)
+		}
+
+		off = nextBlockOffset(t.data, off, len(block.block), t.blockSize)
+	}
+
+	if t.logPosition > 0 {
+		for off := int(t.logPosition); off < t.footerStart && t.data[off] == blockTypeLog; {
+			data, consumed, err := inflateLogBlock(t.data[off:t.footerStart])
+			if err != nil {
+				return nil, err
+			}
+			block, err := newBlockReader(data, 0, 0, t.algo.Size)
+			if err != nil {
+				return nil, err
+			}
+			records, err := block.records()
+			if err != nil {
+				return nil, err
+			}
+			for _, raw := range records {
+				rec, err := decodeLogRecord(raw, t.algo)
+				if err != nil {
+					return nil, err
+				}
+				table.logs = append(table.logs, rec)
+			}
+			off += consumed
+		}
+	}
+
+	return table, nil
+}
+
+// lookupRef returns the record for name in the table, or nil when it has
+// none. Only the blocks the ref index leads to are read, or, without an
+// index, each ref block until one holds a name not before name.
+func (t *tableFile) lookupRef(name string) (*refRecord, error) {
+	key := []byte(name)
+
+	var raw *rawRecord
+	if t.refIndex > 0 {
+		for off := int(t.refIndex); ; {
+			block, err := t.blockAt(off, t.footerStart)
+			if err != nil {
+				return nil, err
+			}
+			if block == nil {
+				return nil, errors.New("reftable: bad ref index position")
+			}
+			if raw, err = block.seek(key); err != nil || raw == nil {
+				return nil, err
+			}
+			if block.blockType == blockTypeRef {
+				break
+			}
+			if block.blockType != blockTypeIndex {
+				return nil, fmt.Errorf("reftable: unexpected block type %c in ref index", block.blockType)
+			}
+
+			position, n := getVarint(raw.value)
+			if n == 0 || position >= uint64(t.refEnd) {
+				return nil, errors.New("reftable: bad ref index record")
+			}
+			off = int(position)
+		}
+	} else {
+		for off := 0; off < t.refEnd && raw == nil; {
+			block, err := t.blockAt(off, t.refEnd)
+			if err != nil {
+				return nil, err
+			}
+			if block == nil || block.blockType != blockTypeRef {
+				return nil, nil
+			}
+			if raw, err = block.seek(key); err != nil {
+				return nil, err
+			}
+			off = nextBlockOffset(t.data, off, len(block.block), t.blockSize)
+		}
+	}
+
+	if raw == nil || !bytes.Equal(raw.key, key) {
+		return nil, nil
+	}
+	return decodeRefRecord(raw, t.minUpdateIndex, t.algo)
+}
+
+func reftableHeader(minIndex uint64, maxIndex uint64, algo *hashalgo.Algorithm) []byte {
+	version := byte(1)
+	if algo != hashalgo.SHA1 {
+		version = 2
+	}
+
+	header := []byte(reftableMagic)
+	header = append(header, version)
+	header = appendUint24(header, reftableBlockSize)
+	header = binary.BigEndian.AppendUint64(header, minIndex)
+	header = binary.BigEndian.AppendUint64(header, maxIndex)
+	if version == 2 {
+		header = append(header, algo.FormatID...)
+	}
+	return header
+}
+
+// nextBlockOffset returns where the block after the one at start begins.
+// Blocks are either padded with zeros to blockSize or packed back to back.
+func nextBlockOffset(data []byte, start int, blockLen int, blockSize int) int {
+	end := start + blockLen
+	if blockSize > 0 && blockLen < blockSize && end < len(data) && data[end] == 0 {
+		return start + blockSize
+	}
+	return end
+}
+
+// rawRecord is a record whose key has been expanded from the prefix encoding.
+type rawRecord struct {
+	key       []byte
+	valueType byte
+	value     []byte
+}
+
+// blockReader decodes the records of one block.
+type blockReader struct {
+	// block runs from the start of the block, which for the first block is
+	// the start of the file, to its end.
+	block     []byte
+	blockType byte
+	hashSize  int
+
+	recordsStart int
+	recordsEnd   int
+	restartCount int
+}
+
+// newBlockReader reads the header and restart table of the block beginning
+// at start, whose type byte sits at typeAt. Object IDs in the records are
+// hashSize bytes long.
+func newBlockReader(data []byte, start int, typeAt int, hashSize int) (*blockReader, error) {
+	if typeAt+4 > len(data) {
+		return nil, errors.New("reftable: truncated block header")
+	}
+
+	blockLen := int(getUint24(data[typeAt+1 : typeAt+4]))
+	if start+blockLen > len(data) || blockLen < typeAt-start+6 {
+		return nil, errors.New("reftable: bad block length")
+	}
+	block := data[start : start+blockLen]
+
+	restartCount := int(binary.BigEndian.Uint16(block[blockLen-2:]))
+	recordsEnd := blockLen - 2 - 3*restartCount
+	if recordsEnd < typeAt-start+4 {
+		return nil, errors.New("reftable: bad restart table")
+	}
+
+	return &blockReader{
+		block:        block,
+		blockType:    data[typeAt],
+		hashSize:     hashSize,
+		recordsStart: typeAt - start + 4,
+		recordsEnd:   recordsEnd,
+		restartCount: restartCount,
+	}, nil
+}
+
+// restart returns the offset of the ith restart point.
+func (b *blockReader) restart(i int) (int, error) {
+	off := int(getUint24(b.block[b.recordsEnd+3*i:]))
+	if off < b.recordsStart || off >= b.recordsEnd {
+		return 0, errors.New("reftable: bad restart offset")
+	}
+	return off, nil
+}
+
+// record decodes the record at pos, whose key shares a prefix with prevKey,
+// and returns it with the offset of the next record.
+func (b *blockReader) record(pos int, prevKey []byte) (*rawRecord, int, error) {
+	prefixLen, n := getVarint(b.block[pos:b.recordsEnd])
+	if n == 0 {
+		return nil, 0, errors.New("reftable: bad record prefix")
+	}
+	pos += n
+	suffixType, n := getVarint(b.block[pos:b.recordsEnd])
+	if n == 0 {
+		return nil, 0, errors.New("reftable: bad record suffix")
+	}
+	pos += n
+
+	suffixLen := int(suffixType >> 3)
+	if prefixLen > uint64(len(prevKey)) || pos+suffixLen > b.recordsEnd {
+		return nil, 0, errors.New("reftable: bad record key")
+	}
+	key := append(append([]byte{}, prevKey[:prefixLen]...), b.block[pos:pos+suffixLen]...)
+	pos += suffixLen
+
+	rec := &rawRecord{key: key, valueType: byte(suffixType & 0x7)}
+	size, err := valueSize(b.block[pos:b.recordsEnd], b.blockType, rec.valueType, b.hashSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	rec.value = b.block[pos : pos+size]
+	return rec, pos + size, nil
+}
+
+// records decodes every record of the block.
+func (b *blockReader) records() ([]*rawRecord, error) {
+	var records []*rawRecord
+	var prevKey []byte
+	for pos := b.recordsStart; pos < b.recordsEnd; {
+		rec, next, err := b.record(pos, prevKey)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+		prevKey, pos = rec.key, next
+	}
+	return records, nil
+}
+
+// seek returns the first record whose key is not before key, or nil when
+// every key in the block is. Restart points hold whole keys, so they are
+// binary searched for the last one not after key, and the records from there
+// are decoded in turn.
+func (b *blockReader) seek(key []byte) (*rawRecord, error) {
+	var searchErr error
+	i := sort.Search(b.restartCount, func(i int) bool {
+		off, err := b.restart(i)
+		if err == nil {
+			var rec *rawRecord
+			if rec, _, err = b.record(off, nil); err == nil {
+				return bytes.Compare(rec.key, key) > 0
+			}
+		}
+		searchErr = err
+		return true
+	})
+	if searchErr != nil {
+		return nil, searchErr
+	}
+
+	pos := b.recordsStart
+	if i > 0 {
+		var err error
+		if pos, err = b.restart(i - 1); err != nil {
+			return nil, err
+		}
+	}
+
+	var prevKey []byte
+	for pos < b.recordsEnd {
+		rec, next, err := b.record(pos, prevKey)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Compare(rec.key, key) >= 0 {
+			return rec, nil
+		}
+		prevKey, pos = rec.key, next
+	}
+	return nil, nil
+}
+
+// valueSize works out how many bytes the value of a record occupies.
+func valueSize(data []byte, blockType byte, valueType byte, hashSize int) (int, error) {
+	pos := 0
+	switch blockType {
+	case blockTypeRef:
+		_, n := getVarint(data)
+		if n == 0 {
+			return 0, errors.New("reftable: bad update index")
+		}
+		pos += n
+		switch valueType {
+		case refValueDeletion:
+		case refValueOID:
+			pos += hashSize
+		case refValuePeeled:
+			pos += 2 * hashSize
+		case refValueSymref:
+			length, n := getVarint(data[pos:])
+			if n == 0 {
+				return 0, errors.New("reftable: bad symref")
+			}
+			pos += n + int(length)
+		default:
+			return 0, fmt.Errorf("reftable: unknown ref value type %d", valueType)
+		}
+	case blockTypeLog:
+		if valueType == logValueDeletion {
+			return 0, nil
+		}
+		pos += 2 * hashSize
+		// name and email, the time and tz offset, then the message.
+		for _, field := range []string{"name", "email", "time", "message"} {
+			length, n := getVarint(data[min(pos, len(data)):])
+			if n == 0 {
+				return 0, fmt.Errorf("reftable: bad log record %s", field)
+			}
+			pos += n
+			switch field {
+			case "time":
+				pos += 2
+			default:
+				pos += int(length)
+			}
+		}
+	case blockTypeIndex:
+		// The position of the block the record points at
+		_, n := getVarint(data)
+		if n == 0 {
+			return 0, errors.New("reftable: bad index record")
+		}
+		pos += n
+	case blockTypeObj:
+		return 0, fmt.Errorf("reftable: unexpected block type %c", blockType)
+	}
+
+	if pos > len(data) {
+		return 0, errors.New("reftable: truncated record")
+	}
+	return pos, nil
+}
+
+func decodeRefRecord(raw *rawRecord, minUpdateIndex uint64, algo *hashalgo.Algorithm) (*refRecord, error) {
+	delta, n := getVarint(raw.value)
+	value := raw.value[n:]
+
+	rec := &refRecord{
+		name:        string(raw.key),
+		updateIndex: minUpdateIndex + delta,
+		valueType:   raw.valueType,
+	}
+
+	var err error
+	switch raw.valueType {
+	case refValueOID:
+		rec.oid, err = object.OIDFromBytes(value[:algo.Size], algo)
+	case refValuePeeled:
+		if rec.oid, err = object.OIDFromBytes(value[:algo.Size], algo); err == nil {
+			rec.peeled, err = object.OIDFromBytes(value[algo.Size:2*algo.Size], algo)
+		}
+	case refValueSymref:
+		length, n := getVarint(value)
+		rec.target = string(value[n : n+int(length)])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reftable: ref %s: %w", rec.name, err)
+	}
+
+	return rec, nil
+}
+
+func decodeLogRecord(raw *rawRecord, algo *hashalgo.Algorithm) (*logRecord, error) {
+	nul := bytes.IndexByte(raw.key, 0)
+	if nul < 0 || len(raw.key)-nul-1 != 8 {
+		return nil, errors.New("reftable: bad log key")
+	}
+
+	rec := &logRecord{
+		refName:     string(raw.key[:nul]),
+		updateIndex: ^binary.BigEndian.Uint64(raw.key[nul+1:]),
+		logType:     raw.valueType,
+	}
+	if rec.logType == logValueDeletion {
+		return rec, nil
+	}
+
+	value := raw.value
+	oldOID, err := object.OIDFromBytes(value[:algo.Size], algo)
+	if err != nil {
+		return nil, fmt.Errorf("reftable: log of %s: %w", rec.refName, err)
+	}
+	newOID, err := object.OIDFromBytes(value[algo.Size:2*algo.Size], algo)
+	if err != nil {
+		return nil, fmt.Errorf("reftable: log of %s: %w", rec.refName, err)
+	}
+	entry := &LogEntry{
+		OldOID: oldOID,
+		NewOID: newOID,
+	}
+	pos := 2 * algo.Size
+
+	readString := func() string {
+		length, n := getVarint(value[pos:])
+		pos += n
+		s := string(value[pos : pos+int(length)])
+		pos += int(length)
+		return s
+	}
+
+	entry.Name = readString()
+	entry.Email = readString()
+	seconds, n := getVarint(value[pos:])
+	pos += n
+	// Like the timezone of a commit, tz_offset is a signed HHMM value.
+	tz := int(int16(binary.BigEndian.Uint16(value[pos:])))
+	tzMinutes := tz/100*60 + tz%100
+	pos += 2
+	entry.Message = readString()
+
+	// git terminates messages with a newline; LogEntry holds the bare text.
+	if l := len(entry.Message); l > 0 && entry.Message[l-1] == '\n' {
+		entry.Message = entry.Message[:l-1]
+	}
+	entry.Time = time.Unix(int64(seconds), 0).In(time.FixedZone("", tzMinutes*60))
+
+	rec.entry = entry
+	return rec, nil
+}
+
+func encodeRefValue(rec *refRecord, minUpdateIndex uint64, algo *hashalgo.Algorithm) ([]byte, error) {
+	value := putVarint(nil, rec.updateIndex-minUpdateIndex)
+
+	switch rec.valueType {
+	case refValueDeletion:
+	case refValueOID, refValuePeeled:
+		oid, err := hashBytes(rec.oid, algo)
+		if err != nil {
+			return nil, err
+		}
+		value = append(value, oid...)
+		if rec.valueType == refValuePeeled {
+			peeled, err := hashBytes(rec.peeled, algo)
+			if err != nil {
+				return nil, err
+			}
+			value = append(value, peeled...)
+		}
+	case refValueSymref:
+		value = putVarint(value, uint64(len(rec.target)))
+		value = append(value, rec.target...)
+	}
+
+	return value, nil
+}
+
+func encodeLogValue(rec *logRecord, algo *hashalgo.Algorithm) ([]byte, error) {
+	if rec.logType == logValueDeletion {
+		return nil, nil
+	}
+
+	entry := rec.entry
+	oldOID, err := hashBytes(entry.OldOID, algo)
+	if err != nil {
+		return nil, err
+	}
+	newOID, err := hashBytes(entry.NewOID, algo)
+	if err != nil {
+		return nil, err
+	}
+
+	message := entry.Message
+	if message != "" && message[len(message)-1] != '\n' {
+		message += "\n"
+	}
+	_, offset := entry.Time.Zone()
+	tzMinutes := offset / 60
+	tz := tzMinutes/60*100 + tzMinutes%60
+
+	value := append(append([]byte{}, oldOID...), newOID...)
+	value = putVarint(value, uint64(len(entry.Name)))
+	value = append(value, entry.Name...)
+	value = putVarint(value, uint64(len(entry.Email)))
+	value = append(value, entry.Email...)
+	value = putVarint(value, uint64(entry.Time.Unix()))
+	value = binary.BigEndian.AppendUint16(value, uint16(int16(tz)))
+	value = putVarint(value, uint64(len(message)))
+	value = append(value, message...)
+
+	return value, nil
+}
+
+// hashBytes returns the raw form of oid, which must be an ID of algo.
+func hashBytes(oid object.ObjectID, algo *hashalgo.Algorithm) ([]byte, error) {
+	if oid.Size() != algo.Size {
+		return nil, fmt.Errorf("reftable: invalid %s object ID '%s'", algo.Name, oid)
+	}
+	return oid.Bytes(), nil
+}
+
+// blockWriter accumulates prefix-compressed records for one block.
+type blockWriter struct {
+	blockType byte
+	// offset is the number of bytes preceding the block header within the
+	// block, i.e. the file header for the first ref block.
+	offset   int
+	limit    int
+	buf      []byte
+	restarts []int
+	prevKey  []byte
+	count    int
+}
+
+func newBlockWriter(blockType byte, offset int) *blockWriter {
+	return &blockWriter{
+		blockType: blockType,
+		offset:    offset,
+		limit:     reftableBlockSize,
+		buf:       []byte{blockType, 0, 0, 0},
+	}
+}
+
+func (w *blockWriter) empty() bool {
+	return w.count == 0
+}
+
+// add appends a record, returning false when it does not fit in the block.
+// A record is always accepted into an empty block.
+func (w *blockWriter) add(key []byte, valueType byte, value []byte) bool {
+	restart := w.count%reftableRestartInterval == 0
+
+	prefix := 0
+	if !restart {
+		for prefix < len(key) && prefix < len(w.prevKey) && key[prefix] == w.prevKey[prefix] {
+			prefix++
+		}
+	}
+
+	rec := putVarint(nil, uint64(prefix))
+	rec = putVarint(rec, uint64(len(key)-prefix)<<3|uint64(valueType))
+	rec = append(rec, key[prefix:]...)
+	rec = append(rec, value...)
+
+	restarts := len(w.restarts)
+	if restart {
+		restarts++
+	}
+	size := w.offset + len(w.buf) + len(rec) + 3*restarts + 2
+	if w.count > 0 && size > w.limit {
+		return false
+	}
+
+	if restart {
+		w.restarts = append(w.restarts, w.offset+len(w.buf))
+	}
+	w.buf = append(w.buf, rec...)
+	w.prevKey = key
+	w.count++
+	return true
+}
+
+// encode appends the restart table and fills in the block length.
+func (w *blockWriter) encode() []byte {
+	block := w.buf
+	for _, restart := range w.restarts {
+		block = appendUint24(block, uint32(restart))
+	}
+	block = binary.BigEndian.AppendUint16(block, uint16(len(w.restarts)))
+	putUint24(block[1:4], uint32(w.offset+len(block)))
+	return block
+}
+
+// finish appends the block to out, padding it to the block size if asked.
+func (w *blockWriter) finish(out []byte, pad bool) []byte {
+	block := w.encode()
+	out = append(out, block...)
+	if pad {
+		for used := w.offset + len(block); used < reftableBlockSize; used++ {
+			out = append(out, 0)
+		}
+	}
+	return out
+}
+
+// finishCompressed appends the block with everything after its 4-byte
+// header deflated, as log blocks are stored.
+func (w *blockWriter) finishCompressed(out []byte) ([]byte, error) {
+	block := w.encode()
+	out = append(out, block[:4]...)
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(block[4:]); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return append(out, compressed.Bytes()...), nil
+}
+
+// inflateLogBlock decompresses the log block at the start of data and returns
+// it with its header, plus the number of bytes it occupied on disk.
+func inflateLogBlock(data []byte) ([]byte, int, error) {
+	if len(data) < 4 {
+		return nil, 0, errors.New("reftable: truncated log block")
+	}
+	blockLen := int(getUint24(data[1:4]))
+
+	src := bytes.NewReader(data[4:])
+	zr, err := zlib.NewReader(src)
+	if err != nil {
+		return nil, 0, fmt.Errorf("reftable: log block: %w", err)
+	}
+	block := make([]byte, blockLen)
+	copy(block, data[:4])
+	if _, err := io.ReadFull(zr, block[4:]); err != nil {
+		return nil, 0, fmt.Errorf("reftable: log block: %w", err)
+	}
+	// Drain the stream so the adler32 trailer is consumed and verified.
+	if _, err := io.Copy(io.Discard, zr); err != nil {
+		return nil, 0, fmt.Errorf("reftable: log block: %w", err)
+	}
+
+	consumed := 4 + len(data) - 4 - src.Len()
+	return block, consumed, nil
+}
+
+// putVarint appends value using the reftable varint encoding, which is the
+// same as the offset encoding of packfile ofs-deltas.
+func putVarint(dst []byte, value uint64) []byte {
+	var buf [10]byte
+	pos := len(buf) - 1
+	buf[pos] = byte(value & 0x7f)
+	for value >>= 7; value != 0; value >>= 7 {
+		value--
+		pos--
+		buf[pos] = 0x80 | byte(value&0x7f)
+	}
+	return append(dst, buf[pos:]...)
+}
+
+// getVarint decodes a varint, returning the value and bytes consumed, or zero
+// consumed bytes when data is truncated.
+func getVarint(data []byte) (uint64, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	value := uint64(data[0] & 0x7f)
+	n := 1
+	for data[n-1]&0x80 != 0 {
+		if n >= len(data) || n > 9 {
+			return 0, 0
+		}
+		value = (value+1)<<7 | uint64(data[n]&0x7f)
+		n++
+	}
+	return value, n
+}
+
+func appendUint24(dst []byte, value uint32) []byte {
+	return append(dst, byte(value>>16), byte(value>>8), byte(value))
+}
+
+func putUint24(dst []byte, value uint32) {
+	dst[0] = byte(value >> 16)
+	dst[1] = byte(value >> 8)
+	dst[2] = byte(value)
+}
+
+func getUint24(src []byte) uint32 {
+	return uint32(src[0])<<16 | uint32(src[1])<<8 | uint32(src[2])
+}
@@ -0,0 +1,150 @@
+package refs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+)
+
+// newTestReftable returns a reftable backend in a temporary directory.
+func newTestReftable(t *testing.T) *ReftableRefs {
+	t.Helper()
+	gitPath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(gitPath, ReftableDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return NewReftableRefs(gitPath, hashalgo.SHA1)
+}
+
+func TestReftableReadDuringCompaction(t *testing.T) {
+	r := newTestReftable(t)
+	oid := testOID(t, 'c')
+	if err := r.UpdateRef("refs/heads/main", oid, "create"); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Every update appends a table and compacts the stack, deleting the
+		// tables it replaces
+		for i := 0; i < 200; i++ {
+			if err := r.UpdateRef(fmt.Sprintf("refs/heads/b%d", i), oid, "create"); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		got, err := r.ReadRef("refs/heads/main")
+		if err != nil {
+			t.Fatalf("ReadRef during compaction: %v", err)
+		}
+		if got != oid {
+			t.Fatalf("ReadRef = %s, want %s", got, oid)
+		}
+	}
+}
+
+func TestReftableLookupThroughRefIndex(t *testing.T) {
+	oid := testOID(t, 'd')
+	table := &reftable{minUpdateIndex: 1, maxUpdateIndex: 1}
+	for i := 0; i < 2000; i++ {
+		table.refs = append(table.refs, &refRecord{
+			name: fmt.Sprintf("refs/heads/topic/%04d", i), updateIndex: 1, valueType: refValueOID, oid: oid,
+		})
+	}
+
+	data, err := encodeReftable(table, hashalgo.SHA1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := openReftable(data, hashalgo.SHA1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.refIndex == 0 {
+		t.Fatalf("no ref index written for %d bytes of refs", file.refEnd)
+	}
+
+	for _, rec := range table.refs {
+		got, err := file.lookupRef(rec.name)
+		if err != nil {
+			t.Fatalf("lookupRef(%s): %v", rec.name, err)
+		}
+		if got == nil || got.name != rec.name || got.oid != oid {
+			t.Fatalf("lookupRef(%s) = %+v", rec.name, got)
+		}
+	}
+	for _, name := range []string{"refs/heads/a", "refs/heads/topic/0500x", "refs/heads/zz"} {
+		got, err := file.lookupRef(name)
+		if err != nil {
+			t.Fatalf("lookupRef(%s): %v", name, err)
+		}
+		if got != nil {
+			t.Errorf("lookupRef(%s) = %s, want none", name, got.name)
+		}
+	}
+
+	decoded, err := file.decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.refs) != len(table.refs) {
+		t.Errorf("decode returned %d refs, want %d", len(decoded.refs), len(table.refs))
+	}
+}
+
+func TestReftableLogTimezoneIsHHMM(t *testing.T) {
+	tests := []struct {
+		zone  *time.Location
+		bytes [2]byte
+	}{
+		{time.FixedZone("", -8*3600), [2]byte{0xfc, 0xe0}},           // -0800
+		{time.FixedZone("", 5*3600+30*60), [2]byte{0x02, 0x12}},      // +0530
+		{time.FixedZone("", -(3*3600 + 30*60)), [2]byte{0xfe, 0xb6}}, // -0330
+	}
+
+	for _, tt := range tests {
+		when := time.Unix(1700000000, 0).In(tt.zone)
+		rec := &logRecord{
+			refName: HeadRef, updateIndex: 1, logType: logValueUpdate,
+			entry: &LogEntry{
+				OldOID: testOID(t, '0'), NewOID: testOID(t, 'e'),
+				Name: "A U Thor", Email: "author@example.com", Time: when, Message: "commit: one",
+			},
+		}
+		value, err := encodeLogValue(rec, hashalgo.SHA1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The tz_offset follows the varint timestamp, which ends the fixed
+		// part before the message.
+		tzAt := len(value) - 1 - len("commit: one\n") - 2
+		if got := [2]byte(value[tzAt : tzAt+2]); got != tt.bytes {
+			t.Errorf("%s: tz_offset = % x, want % x", when.Format("-0700"), got, tt.bytes)
+		}
+
+		decoded, err := decodeLogRecord(&rawRecord{key: rec.key(), valueType: logValueUpdate, value: value}, hashalgo.SHA1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := decoded.entry.Time.Format("-0700"); got != when.Format("-0700") {
+			t.Errorf("decoded zone %s, want %s", got, when.Format("-0700"))
+		}
+		if !decoded.entry.Time.Equal(when) {
+			t.Errorf("decoded time %s, want %s", decoded.entry.Time, when)
+		}
+	}
+}
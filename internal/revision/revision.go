@@ -0,0 +1,252 @@
+package revision
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/object"
+	"github.com/shanmugharajk/gogit/internal/refs"
+	"github.com/shanmugharajk/gogit/internal/storage"
+)
+
+var (
+	// peelPattern matches "<rev>^{<type>}" and "<rev>^{}".
+	peelPattern = regexp.MustCompile(`^(.+)\^\{(\w*)\}$`)
+	// parentPattern matches "<rev>^" and "<rev>^<n>".
+	parentPattern = regexp.MustCompile(`^(.+)\^(\d*)$`)
+	// ancestorPattern matches "<rev>~" and "<rev>~<n>".
+	ancestorPattern = regexp.MustCompile(`^(.+)~(\d*)$`)
+	// oidPattern matches full and abbreviated object IDs.
+	oidPattern = regexp.MustCompile(`^[0-9a-fA-F]{4,64}$`)
+	// invalidRefPattern rejects names git would never accept as refs.
+	invalidRefPattern = regexp.MustCompile(`^\.|/\.|\.\.|^/|/$|\.lock$|@\{|[\x00-\x20*:?\[\\^~\x7f]`)
+
+	// refSearchPatterns is the order git tries when expanding a short ref name.
+	refSearchPatterns = []string{
+		"%s",
+		"refs/%s",
+		"refs/tags/%s",
+		"refs/heads/%s",
+		"refs/remotes/%s",
+		"refs/remotes/%s/HEAD",
+	}
+)
+
+// Revision resolves revision expressions such as "HEAD", "main~2", "@^",
+// "v1^{tree}", "HEAD:docs/README" or an abbreviated object ID.
+type Revision struct {
+	refs refs.Refs
+	db   storage.ObjectStore
+	expr string
+}
+
+// New creates a Revision for expr in the given repository.
+func New(refsStore refs.Refs, db storage.ObjectStore, expr string) *Revision {
+	return &Revision{
+		refs: refsStore,
+		db:   db,
+		expr: expr,
+	}
+}
+
+// Resolve returns the OID of the object the expression names.
+func (r *Revision) Resolve() (object.ObjectID, error) {
+	oid, err := r.resolve(r.expr)
+	if err != nil {
+		return object.ObjectID{}, err
+	}
+	if oid.IsZero() {
+		return object.ObjectID{}, &InvalidError{Expr: r.expr, Reason: fmt.Sprintf("Not a valid object name: '%s'.", r.expr)}
+	}
+	return oid, nil
+}
+
+// ResolveCommit is like Resolve but requires the result to be a commit.
+func (r *Revision) ResolveCommit() (object.ObjectID, error) {
+	oid, err := r.Resolve()
+	if err != nil {
+		return object.ObjectID{}, err
+	}
+	if _, err := r.db.LoadCommit(oid); err != nil {
+		return object.ObjectID{}, &InvalidError{Expr: r.expr, Reason: err.Error()}
+	}
+	return oid, nil
+}
+
+func (r *Revision) resolve(expr string) (object.ObjectID, error) {
+	if rev, pathname, ok := strings.Cut(expr, ":"); ok && rev != "" {
+		oid, err := r.resolve(rev)
+		if err != nil || oid.IsZero() {
+			return object.ObjectID{}, err
+		}
+		if oid, err = r.peel(oid, "tree"); err != nil {
+			return object.ObjectID{}, err
+		}
+		return r.treeEntry(oid, rev, pathname)
+	}
+
+	if m := peelPattern.FindStringSubmatch(expr); m != nil {
+		oid, err := r.resolve(m[1])
+		if err != nil || oid.IsZero() {
+			return object.ObjectID{}, err
+		}
+		return r.peel(oid, m[2])
+	}
+
+	if m := parentPattern.FindStringSubmatch(expr); m != nil {
+		n, err := count(m[2])
+		if err != nil {
+			return object.ObjectID{}, err
+		}
+		oid, err := r.resolve(m[1])
+		if err != nil || oid.IsZero() {
+			return object.ObjectID{}, err
+		}
+		if n == 0 {
+			return oid, nil
+		}
+		return r.parent(oid, n)
+	}
+
+	if m := ancestorPattern.FindStringSubmatch(expr); m != nil {
+		n, err := count(m[2])
+		if err != nil {
+			return object.ObjectID{}, err
+		}
+		oid, err := r.resolve(m[1])
+		for i := 0; i < n && err == nil && !oid.IsZero(); i++ {
+			oid, err = r.parent(oid, 1)
+		}
+		return oid, err
+	}
+
+	if expr == "@" {
+		expr = refs.HeadRef
+	}
+
+	if oid, err := r.readRef(expr); err != nil || !oid.IsZero() {
+		return oid, err
+	}
+
+	if oidPattern.MatchString(expr) {
+		return r.matchOID(expr)
+	}
+
+	return object.ObjectID{}, nil
+}
+
+// readRef expands a possibly short ref name in git's search order.
+func (r *Revision) readRef(name string) (object.ObjectID, error) {
+	if invalidRefPattern.MatchString(name) {
+		return object.ObjectID{}, nil
+	}
+
+	for _, pattern := range refSearchPatterns {
+		oid, err := r.refs.ReadRef(fmt.Sprintf(pattern, name))
+		if err != nil || !oid.IsZero() {
+			return oid, err
+		}
+	}
+	return object.ObjectID{}, nil
+}
+
+// matchOID resolves a full or abbreviated object ID.
+func (r *Revision) matchOID(prefix string) (object.ObjectID, error) {
+	matches, err := r.db.PrefixMatch(prefix)
+	if err != nil {
+		return object.ObjectID{}, err
+	}
+
+	switch len(matches) {
+	case 0:
+		return object.ObjectID{}, nil
+	case 1:
+		return matches[0], nil
+	default:
+		return object.ObjectID{}, &InvalidError{Expr: prefix, Reason: fmt.Sprintf("short object ID %s is ambiguous", prefix)}
+	}
+}
+
+// parent returns the nth parent of the commit oid.
+func (r *Revision) parent(oid object.ObjectID, n int) (object.ObjectID, error) {
+	c, err := r.db.LoadCommit(oid)
+	if err != nil {
+		return object.ObjectID{}, &InvalidError{Expr: r.expr, Reason: err.Error()}
+	}
+	if n > len(c.Parents) {
+		return object.ObjectID{}, nil
+	}
+	return c.Parents[n-1], nil
+}
+
+// peel follows tags, and commits to their tree, until it reaches an object
+// of type objType. An empty objType peels tags only; "object" accepts any.
+func (r *Revision) peel(oid object.ObjectID, objType string) (object.ObjectID, error) {
+	for {
+		actual, data, err := r.db.ReadObject(oid)
+		if err != nil {
+			return object.ObjectID{}, &InvalidError{Expr: r.expr, Reason: err.Error()}
+		}
+
+		switch {
+		case actual == objType, objType == "object", objType == "" && actual != "tag":
+			return oid, nil
+		case actual == "tag":
+			target, _, _ := strings.Cut(strings.TrimPrefix(string(data), "object "), "\n")
+			if oid, err = object.ParseOID(target, r.db.Algorithm()); err != nil {
+				return object.ObjectID{}, &InvalidError{Expr: r.expr, Reason: fmt.Sprintf("bad tag: %s", err)}
+			}
+		case actual == "commit" && objType == "tree":
+			c, err := r.db.LoadCommit(oid)
+			if err != nil {
+				return object.ObjectID{}, &InvalidError{Expr: r.expr, Reason: err.Error()}
+			}
+			oid = c.TreeOID
+		default:
+			return object.ObjectID{}, &InvalidError{Expr: r.expr, Reason: fmt.Sprintf("%s is a %s, not a %s", r.expr, actual, objType)}
+		}
+	}
+}
+
+// treeEntry returns the OID of the entry at pathname inside the tree oid,
+// which rev named.
+func (r *Revision) treeEntry(oid object.ObjectID, rev string, pathname string) (object.ObjectID, error) {
+	pathname = path.Clean("/" + pathname)
+	if pathname == "/" {
+		return oid, nil
+	}
+
+	for _, name := range strings.Split(pathname[1:], "/") {
+		tree, err := r.db.LoadTree(oid)
+		if err != nil {
+			return object.ObjectID{}, &InvalidError{Expr: r.expr, Reason: err.Error()}
+		}
+		entry := tree.Get(name)
+		if entry == nil {
+			return object.ObjectID{}, &InvalidError{Expr: r.expr, Reason: fmt.Sprintf("path '%s' does not exist in '%s'", pathname[1:], rev)}
+		}
+		oid = entry.GetOID()
+	}
+	return oid, nil
+}
+
+// count parses the optional number after ^ or ~, which defaults to one.
+func count(digits string) (int, error) {
+	if digits == "" {
+		return 1, nil
+	}
+	return strconv.Atoi(strings.TrimSpace(digits))
+}
+
+// InvalidError reports a revision expression that cannot be resolved.
+type InvalidError struct {
+	Expr   string
+	Reason string
+}
+
+func (e *InvalidError) Error() string {
+	return e.Reason
+}
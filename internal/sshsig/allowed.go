@@ -0,0 +1,210 @@
+package sshsig
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// AllowedSigner is one line of an allowed signers file: the principals
+// trusted to sign with a key, and the limits on what they may sign.
+type AllowedSigner struct {
+	// Principals are patterns for the identities the key belongs to.
+	Principals []string
+	// Key is nil for keys of types that cannot be checked.
+	Key *PublicKey
+
+	// CertAuthority marks a key that signs certificates rather than
+	// messages. Certificates are not supported, so such lines never match.
+	CertAuthority bool
+	// Namespaces limits the namespaces the key may sign for, when set.
+	Namespaces []string
+	// ValidAfter and ValidBefore bound when signatures are accepted, when
+	// not zero.
+	ValidAfter  time.Time
+	ValidBefore time.Time
+}
+
+// ParseAllowedSigners decodes an allowed signers file in the format of
+// ssh-keygen(1). Blank lines and lines starting with # are skipped.
+func ParseAllowedSigners(data []byte) ([]*AllowedSigner, error) {
+	var signers []*AllowedSigner
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		signer, err := parseAllowedSigner(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		signers = append(signers, signer)
+	}
+	return signers, scanner.Err()
+}
+
+// parseAllowedSigner decodes "principals [options] keytype key [comment]".
+func parseAllowedSigner(line string) (*AllowedSigner, error) {
+	fields := splitQuoted(line)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("missing key")
+	}
+
+	signer := &AllowedSigner{Principals: strings.Split(unquote(fields[0]), ",")}
+	rest := fields[1:]
+	if !isKeyType(rest[0]) {
+		if err := signer.parseOptions(rest[0]); err != nil {
+			return nil, err
+		}
+		rest = rest[1:]
+	}
+	if len(rest) < 2 {
+		return nil, fmt.Errorf("missing key")
+	}
+
+	if !isKeyType(rest[0]) {
+		return nil, fmt.Errorf("invalid key type %q", rest[0])
+	}
+	// Keys of types gogit cannot check are kept without a Key, so that
+	// they never match
+	signer.Key, _ = ParseAuthorizedKey(rest[0] + " " + rest[1])
+	return signer, nil
+}
+
+// isKeyType reports whether field names an SSH key type rather than
+// options.
+func isKeyType(field string) bool {
+	for _, prefix := range []string{"ssh-", "ecdsa-", "sk-"} {
+		if strings.HasPrefix(field, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseOptions decodes the comma-separated options of a line.
+func (s *AllowedSigner) parseOptions(options string) error {
+	for _, option := range splitOptions(options) {
+		name, value, _ := strings.Cut(option, "=")
+		value = unquote(value)
+		var err error
+		switch strings.ToLower(name) {
+		case "cert-authority":
+			s.CertAuthority = true
+		case "namespaces":
+			s.Namespaces = strings.Split(value, ",")
+		case "valid-after":
+			s.ValidAfter, err = parseSignerTime(value)
+		case "valid-before":
+			s.ValidBefore, err = parseSignerTime(value)
+		default:
+			return fmt.Errorf("unknown option %q", name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseSignerTime decodes YYYYMMDD[HHMM[SS]], in local time unless it ends
+// with Z.
+func parseSignerTime(value string) (time.Time, error) {
+	loc := time.Local
+	if rest, ok := strings.CutSuffix(value, "Z"); ok {
+		value, loc = rest, time.UTC
+	}
+	for _, layout := range []string{"20060102", "200601021504", "20060102150405"} {
+		if len(value) == len(layout) {
+			return time.ParseInLocation(layout, value, loc)
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
+
+// FindPrincipals returns the principals of every line that allows key to
+// sign for namespace at the given time, in file order, as ssh-keygen -Y
+// find-principals does.
+func FindPrincipals(signers []*AllowedSigner, key *PublicKey, namespace string, at time.Time) []string {
+	var principals []string
+	for _, s := range signers {
+		if s.CertAuthority || s.Key == nil || !s.Key.Equal(key) {
+			continue
+		}
+		if s.Namespaces != nil && !matchAny(s.Namespaces, namespace) {
+			continue
+		}
+		if !s.ValidAfter.IsZero() && at.Before(s.ValidAfter) {
+			continue
+		}
+		if !s.ValidBefore.IsZero() && !at.Before(s.ValidBefore) {
+			continue
+		}
+		principals = append(principals, s.Principals...)
+	}
+	return principals
+}
+
+// matchAny reports whether name matches one of the patterns, which may use
+// * and ? wildcards.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// splitQuoted splits line at whitespace outside double quotes.
+func splitQuoted(line string) []string {
+	var fields []string
+	var field strings.Builder
+	quoted := false
+	for _, c := range line {
+		switch {
+		case c == '"':
+			quoted = !quoted
+			field.WriteRune(c)
+		case (c == ' ' || c == '\t') && !quoted:
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+		default:
+			field.WriteRune(c)
+		}
+	}
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+// splitOptions splits options at commas outside double quotes.
+func splitOptions(options string) []string {
+	var parts []string
+	start, quoted := 0, false
+	for i, c := range options {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			parts = append(parts, options[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, options[start:])
+}
+
+// unquote removes surrounding double quotes.
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,241 @@
+package sshsig
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Key types as SSH names them.
+const (
+	KeyTypeEd25519 = "ssh-ed25519"
+	KeyTypeRSA     = "ssh-rsa"
+)
+
+// privateKeyMagic starts the OpenSSH private key format.
+const privateKeyMagic = "openssh-key-v1\x00"
+
+// PublicKey is an SSH public key together with its wire encoding.
+type PublicKey struct {
+	// Type is the SSH name of the key type, such as ssh-ed25519.
+	Type string
+	// Blob is the key in the SSH wire format.
+	Blob []byte
+
+	key crypto.PublicKey
+}
+
+// ParsePublicKey decodes a public key in the SSH wire format.
+func ParsePublicKey(blob []byte) (*PublicKey, error) {
+	r := &reader{data: blob}
+	keyType := r.string()
+	pub := &PublicKey{Type: string(keyType), Blob: blob}
+
+	switch pub.Type {
+	case KeyTypeEd25519:
+		key := r.string()
+		if r.err == nil && len(key) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 public key")
+		}
+		pub.key = ed25519.PublicKey(key)
+	case KeyTypeRSA:
+		e := r.mpint()
+		n := r.mpint()
+		if r.err == nil && !e.IsInt64() {
+			return nil, errors.New("invalid RSA public key")
+		}
+		pub.key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", pub.Type)
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", r.err)
+	}
+	return pub, nil
+}
+
+// ParseAuthorizedKey decodes a public key written as in authorized_keys or
+// a .pub file: the key type, the base64 key and an optional comment.
+func ParseAuthorizedKey(line string) (*PublicKey, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid public key %q", line)
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key %q", line)
+	}
+	pub, err := ParsePublicKey(blob)
+	if err != nil {
+		return nil, err
+	}
+	if pub.Type != fields[0] {
+		return nil, fmt.Errorf("public key type %s does not match %s", pub.Type, fields[0])
+	}
+	return pub, nil
+}
+
+// Equal reports whether both are the same key.
+func (k *PublicKey) Equal(other *PublicKey) bool {
+	return bytes.Equal(k.Blob, other.Blob)
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the key as ssh-keygen
+// prints it.
+func (k *PublicKey) Fingerprint() string {
+	sum := sha256.Sum256(k.Blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// TypeName returns the short name ssh-keygen uses for the key type.
+func (k *PublicKey) TypeName() string {
+	switch k.Type {
+	case KeyTypeEd25519:
+		return "ED25519"
+	case KeyTypeRSA:
+		return "RSA"
+	default:
+		return strings.ToUpper(k.Type)
+	}
+}
+
+// String returns the key as a line of authorized_keys.
+func (k *PublicKey) String() string {
+	return k.Type + " " + base64.StdEncoding.EncodeToString(k.Blob)
+}
+
+// PrivateKey is a key that can make signatures.
+type PrivateKey struct {
+	Public *PublicKey
+
+	signer crypto.Signer
+}
+
+// ParsePrivateKey decodes an unencrypted private key in the OpenSSH format
+// or, for RSA, in PEM as PKCS #1 or PKCS #8.
+func ParsePrivateKey(data []byte) (*PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no key found")
+	}
+
+	switch block.Type {
+	case "OPENSSH PRIVATE KEY":
+		return parseOpenSSHPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return newPrivateKey(key)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key %T", key)
+		}
+		return newPrivateKey(signer)
+	default:
+		return nil, fmt.Errorf("unsupported private key %q", block.Type)
+	}
+}
+
+// parseOpenSSHPrivateKey decodes the openssh-key-v1 format.
+func parseOpenSSHPrivateKey(data []byte) (*PrivateKey, error) {
+	magic, data, ok := bytes.Cut(data, []byte{0})
+	if !ok || string(magic)+"\x00" != privateKeyMagic {
+		return nil, errors.New("invalid OpenSSH private key")
+	}
+
+	r := &reader{data: data}
+	cipher := string(r.string())
+	kdf := string(r.string())
+	r.string() // kdf options
+	count := r.uint32()
+	r.string() // public key
+	private := r.string()
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid OpenSSH private key: %w", r.err)
+	}
+	if cipher != "none" || kdf != "none" {
+		return nil, errors.New("encrypted private keys are not supported")
+	}
+	if count != 1 {
+		return nil, fmt.Errorf("expected one private key, found %d", count)
+	}
+
+	r = &reader{data: private}
+	if r.uint32() != r.uint32() {
+		return nil, errors.New("invalid OpenSSH private key: check bytes differ")
+	}
+
+	var signer crypto.Signer
+	switch keyType := string(r.string()); keyType {
+	case KeyTypeEd25519:
+		r.string() // public key
+		key := r.string()
+		if r.err == nil && len(key) != ed25519.PrivateKeySize {
+			return nil, errors.New("invalid ed25519 private key")
+		}
+		signer = ed25519.PrivateKey(key)
+	case KeyTypeRSA:
+		n := r.mpint()
+		e := r.mpint()
+		d := r.mpint()
+		r.mpint() // iqmp, recomputed by Precompute
+		p := r.mpint()
+		q := r.mpint()
+		if r.err != nil {
+			break
+		}
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: n, E: int(e.Int64())},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		if err := key.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid RSA private key: %w", err)
+		}
+		key.Precompute()
+		signer = key
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", keyType)
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid OpenSSH private key: %w", r.err)
+	}
+	return newPrivateKey(signer)
+}
+
+// newPrivateKey wraps signer with its SSH public key.
+func newPrivateKey(signer crypto.Signer) (*PrivateKey, error) {
+	var w writer
+	switch pub := signer.Public().(type) {
+	case ed25519.PublicKey:
+		w.string([]byte(KeyTypeEd25519))
+		w.string(pub)
+	case *rsa.PublicKey:
+		w.string([]byte(KeyTypeRSA))
+		w.mpint(big.NewInt(int64(pub.E)))
+		w.mpint(pub.N)
+	default:
+		return nil, fmt.Errorf("unsupported private key %T", pub)
+	}
+	public, err := ParsePublicKey(w.bytes())
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{Public: public, signer: signer}, nil
+}
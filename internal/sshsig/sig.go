@@ -0,0 +1,214 @@
+package sshsig
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// The armor around a signature and the magic preamble inside it.
+const (
+	BeginMarker = "-----BEGIN SSH SIGNATURE-----"
+	EndMarker   = "-----END SSH SIGNATURE-----"
+
+	magic   = "SSHSIG"
+	version = 1
+
+	// armorLineLength is how ssh-keygen wraps the base64 signature.
+	armorLineLength = 70
+)
+
+// Hash algorithms a signature may use for the message.
+const (
+	HashSHA256 = "sha256"
+	HashSHA512 = "sha512"
+)
+
+// Signature formats for the keys gogit can sign with.
+const (
+	sigFormatEd25519   = "ssh-ed25519"
+	sigFormatRSASHA256 = "rsa-sha2-256"
+	sigFormatRSASHA512 = "rsa-sha2-512"
+)
+
+// ErrBadSignature reports a signature that does not match its message.
+var ErrBadSignature = errors.New("incorrect signature")
+
+// Signature is a decoded SSH signature, as made by ssh-keygen -Y sign.
+type Signature struct {
+	PublicKey     *PublicKey
+	Namespace     string
+	HashAlgorithm string
+
+	format string
+	blob   []byte
+}
+
+// Sign signs message for namespace, such as "git", and returns the armored
+// signature.
+func (k *PrivateKey) Sign(message []byte, namespace string) (string, error) {
+	signed, err := signedData(message, namespace, HashSHA512)
+	if err != nil {
+		return "", err
+	}
+
+	var format string
+	var blob []byte
+	switch key := k.signer.(type) {
+	case ed25519.PrivateKey:
+		format, blob = sigFormatEd25519, ed25519.Sign(key, signed)
+	case *rsa.PrivateKey:
+		digest := sha512.Sum512(signed)
+		if blob, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA512, digest[:]); err != nil {
+			return "", err
+		}
+		format = sigFormatRSASHA512
+	default:
+		return "", fmt.Errorf("unsupported private key %T", key)
+	}
+
+	var sig writer
+	sig.string([]byte(format))
+	sig.string(blob)
+
+	var w writer
+	w.raw([]byte(magic))
+	w.uint32(version)
+	w.string(k.Public.Blob)
+	w.string([]byte(namespace))
+	w.string(nil)
+	w.string([]byte(HashSHA512))
+	w.string(sig.bytes())
+
+	return armor(w.bytes()), nil
+}
+
+// signedData returns what is actually signed for message: its hash wrapped
+// with the namespace and algorithm.
+func signedData(message []byte, namespace string, hashAlgorithm string) ([]byte, error) {
+	var h hash.Hash
+	switch hashAlgorithm {
+	case HashSHA256:
+		h = sha256.New()
+	case HashSHA512:
+		h = sha512.New()
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm %q", hashAlgorithm)
+	}
+	h.Write(message)
+
+	var w writer
+	w.raw([]byte(magic))
+	w.string([]byte(namespace))
+	w.string(nil)
+	w.string([]byte(hashAlgorithm))
+	w.string(h.Sum(nil))
+	return w.bytes(), nil
+}
+
+// armor encodes a signature blob between the begin and end markers.
+func armor(blob []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(blob)
+
+	var b strings.Builder
+	b.WriteString(BeginMarker + "\n")
+	for len(encoded) > armorLineLength {
+		b.WriteString(encoded[:armorLineLength] + "\n")
+		encoded = encoded[armorLineLength:]
+	}
+	b.WriteString(encoded + "\n")
+	b.WriteString(EndMarker + "\n")
+	return b.String()
+}
+
+// ParseSignature decodes an armored signature.
+func ParseSignature(armored string) (*Signature, error) {
+	body, ok := strings.CutPrefix(strings.TrimSpace(armored), BeginMarker)
+	if !ok {
+		return nil, errors.New("not an SSH signature")
+	}
+	body, ok = strings.CutSuffix(body, EndMarker)
+	if !ok {
+		return nil, errors.New("unterminated SSH signature")
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSH signature: %w", err)
+	}
+
+	if len(data) < len(magic) || string(data[:len(magic)]) != magic {
+		return nil, errors.New("invalid SSH signature: missing magic")
+	}
+	r := &reader{data: data[len(magic):]}
+	if v := r.uint32(); r.err == nil && v != version {
+		return nil, fmt.Errorf("unsupported SSH signature version %d", v)
+	}
+	keyBlob := r.string()
+	namespace := r.string()
+	r.string() // reserved
+	hashAlgorithm := r.string()
+	sig := &reader{data: r.string()}
+	format := sig.string()
+	blob := sig.string()
+	if r.err != nil || sig.err != nil {
+		return nil, errors.New("invalid SSH signature: truncated")
+	}
+
+	key, err := ParsePublicKey(keyBlob)
+	if err != nil {
+		return nil, err
+	}
+	return &Signature{
+		PublicKey:     key,
+		Namespace:     string(namespace),
+		HashAlgorithm: string(hashAlgorithm),
+		format:        string(format),
+		blob:          blob,
+	}, nil
+}
+
+// Verify checks that the signature was made over message for namespace by
+// its public key. It says nothing about whether the key is trusted.
+func (s *Signature) Verify(message []byte, namespace string) error {
+	if s.Namespace != namespace {
+		return fmt.Errorf("signature namespace %q does not match %q", s.Namespace, namespace)
+	}
+	signed, err := signedData(message, namespace, s.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+
+	switch key := s.PublicKey.key.(type) {
+	case ed25519.PublicKey:
+		if s.format != sigFormatEd25519 || !ed25519.Verify(key, signed, s.blob) {
+			return ErrBadSignature
+		}
+	case *rsa.PublicKey:
+		var hashed []byte
+		var algo crypto.Hash
+		switch s.format {
+		case sigFormatRSASHA256:
+			sum := sha256.Sum256(signed)
+			hashed, algo = sum[:], crypto.SHA256
+		case sigFormatRSASHA512:
+			sum := sha512.Sum512(signed)
+			hashed, algo = sum[:], crypto.SHA512
+		default:
+			return fmt.Errorf("unsupported signature format %q", s.format)
+		}
+		if rsa.VerifyPKCS1v15(key, algo, hashed, s.blob) != nil {
+			return ErrBadSignature
+		}
+	default:
+		return fmt.Errorf("unsupported key type %q", s.PublicKey.Type)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package sshsig
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// errTruncated reports data that ends inside a field.
+var errTruncated = errors.New("truncated data")
+
+// reader decodes the SSH wire format. After the first error every read
+// returns a zero value, so callers check err once at the end.
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (r *reader) uint32() uint32 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.data) < 4 {
+		r.err = errTruncated
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return v
+}
+
+// string reads a length-prefixed byte string.
+func (r *reader) string() []byte {
+	n := r.uint32()
+	if r.err != nil {
+		return nil
+	}
+	if uint32(len(r.data)) < n {
+		r.err = errTruncated
+		return nil
+	}
+	s := r.data[:n]
+	r.data = r.data[n:]
+	return s
+}
+
+// mpint reads a non-negative multiple precision integer.
+func (r *reader) mpint() *big.Int {
+	b := r.string()
+	if r.err == nil && len(b) > 0 && b[0]&0x80 != 0 {
+		r.err = errors.New("negative integer")
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+// writer encodes the SSH wire format.
+type writer struct {
+	buf []byte
+}
+
+func (w *writer) raw(b []byte) {
+	w.buf = append(w.buf, b...)
+}
+
+func (w *writer) uint32(v uint32) {
+	w.buf = binary.BigEndian.AppendUint32(w.buf, v)
+}
+
+func (w *writer) string(b []byte) {
+	w.uint32(uint32(len(b)))
+	w.raw(b)
+}
+
+// mpint writes a non-negative integer, with a leading zero byte when its
+// top bit would otherwise mark it negative.
+func (w *writer) mpint(n *big.Int) {
+	b := n.Bytes()
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	w.string(b)
+}
+
+func (w *writer) bytes() []byte {
+	return w.buf
+}
@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/file"
+)
+
+const (
+	// AlternatesFile lists, relative to the objects directory, other object
+	// directories whose objects the database may read.
+	AlternatesFile = "info/alternates"
+	// AlternatesEnv names a list of extra object directories separated by
+	// the platform's path list separator.
+	AlternatesEnv = "GIT_ALTERNATE_OBJECT_DIRECTORIES"
+
+	// maxAlternateDepth bounds how many alternates files are followed from
+	// one database to the next, as git does.
+	maxAlternateDepth = 5
+)
+
+// alternateDatabases returns the databases this one borrows objects from,
+// reading the alternates on first use. Alternates of alternates are
+// followed, each directory is visited once however often it is listed, and
+// directories that do not exist are skipped. The returned databases have no
+// alternates of their own, so lookups in them never recurse.
+func (db *Database) alternateDatabases() ([]*Database, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.alternates != nil {
+		return db.alternates, nil
+	}
+
+	seen := map[string]bool{canonicalDir(db.pathname): true}
+	alternates := []*Database{}
+
+	var visit func(objectsDir string, depth int) error
+	add := func(dir string, depth int) error {
+		key := canonicalDir(dir)
+		if seen[key] {
+			return nil
+		}
+		seen[key] = true
+
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil
+		}
+		alternates = append(alternates, db.newAlternate(dir))
+		return visit(dir, depth+1)
+	}
+	visit = func(objectsDir string, depth int) error {
+		if depth > maxAlternateDepth {
+			return nil
+		}
+		dirs, err := readAlternates(objectsDir)
+		if err != nil {
+			return err
+		}
+		for _, dir := range dirs {
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(objectsDir, dir)
+			}
+			if err := add(dir, depth); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := visit(db.pathname, 0); err != nil {
+		return nil, err
+	}
+	for _, dir := range filepath.SplitList(os.Getenv(AlternatesEnv)) {
+		if dir == "" {
+			continue
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(abs, 1); err != nil {
+			return nil, err
+		}
+	}
+
+	db.alternates = alternates
+	return db.alternates, nil
+}
+
+// newAlternate opens the object directory dir as an alternate of db.
+func (db *Database) newAlternate(dir string) *Database {
+	return &Database{
+		pathname:   dir,
+		algo:       db.algo,
+		alternates: []*Database{},
+	}
+}
+
+// AddAlternate lets the database read objects from objectsDir for as long
+// as it is open, without recording the directory in the alternates file.
+func (db *Database) AddAlternate(objectsDir string) error {
+	alternates, err := db.alternateDatabases()
+	if err != nil {
+		return err
+	}
+
+	key := canonicalDir(objectsDir)
+	if key == canonicalDir(db.pathname) {
+		return nil
+	}
+	for _, alt := range alternates {
+		if canonicalDir(alt.pathname) == key {
+			return nil
+		}
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.alternates = append(db.alternates, db.newAlternate(objectsDir))
+	return nil
+}
+
+// WriteAlternates records dirs as the alternates of the database, replacing
+// any existing list. An empty list removes the alternates file.
+func (db *Database) WriteAlternates(dirs []string) error {
+	path := filepath.Join(db.pathname, filepath.FromSlash(AlternatesFile))
+	if len(dirs) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(path), file.ModeDir); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		lock := file.NewLockfile(path)
+		held, err := lock.HoldForUpdate()
+		if err != nil {
+			return err
+		}
+		if !held {
+			return fmt.Errorf("unable to lock %s", path)
+		}
+		if err := lock.Write(strings.Join(dirs, "\n") + "\n"); err != nil {
+			lock.Rollback()
+			return err
+		}
+		if err := lock.Commit(); err != nil {
+			return err
+		}
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.alternates = nil
+	return nil
+}
+
+// readAlternates returns the directories listed in the alternates file of
+// objectsDir. Blank lines and comments are skipped, and paths may be quoted
+// as C-style strings.
+func readAlternates(objectsDir string) ([]string, error) {
+	f, err := os.Open(filepath.Join(objectsDir, filepath.FromSlash(AlternatesFile)))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var dirs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, `"`) {
+			unquoted, err := strconv.Unquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("%s: bad quoted path %s", filepath.Join(objectsDir, AlternatesFile), line)
+			}
+			line = unquoted
+		}
+		dirs = append(dirs, line)
+	}
+	return dirs, scanner.Err()
+}
+
+// canonicalDir returns a name identifying dir however it was spelled, so
+// that cycles through symlinks or relative paths are noticed.
+func canonicalDir(dir string) string {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return filepath.Clean(dir)
+}
@@ -2,97 +2,264 @@ package storage
 
 import (
 	"compress/zlib"
-	"crypto/sha1"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
+	"github.com/shanmugharajk/gogit/internal/commit"
 	"github.com/shanmugharajk/gogit/internal/file"
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
 	"github.com/shanmugharajk/gogit/internal/object"
 )
 
-// Database manages the storage of git objects on disk.
+// Database is the ObjectStore keeping git objects on disk. It is safe for
+// concurrent use.
 type Database struct {
 	pathname string
+	algo     *hashalgo.Algorithm
+	fsync    FsyncPolicy
+
+	// mu guards the lazily loaded pack indexes and alternates, and the
+	// pending batch.
+	mu         sync.Mutex
+	packs      []*packIndex
+	alternates []*Database
+	pending    map[object.ObjectID]string
 }
 
-// New creates a new Database at the specified pathname.
-func New(pathname string) *Database {
+// New creates a new Database at the specified pathname whose objects are
+// named by algo.
+func New(pathname string, algo *hashalgo.Algorithm) *Database {
 	return &Database{
 		pathname: pathname,
+		algo:     algo,
 	}
 }
 
+// Algorithm returns the hash algorithm naming the database's objects.
+func (db *Database) Algorithm() *hashalgo.Algorithm {
+	return db.algo
+}
+
 // Store persists a git object to the database and sets its OID.
-// The OID is computed as SHA1("type size\0data").
+// The OID is the hash of "type size\0data" under the database's algorithm. Objects that are already
+// stored, loose or packed, are not written again.
 func (db *Database) Store(obj object.Object) error {
-	data := obj.Bytes()
+	content := db.serializeObject(obj)
+	if db.freshen(obj.GetOID()) {
+		return nil
+	}
 
-	// Create the content with null terminator: "type size\0data"
-	header := fmt.Sprintf("%s %d\x00", obj.Type(), len(data))
-	content := append([]byte(header), data...)
+	// Write object to disk
+	return db.writeObject(obj.GetOID(), content)
+}
 
-	// Compute SHA1 hash and set OID
-	hash := sha1.Sum(content)
-	oid := fmt.Sprintf("%x", hash)
-	obj.SetOID(oid)
+// HashObject computes and sets the OID of obj without writing it to disk.
+func (db *Database) HashObject(obj object.Object) object.ObjectID {
+	db.serializeObject(obj)
+	return obj.GetOID()
+}
 
-	// Write object to disk
-	return db.writeObject(oid, content)
+// serializeObject builds "type size\0data" for obj and sets its OID to the
+// hash of that content.
+func (db *Database) serializeObject(obj object.Object) []byte {
+	return encodeObject(db.algo, obj)
 }
 
 // writeObject writes a git object to disk with atomic writes.
 // The object is stored at pathname/XX/YYYYYYY where XX are the first 2 hex chars of the OID
 // and YYYYYYY are the remaining hex chars.
-func (db *Database) writeObject(oid string, content []byte) error {
-	// Compute object path: first 2 chars as directory, rest as filename
-	objDir := filepath.Join(db.pathname, oid[:2])
-	objPath := filepath.Join(objDir, oid[2:])
-
-	// Ensure directory exists, create if necessary
-	if err := os.MkdirAll(objDir, file.ModeDir); err != nil {
+func (db *Database) writeObject(oid object.ObjectID, content []byte) error {
+	// Ensure the objects directory exists, create if necessary
+	if err := os.MkdirAll(db.pathname, file.ModeDir); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	// Create a temporary file in the object directory
-	tempFile, err := os.CreateTemp(objDir, "tmp_obj_")
+	// Create a temporary file in the objects directory
+	tempFile, err := os.CreateTemp(db.pathname, "tmp_obj_")
 	if err != nil {
 		return fmt.Errorf("failed to create temporary file: %w", err)
 	}
-	defer func() {
-		tempFile.Close()
-		os.Remove(tempFile.Name())
-	}()
 
 	// Compress content and write to temporary file
 	if err := db.compressAndWrite(tempFile, content); err != nil {
+		discardTemp(tempFile)
+		return err
+	}
+
+	// Sync according to policy and move the file into place
+	return db.finishObject(oid, tempFile)
+}
+
+// compressAndWrite compresses the content using zlib deflate and writes to the writer.
+func (db *Database) compressAndWrite(w io.Writer, content []byte) error {
+	encoder := zlib.NewWriter(w)
+	if _, err := encoder.Write(content); err != nil {
 		return err
 	}
+	return encoder.Close()
+}
+
+// ReadObject inflates the object with the given OID and returns its type and
+// contents without the "type size\0" header. Use OpenObject to avoid holding
+// large contents in memory.
+func (db *Database) ReadObject(oid object.ObjectID) (string, []byte, error) {
+	return readObject(db, oid)
+}
+
+// Load reads and parses the object with the given OID.
+func (db *Database) Load(oid object.ObjectID) (object.Object, error) {
+	return loadObject(db, oid)
+}
+
+// LoadCommit loads oid and checks that it is a commit.
+func (db *Database) LoadCommit(oid object.ObjectID) (*commit.Commit, error) {
+	return loadCommit(db, oid)
+}
+
+// LoadTree loads oid and checks that it is a tree.
+func (db *Database) LoadTree(oid object.ObjectID) (*object.Tree, error) {
+	return loadTree(db, oid)
+}
+
+// ListTree flattens the tree oid into a map from slash-separated path to
+// blob entry, descending into subtrees. prefix is prepended to every path.
+func (db *Database) ListTree(oid object.ObjectID, prefix string) (map[string]*object.StoredEntry, error) {
+	result := make(map[string]*object.StoredEntry)
+	if err := listTree(db, oid, prefix, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// PrefixMatch returns the OIDs of all stored objects, loose, packed or in
+// an alternate, whose hex form starts with prefix, which must be at least
+// two hex digits long. The OIDs are sorted.
+func (db *Database) PrefixMatch(prefix string) ([]object.ObjectID, error) {
+	if len(prefix) < 2 {
+		return nil, nil
+	}
+	prefix = strings.ToLower(prefix)
+
+	seen := make(map[object.ObjectID]bool)
+	var matches []object.ObjectID
+	add := func(oid object.ObjectID) {
+		if !seen[oid] {
+			seen[oid] = true
+			matches = append(matches, oid)
+		}
+	}
+
+	dirEntries, err := os.ReadDir(filepath.Join(db.pathname, prefix[:2]))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	for _, entry := range dirEntries {
+		name := prefix[:2] + entry.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		// Temporary and unrelated files are not valid object IDs.
+		if oid, err := object.ParseOID(name, db.algo); err == nil {
+			add(oid)
+		}
+	}
 
-	// Sync to ensure data is written to disk before renaming
-	if err := tempFile.Sync(); err != nil {
-		return fmt.Errorf("failed to sync temporary file: %w", err)
+	packs, err := db.packIndexes()
+	if err != nil {
+		return nil, err
+	}
+	for _, idx := range packs {
+		for _, oid := range idx.prefixMatch(prefix) {
+			add(oid)
+		}
 	}
 
-	// Close the file before renaming
-	if err := tempFile.Close(); err != nil {
-		return fmt.Errorf("failed to close temporary file: %w", err)
+	alternates, err := db.alternateDatabases()
+	if err != nil {
+		return nil, err
+	}
+	for _, alt := range alternates {
+		oids, err := alt.PrefixMatch(prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, oid := range oids {
+			add(oid)
+		}
 	}
 
-	// Atomic rename: move temp file to final location
-	if err := os.Rename(tempFile.Name(), objPath); err != nil {
-		return fmt.Errorf("failed to rename temporary file: %w", err)
+	db.mu.Lock()
+	for oid := range db.pending {
+		if strings.HasPrefix(oid.String(), prefix) {
+			add(oid)
+		}
 	}
+	db.mu.Unlock()
 
-	return nil
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Compare(matches[j]) < 0
+	})
+	return matches, nil
 }
 
-// compressAndWrite compresses the content using zlib deflate and writes to the writer.
-func (db *Database) compressAndWrite(w io.Writer, content []byte) error {
-	encoder := zlib.NewWriter(w)
-	defer encoder.Close()
+// LooseObjects returns the OIDs of every loose object in the database,
+// sorted. Files that are not named like objects are skipped.
+func (db *Database) LooseObjects() ([]object.ObjectID, error) {
+	dirs, err := os.ReadDir(db.pathname)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var oids []object.ObjectID
+	for _, dir := range dirs {
+		if !dir.IsDir() || len(dir.Name()) != 2 {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(db.pathname, dir.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if oid, err := object.ParseOID(dir.Name()+entry.Name(), db.algo); err == nil {
+				oids = append(oids, oid)
+			}
+		}
+	}
+
+	sort.Slice(oids, func(i, j int) bool {
+		return oids[i].Compare(oids[j]) < 0
+	})
+	return oids, nil
+}
+
+// loosePath returns where the loose object oid can be read from, which is
+// its temporary file while a batch is pending.
+func (db *Database) loosePath(oid object.ObjectID) string {
+	if tempPath, ok := db.pendingPath(oid); ok {
+		return tempPath
+	}
+	return db.objectPath(oid)
+}
+
+func (db *Database) objectPath(oid object.ObjectID) string {
+	name := oid.String()
+	return filepath.Join(db.pathname, name[:2], name[2:])
+}
+
+// ObjectNotFoundError indicates that no object with the given OID is stored.
+type ObjectNotFoundError struct {
+	OID object.ObjectID
+}
 
-	_, err := encoder.Write(content)
-	return err
+func (e *ObjectNotFoundError) Error() string {
+	return fmt.Sprintf("object not found: %s", e.OID)
 }
@@ -0,0 +1,307 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shanmugharajk/gogit/internal/config"
+	"github.com/shanmugharajk/gogit/internal/file"
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+// Config keys choosing the FsyncPolicy, as git names them.
+const (
+	// FsyncConfigKey lists the components git syncs, e.g. "loose-object" or
+	// "-reference"; see git-config(1).
+	FsyncConfigKey = "core.fsync"
+	// FsyncMethodConfigKey is "fsync", "writeout-only" or "batch".
+	FsyncMethodConfigKey = "core.fsyncMethod"
+	// FsyncObjectFilesConfigKey is the boolean core.fsync superseded.
+	FsyncObjectFilesConfigKey = "core.fsyncObjectFiles"
+)
+
+// FsyncPolicy controls when new object files are flushed to stable storage.
+type FsyncPolicy int
+
+const (
+	// FsyncAlways syncs every object file before moving it into place.
+	FsyncAlways FsyncPolicy = iota
+	// FsyncBatch writes object files without syncing and moves them into
+	// place on Flush, once each of them and the directories they are moved
+	// to have been synced.
+	FsyncBatch
+	// FsyncNever leaves flushing to the operating system.
+	FsyncNever
+)
+
+// fsyncComponents maps the core.fsync components covering loose objects to
+// whether they do. The others are accepted but concern files this package
+// does not write.
+var fsyncComponents = map[string]bool{
+	"loose-object":     true,
+	"objects":          true,
+	"committed":        true,
+	"added":            true,
+	"all":              true,
+	"pack":             false,
+	"pack-metadata":    false,
+	"commit-graph":     false,
+	"index":            false,
+	"reference":        false,
+	"derived-metadata": false,
+}
+
+// FsyncPolicyFromConfig returns the policy for loose objects chosen by cfg
+// the way git chooses it. Like git, loose objects are not synced unless
+// core.fsync names them or the older core.fsyncObjectFiles is set, and
+// core.fsyncMethod=batch syncs them together at the end of a command.
+func FsyncPolicyFromConfig(cfg *config.Config) (FsyncPolicy, error) {
+	looseObjects := false
+	if value, ok := cfg.Get(FsyncConfigKey); ok {
+		for _, component := range strings.Split(value, ",") {
+			component = strings.ToLower(strings.TrimSpace(component))
+			negated := strings.HasPrefix(component, "-")
+			component = strings.TrimPrefix(component, "-")
+
+			if component == "none" {
+				looseObjects = false
+				continue
+			}
+			covers, known := fsyncComponents[component]
+			if !known {
+				return FsyncNever, fmt.Errorf("invalid value for %s: %s", FsyncConfigKey, value)
+			}
+			if covers {
+				looseObjects = !negated
+			}
+		}
+	}
+
+	batch := false
+	if value, ok := cfg.Get(FsyncMethodConfigKey); ok {
+		switch strings.ToLower(value) {
+		case "fsync", "writeout-only":
+		case "batch":
+			batch = true
+		default:
+			return FsyncNever, fmt.Errorf("invalid value for %s: %s", FsyncMethodConfigKey, value)
+		}
+	}
+
+	objectFiles, err := cfg.Bool(FsyncObjectFilesConfigKey, false)
+	if err != nil {
+		return FsyncNever, fmt.Errorf("invalid value for %s: %w", FsyncObjectFilesConfigKey, err)
+	}
+
+	switch {
+	case looseObjects && batch:
+		return FsyncBatch, nil
+	case looseObjects || objectFiles:
+		return FsyncAlways, nil
+	default:
+		return FsyncNever, nil
+	}
+}
+
+// SetFsyncPolicy changes when object files are synced. Callers choosing
+// FsyncBatch must call Flush before anything refers to the new objects.
+func (db *Database) SetFsyncPolicy(policy FsyncPolicy) {
+	db.fsync = policy
+}
+
+// Flush makes objects written under FsyncBatch durable and visible. Each
+// pending file is synced before it is moved into place, and the directories
+// they were moved to are synced afterwards so that the renames survive a
+// crash too. Flush does nothing under other policies.
+func (db *Database) Flush() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if len(db.pending) == 0 {
+		return nil
+	}
+
+	// Rename in a stable order so that a failure leaves a predictable state.
+	oids := make([]object.ObjectID, 0, len(db.pending))
+	for oid := range db.pending {
+		oids = append(oids, oid)
+	}
+	sort.Slice(oids, func(i, j int) bool {
+		return oids[i].Compare(oids[j]) < 0
+	})
+
+	dirs := make(map[string]bool)
+	for _, oid := range oids {
+		tempPath := db.pending[oid]
+		if err := syncPath(tempPath); err != nil {
+			return fmt.Errorf("failed to sync temporary file: %w", err)
+		}
+		if err := db.moveIntoPlace(tempPath, oid); err != nil {
+			return err
+		}
+		delete(db.pending, oid)
+		dirs[filepath.Dir(db.objectPath(oid))] = true
+	}
+
+	for dir := range dirs {
+		if err := syncPath(dir); err != nil {
+			return fmt.Errorf("failed to sync object directory: %w", err)
+		}
+	}
+	return nil
+}
+
+// syncPath flushes the file or directory at path to stable storage.
+func syncPath(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	err = f.Sync()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// finishObject syncs, closes and publishes a fully written temporary object
+// file according to the fsync policy. The temporary file is removed on error.
+func (db *Database) finishObject(oid object.ObjectID, tempFile *os.File) error {
+	if db.fsync == FsyncAlways {
+		if err := tempFile.Sync(); err != nil {
+			discardTemp(tempFile)
+			return fmt.Errorf("failed to sync temporary file: %w", err)
+		}
+	}
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempFile.Name())
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	if db.fsync == FsyncBatch {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+
+		if db.pending == nil {
+			db.pending = make(map[object.ObjectID]string)
+		}
+		if _, ok := db.pending[oid]; ok {
+			// Another writer produced the same object concurrently.
+			return os.Remove(tempFile.Name())
+		}
+		db.pending[oid] = tempFile.Name()
+		return nil
+	}
+
+	if err := db.moveIntoPlace(tempFile.Name(), oid); err != nil {
+		os.Remove(tempFile.Name())
+		return err
+	}
+	return nil
+}
+
+// moveIntoPlace renames a finished temporary file to the object's path.
+func (db *Database) moveIntoPlace(tempPath string, oid object.ObjectID) error {
+	objPath := db.objectPath(oid)
+	if err := os.MkdirAll(filepath.Dir(objPath), file.ModeDir); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.Rename(tempPath, objPath); err != nil {
+		return fmt.Errorf("failed to rename temporary file: %w", err)
+	}
+	return nil
+}
+
+// pendingPath returns the temporary file of oid while it awaits Flush.
+func (db *Database) pendingPath(oid object.ObjectID) (string, bool) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	tempPath, ok := db.pending[oid]
+	return tempPath, ok
+}
+
+// discardTemp closes and removes an abandoned temporary file.
+func discardTemp(tempFile *os.File) {
+	tempFile.Close()
+	os.Remove(tempFile.Name())
+}
+
+// Exists reports whether the object oid is stored, loose, packed or in an
+// alternate.
+func (db *Database) Exists(oid object.ObjectID) (bool, error) {
+	if found, err := db.ExistsLocally(oid); found || err != nil {
+		return found, err
+	}
+
+	alternates, err := db.alternateDatabases()
+	if err != nil {
+		return false, err
+	}
+	for _, alt := range alternates {
+		if found, err := alt.ExistsLocally(oid); found || err != nil {
+			return found, err
+		}
+	}
+	return false, nil
+}
+
+// ExistsLocally reports whether the object oid is stored in this database
+// itself rather than borrowed from an alternate.
+func (db *Database) ExistsLocally(oid object.ObjectID) (bool, error) {
+	if _, ok := db.pendingPath(oid); ok {
+		return true, nil
+	}
+	if _, err := os.Stat(db.objectPath(oid)); err == nil {
+		return true, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+
+	idx, err := db.findPacked(oid)
+	return idx != nil, err
+}
+
+// freshen reports whether oid is already stored, updating the modification
+// time of its file so that a concurrent prune treats it as recently written.
+// An object whose file cannot be touched, as is common for alternates owned
+// by another user, is reported missing, so that it is written again.
+func (db *Database) freshen(oid object.ObjectID) bool {
+	if db.freshenLocal(oid) {
+		return true
+	}
+
+	alternates, err := db.alternateDatabases()
+	if err != nil {
+		return false
+	}
+	for _, alt := range alternates {
+		if alt.freshenLocal(oid) {
+			return true
+		}
+	}
+	return false
+}
+
+// freshenLocal freshens oid when this database itself stores it.
+func (db *Database) freshenLocal(oid object.ObjectID) bool {
+	if _, ok := db.pendingPath(oid); ok {
+		return true
+	}
+
+	now := time.Now()
+	if err := os.Chtimes(db.objectPath(oid), now, now); err == nil {
+		return true
+	}
+
+	idx, err := db.findPacked(oid)
+	if err != nil || idx == nil {
+		return false
+	}
+	return os.Chtimes(idx.packPath, now, now) == nil
+}
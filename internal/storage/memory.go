@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shanmugharajk/gogit/internal/commit"
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+// Memory is an ObjectStore that keeps objects in memory, for building
+// commits and trees without touching disk. It is safe for concurrent use.
+type Memory struct {
+	algo *hashalgo.Algorithm
+
+	mu      sync.RWMutex
+	objects map[object.ObjectID]*memoryObject
+}
+
+// memoryObject is the type and contents of an object held by Memory.
+type memoryObject struct {
+	objType string
+	data    []byte
+}
+
+// NewMemory creates an empty in-memory store whose objects are named by
+// algo.
+func NewMemory(algo *hashalgo.Algorithm) *Memory {
+	return &Memory{
+		algo:    algo,
+		objects: make(map[object.ObjectID]*memoryObject),
+	}
+}
+
+// Algorithm returns the hash algorithm naming the store's objects.
+func (m *Memory) Algorithm() *hashalgo.Algorithm {
+	return m.algo
+}
+
+// Store keeps a copy of obj and sets its OID.
+func (m *Memory) Store(obj object.Object) error {
+	content := encodeObject(m.algo, obj)
+	_, data, _ := bytes.Cut(content, []byte{0})
+	m.put(obj.GetOID(), obj.Type(), data)
+	return nil
+}
+
+// HashObject computes and sets the OID of obj without storing it.
+func (m *Memory) HashObject(obj object.Object) object.ObjectID {
+	encodeObject(m.algo, obj)
+	return obj.GetOID()
+}
+
+// StoreStream stores an object of objType whose size bytes of contents are
+// read from r, and returns its OID.
+func (m *Memory) StoreStream(objType string, size int64, r io.Reader) (object.ObjectID, error) {
+	var contents bytes.Buffer
+	oid, err := copyObject(m.algo, io.Discard, objType, size, io.TeeReader(r, &contents))
+	if err != nil {
+		return object.ObjectID{}, err
+	}
+	m.put(oid, objType, contents.Bytes())
+	return oid, nil
+}
+
+// HashStream computes the OID of an object of objType whose size bytes of
+// contents are read from r, without storing it.
+func (m *Memory) HashStream(objType string, size int64, r io.Reader) (object.ObjectID, error) {
+	return copyObject(m.algo, io.Discard, objType, size, r)
+}
+
+// put records an object unless one with the same OID is already held.
+func (m *Memory) put(oid object.ObjectID, objType string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.objects[oid]; !ok {
+		m.objects[oid] = &memoryObject{objType: objType, data: data}
+	}
+}
+
+// OpenObject opens the object with the given OID for reading.
+func (m *Memory) OpenObject(oid object.ObjectID) (*ObjectReader, error) {
+	m.mu.RLock()
+	obj, ok := m.objects[oid]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, &ObjectNotFoundError{OID: oid}
+	}
+
+	return &ObjectReader{
+		Type:     obj.objType,
+		Size:     int64(len(obj.data)),
+		contents: bytes.NewReader(obj.data),
+	}, nil
+}
+
+// ReadObject returns the type and contents of the object with the given OID.
+func (m *Memory) ReadObject(oid object.ObjectID) (string, []byte, error) {
+	return readObject(m, oid)
+}
+
+// Load reads and parses the object with the given OID.
+func (m *Memory) Load(oid object.ObjectID) (object.Object, error) {
+	return loadObject(m, oid)
+}
+
+// LoadCommit loads oid and checks that it is a commit.
+func (m *Memory) LoadCommit(oid object.ObjectID) (*commit.Commit, error) {
+	return loadCommit(m, oid)
+}
+
+// LoadTree loads oid and checks that it is a tree.
+func (m *Memory) LoadTree(oid object.ObjectID) (*object.Tree, error) {
+	return loadTree(m, oid)
+}
+
+// ListTree flattens the tree oid into a map from slash-separated path to
+// blob entry, descending into subtrees. prefix is prepended to every path.
+func (m *Memory) ListTree(oid object.ObjectID, prefix string) (map[string]*object.StoredEntry, error) {
+	result := make(map[string]*object.StoredEntry)
+	if err := listTree(m, oid, prefix, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Exists reports whether the object oid is stored.
+func (m *Memory) Exists(oid object.ObjectID) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.objects[oid]
+	return ok, nil
+}
+
+// PrefixMatch returns the OIDs of all stored objects whose hex form starts
+// with prefix, which must be at least two hex digits long. The OIDs are
+// sorted.
+func (m *Memory) PrefixMatch(prefix string) ([]object.ObjectID, error) {
+	if len(prefix) < 2 {
+		return nil, nil
+	}
+	prefix = strings.ToLower(prefix)
+
+	m.mu.RLock()
+	var matches []object.ObjectID
+	for oid := range m.objects {
+		if strings.HasPrefix(oid.String(), prefix) {
+			matches = append(matches, oid)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Compare(matches[j]) < 0
+	})
+	return matches, nil
+}
+
+// Flush does nothing, as stored objects are visible at once.
+func (m *Memory) Flush() error {
+	return nil
+}
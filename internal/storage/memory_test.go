@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/object"
+	"github.com/shanmugharajk/gogit/internal/tag"
+)
+
+// objectStores returns a fresh store of every kind, so that Memory is held
+// to the behavior of Database.
+func objectStores(t *testing.T) map[string]ObjectStore {
+	t.Helper()
+	return map[string]ObjectStore{
+		"memory":   NewMemory(hashalgo.SHA1),
+		"database": New(t.TempDir(), hashalgo.SHA1),
+	}
+}
+
+// helloOID is the ID git gives the blob "hello\n".
+const helloOID = "ce013625030ba8dba906f756967f9e9ca394464a"
+
+func TestObjectStoreBlobs(t *testing.T) {
+	for name, store := range objectStores(t) {
+		t.Run(name, func(t *testing.T) {
+			blob := object.NewBlob([]byte("hello\n"))
+			if err := store.Store(blob); err != nil {
+				t.Fatal(err)
+			}
+			if got := blob.GetOID().String(); got != helloOID {
+				t.Fatalf("Store set OID %s, want %s", got, helloOID)
+			}
+			if err := store.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			streamed, err := store.StoreStream("blob", 6, strings.NewReader("hello\n"))
+			if err != nil || streamed != blob.GetOID() {
+				t.Errorf("StoreStream = %s, %v", streamed, err)
+			}
+			if hashed := store.HashObject(object.NewBlob([]byte("other\n"))); hashed.IsZero() {
+				t.Errorf("HashObject returned the zero ID")
+			}
+
+			objType, data, err := store.ReadObject(blob.GetOID())
+			if err != nil || objType != "blob" || string(data) != "hello\n" {
+				t.Errorf("ReadObject = %s %q, %v", objType, data, err)
+			}
+
+			reader, err := store.OpenObject(blob.GetOID())
+			if err != nil {
+				t.Fatal(err)
+			}
+			contents, err := io.ReadAll(reader)
+			reader.Close()
+			if err != nil || reader.Type != "blob" || reader.Size != 6 || string(contents) != "hello\n" {
+				t.Errorf("OpenObject = %s %d %q, %v", reader.Type, reader.Size, contents, err)
+			}
+
+			if ok, err := store.Exists(blob.GetOID()); err != nil || !ok {
+				t.Errorf("Exists = %v, %v", ok, err)
+			}
+			matches, err := store.PrefixMatch("ce01")
+			if err != nil || len(matches) != 1 || matches[0] != blob.GetOID() {
+				t.Errorf("PrefixMatch(ce01) = %v, %v", matches, err)
+			}
+
+			missing, _ := object.ParseOID(strings.Repeat("0", 40), hashalgo.SHA1)
+			if ok, err := store.Exists(missing); err != nil || ok {
+				t.Errorf("Exists(missing) = %v, %v", ok, err)
+			}
+			var notFound *ObjectNotFoundError
+			if _, _, err := store.ReadObject(missing); !errors.As(err, &notFound) {
+				t.Errorf("ReadObject(missing) error = %v, want ObjectNotFoundError", err)
+			}
+		})
+	}
+}
+
+func TestObjectStoreTrees(t *testing.T) {
+	for name, store := range objectStores(t) {
+		t.Run(name, func(t *testing.T) {
+			blob := object.NewBlob([]byte("hello\n"))
+			if err := store.Store(blob); err != nil {
+				t.Fatal(err)
+			}
+
+			root := object.Build([]*object.Entry{
+				object.NewEntryWithMode("dir/hello.txt", blob.GetOID(), "100644"),
+				object.NewEntryWithMode("top.txt", blob.GetOID(), "100644"),
+			})
+			var storeErr error
+			root.Traverse(func(tree *object.Tree) {
+				if err := store.Store(tree); err != nil && storeErr == nil {
+					storeErr = err
+				}
+			})
+			if storeErr != nil {
+				t.Fatal(storeErr)
+			}
+			if err := store.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			tree, err := store.LoadTree(root.GetOID())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if names := tree.Names(); len(names) != 2 || names[0] != "dir" || names[1] != "top.txt" {
+				t.Errorf("LoadTree names = %v", names)
+			}
+
+			files, err := store.ListTree(root.GetOID(), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 2 || files["dir/hello.txt"] == nil || files["dir/hello.txt"].OID != blob.GetOID() {
+				t.Errorf("ListTree = %v", files)
+			}
+
+			if _, err := store.LoadCommit(root.GetOID()); err == nil {
+				t.Errorf("LoadCommit accepted a tree")
+			}
+		})
+	}
+}
+
+func TestObjectStoreTags(t *testing.T) {
+	for name, store := range objectStores(t) {
+		t.Run(name, func(t *testing.T) {
+			data := "object " + helloOID + "\ntype blob\ntag v1\ntagger A U Thor <author@example.com> 1700000000 -0800\n\nrelease\n"
+			oid, err := store.StoreStream("tag", int64(len(data)), strings.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			obj, err := store.Load(oid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			loaded, ok := obj.(*tag.Tag)
+			if !ok {
+				t.Fatalf("Load returned a %T", obj)
+			}
+			if loaded.Object.String() != helloOID || loaded.ObjectType != "blob" || loaded.Name != "v1" || loaded.Message != "release\n" {
+				t.Errorf("Load = %+v", loaded)
+			}
+			if string(loaded.Bytes()) != data {
+				t.Errorf("Bytes =\n%s\nwant\n%s", loaded.Bytes(), data)
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+// Packfile layout:
+//
+//	header: 'PACK' | uint32 version (2 or 3) | uint32 object count
+//	entries: type and size varint | [delta base] | zlib(contents or delta)
+//	trailer: hash of everything before it
+//
+// A delta entry names its base either by a backwards offset within the same
+// pack (ofs-delta) or by object ID (ref-delta).
+const (
+	packSignature  = "PACK"
+	packHeaderSize = 12
+
+	packCommit   = 1
+	packTree     = 2
+	packBlob     = 3
+	packTag      = 4
+	packOfsDelta = 6
+	packRefDelta = 7
+
+	// maxDeltaDepth bounds delta chains so that a corrupt pack whose deltas
+	// refer to each other cannot recurse forever.
+	maxDeltaDepth = 10000
+)
+
+var packTypeNames = map[byte]string{
+	packCommit: "commit",
+	packTree:   "tree",
+	packBlob:   "blob",
+	packTag:    "tag",
+}
+
+var packTypeCodes = map[string]byte{
+	"commit": packCommit,
+	"tree":   packTree,
+	"blob":   packBlob,
+	"tag":    packTag,
+}
+
+// readPacked returns the type and contents of the object at offset in the
+// pack indexed by idx, applying deltas as needed.
+func (db *Database) readPacked(idx *packIndex, offset uint64) (string, []byte, error) {
+	f, err := os.Open(idx.packPath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+
+	return db.readPackEntry(f, idx, offset, 0)
+}
+
+func (db *Database) readPackEntry(f *os.File, idx *packIndex, offset uint64, depth int) (string, []byte, error) {
+	if depth > maxDeltaDepth {
+		return "", nil, fmt.Errorf("%s: delta chain too deep at offset %d", idx.packPath, offset)
+	}
+	if offset < packHeaderSize || offset > math.MaxInt64 {
+		return "", nil, fmt.Errorf("%s: bad object offset %d", idx.packPath, offset)
+	}
+
+	r := bufio.NewReader(io.NewSectionReader(f, int64(offset), math.MaxInt64-int64(offset)))
+	packType, size, err := readEntryHeader(r)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: offset %d: %w", idx.packPath, offset, err)
+	}
+
+	switch packType {
+	case packCommit, packTree, packBlob, packTag:
+		data, err := inflateEntry(r, size)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: offset %d: %w", idx.packPath, offset, err)
+		}
+		return packTypeNames[packType], data, nil
+
+	case packOfsDelta:
+		distance, err := readDeltaOffset(r)
+		if err != nil || distance == 0 || distance >= offset {
+			return "", nil, fmt.Errorf("%s: offset %d: bad delta base offset", idx.packPath, offset)
+		}
+		delta, err := inflateEntry(r, size)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: offset %d: %w", idx.packPath, offset, err)
+		}
+		baseType, base, err := db.readPackEntry(f, idx, offset-distance, depth+1)
+		if err != nil {
+			return "", nil, err
+		}
+		data, err := applyDelta(base, delta)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: offset %d: %w", idx.packPath, offset, err)
+		}
+		return baseType, data, nil
+
+	case packRefDelta:
+		raw := make([]byte, db.algo.Size)
+		if _, err := io.ReadFull(r, raw); err != nil {
+			return "", nil, fmt.Errorf("%s: offset %d: truncated delta base", idx.packPath, offset)
+		}
+		baseOID, err := object.OIDFromBytes(raw, db.algo)
+		if err != nil {
+			return "", nil, err
+		}
+		delta, err := inflateEntry(r, size)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: offset %d: %w", idx.packPath, offset, err)
+		}
+
+		// The base is normally in the same pack, but thin packs completed by
+		// a fetch may refer to objects stored elsewhere.
+		var baseType string
+		var base []byte
+		if baseOffset, ok := idx.lookup(baseOID); ok {
+			baseType, base, err = db.readPackEntry(f, idx, baseOffset, depth+1)
+		} else {
+			baseType, base, err = db.ReadObject(baseOID)
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		data, err := applyDelta(base, delta)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: offset %d: %w", idx.packPath, offset, err)
+		}
+		return baseType, data, nil
+
+	default:
+		return "", nil, fmt.Errorf("%s: offset %d: unknown object type %d", idx.packPath, offset, packType)
+	}
+}
+
+// readEntryHeader decodes the type and inflated size at the start of a pack
+// entry. The first byte holds the type in bits 4-6 and the low four bits of
+// the size; later bytes add seven bits each while the high bit is set.
+func readEntryHeader(r io.ByteReader) (byte, uint64, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	packType := (c >> 4) & 0x7
+	size := uint64(c & 0x0f)
+
+	for shift := uint(4); c&0x80 != 0; shift += 7 {
+		if shift > 57 {
+			return 0, 0, errors.New("object size overflows")
+		}
+		if c, err = r.ReadByte(); err != nil {
+			return 0, 0, err
+		}
+		size |= uint64(c&0x7f) << shift
+	}
+	return packType, size, nil
+}
+
+// readDeltaOffset decodes the distance back to an ofs-delta's base, which
+// uses the same encoding as reftable varints.
+func readDeltaOffset(r io.ByteReader) (uint64, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	distance := uint64(c & 0x7f)
+	for c&0x80 != 0 {
+		if distance >= 1<<56 {
+			return 0, errors.New("delta offset overflows")
+		}
+		if c, err = r.ReadByte(); err != nil {
+			return 0, err
+		}
+		distance = (distance+1)<<7 | uint64(c&0x7f)
+	}
+	return distance, nil
+}
+
+// inflateEntry decompresses size bytes of entry contents from r.
+func inflateEntry(r io.Reader, size uint64) ([]byte, error) {
+	if size > math.MaxInt32 {
+		return nil, fmt.Errorf("object of %d bytes is too large", size)
+	}
+
+	decoder, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate: %w", err)
+	}
+	defer decoder.Close()
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(decoder, data); err != nil {
+		return nil, fmt.Errorf("failed to inflate: %w", err)
+	}
+	return data, nil
+}
+
+// applyDelta rebuilds an object from its base and a delta, which is a pair
+// of size varints followed by copy-from-base and insert instructions.
+func applyDelta(base []byte, delta []byte) ([]byte, error) {
+	srcSize, n := binary.Uvarint(delta)
+	if n <= 0 || srcSize != uint64(len(base)) {
+		return nil, errors.New("delta does not match the size of its base")
+	}
+	delta = delta[n:]
+	dstSize, n := binary.Uvarint(delta)
+	if n <= 0 || dstSize > math.MaxInt32 {
+		return nil, errors.New("delta has a bad result size")
+	}
+	delta = delta[n:]
+
+	out := make([]byte, 0, dstSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		switch {
+		case op&0x80 != 0:
+			// Copy: bits 0-3 select offset bytes, bits 4-6 size bytes.
+			var offset, size uint64
+			for i := 0; i < 7; i++ {
+				if op&(1<<i) == 0 {
+					continue
+				}
+				if len(delta) == 0 {
+					return nil, errors.New("truncated delta copy instruction")
+				}
+				if i < 4 {
+					offset |= uint64(delta[0]) << (8 * i)
+				} else {
+					size |= uint64(delta[0]) << (8 * (i - 4))
+				}
+				delta = delta[1:]
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > uint64(len(base)) {
+				return nil, errors.New("delta copies beyond the end of its base")
+			}
+			out = append(out, base[offset:offset+size]...)
+		case op != 0:
+			// Insert: the next op bytes are literal data.
+			if int(op) > len(delta) {
+				return nil, errors.New("truncated delta insert instruction")
+			}
+			out = append(out, delta[:op]...)
+			delta = delta[op:]
+		default:
+			return nil, errors.New("delta uses a reserved instruction")
+		}
+	}
+
+	if uint64(len(out)) != dstSize {
+		return nil, errors.New("delta result has the wrong size")
+	}
+	return out, nil
+}
+
+// PackedObjects returns the OIDs of every object stored in a pack.
+func (db *Database) PackedObjects() ([]object.ObjectID, error) {
+	packs, err := db.packIndexes()
+	if err != nil {
+		return nil, err
+	}
+
+	var oids []object.ObjectID
+	for _, idx := range packs {
+		for i := 0; i < idx.count; i++ {
+			oids = append(oids, idx.oidAt(i))
+		}
+	}
+	return oids, nil
+}
+
+// ReadPackedObject reads oid from the first pack containing it, even when a
+// loose copy exists, so that packed copies can be checked on their own.
+func (db *Database) ReadPackedObject(oid object.ObjectID) (string, []byte, error) {
+	idx, err := db.findPacked(oid)
+	if err != nil {
+		return "", nil, err
+	}
+	if idx == nil {
+		return "", nil, &ObjectNotFoundError{OID: oid}
+	}
+
+	offset, _ := idx.lookup(oid)
+	return db.readPacked(idx, offset)
+}
+
+// VerifyPacks checks the header and trailing checksum of every packfile, and
+// that each agrees with its index. It returns one error per damaged pack.
+func (db *Database) VerifyPacks() ([]error, error) {
+	packs, err := db.packIndexes()
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []error
+	for _, idx := range packs {
+		if err := db.verifyPack(idx); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", idx.packPath, err))
+		}
+	}
+	return problems, nil
+}
+
+func (db *Database) verifyPack(idx *packIndex) error {
+	f, err := os.Open(idx.packPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	header := make([]byte, packHeaderSize)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return errors.New("pack is too short")
+	}
+	if string(header[:4]) != packSignature {
+		return errors.New("bad pack signature")
+	}
+	if v := binary.BigEndian.Uint32(header[4:8]); v != 2 && v != 3 {
+		return fmt.Errorf("unsupported pack version %d", v)
+	}
+	if count := binary.BigEndian.Uint32(header[8:12]); int(count) != idx.count {
+		return fmt.Errorf("pack holds %d objects but its index lists %d", count, idx.count)
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	bodySize := stat.Size() - int64(db.algo.Size)
+	if bodySize < packHeaderSize {
+		return errors.New("pack is too short")
+	}
+
+	digest := db.algo.New()
+	digest.Write(header)
+	if _, err := io.CopyN(digest, f, bodySize-packHeaderSize); err != nil {
+		return err
+	}
+	trailer := make([]byte, db.algo.Size)
+	if _, err := io.ReadFull(f, trailer); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(digest.Sum(nil), trailer) {
+		return errors.New("pack checksum does not match its contents")
+	}
+	if !bytes.Equal(trailer, idx.packChecksum) {
+		return errors.New("pack checksum does not match its index")
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+const (
+	// PackDir is the directory under objects/ holding packfiles.
+	PackDir = "pack"
+
+	packIndexMagic   = "\xfftOc"
+	packIndexVersion = 2
+	fanoutSize       = 256 * 4
+)
+
+// packIndex is a parsed .idx file, mapping the OIDs stored in one packfile
+// to their offsets. Both version 1 and version 2 indexes are understood.
+type packIndex struct {
+	packPath string
+	algo     *hashalgo.Algorithm
+	oidSize  int
+	count    int
+	fanout   []byte
+	oids     []byte
+	offsets  func(i int) uint64
+
+	// packChecksum is the trailing checksum of the packfile, as recorded
+	// by the index.
+	packChecksum []byte
+}
+
+// readPackIndex parses the .idx file at path, whose OIDs and checksums are
+// computed with algo.
+func readPackIndex(path string, algo *hashalgo.Algorithm) (*packIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < fanoutSize+2*algo.Size {
+		return nil, fmt.Errorf("pack index %s is too short", path)
+	}
+
+	body := data[:len(data)-algo.Size]
+	if !bytes.Equal(algo.Sum(body), data[len(body):]) {
+		return nil, fmt.Errorf("pack index %s checksum does not match its contents", path)
+	}
+
+	idx := &packIndex{
+		packPath: strings.TrimSuffix(path, ".idx") + ".pack",
+		algo:     algo,
+		oidSize:  algo.Size,
+
+		packChecksum: body[len(body)-algo.Size:],
+	}
+
+	if strings.HasPrefix(string(data), packIndexMagic) {
+		if v := binary.BigEndian.Uint32(data[4:8]); v != packIndexVersion {
+			return nil, fmt.Errorf("pack index %s has unsupported version %d", path, v)
+		}
+		idx.fanout = data[8 : 8+fanoutSize]
+		idx.count = int(binary.BigEndian.Uint32(idx.fanout[fanoutSize-4:]))
+
+		oidStart := 8 + fanoutSize
+		crcStart := oidStart + idx.count*idx.oidSize
+		offsetStart := crcStart + idx.count*4
+		largeStart := offsetStart + idx.count*4
+		if largeStart > len(body) {
+			return nil, fmt.Errorf("pack index %s is truncated", path)
+		}
+
+		idx.oids = data[oidStart:crcStart]
+		idx.offsets = func(i int) uint64 {
+			offset := binary.BigEndian.Uint32(data[offsetStart+i*4:])
+			if offset&0x80000000 == 0 {
+				return uint64(offset)
+			}
+			// Offsets beyond 2GiB live in a separate table of 64-bit values.
+			large := largeStart + int(offset&0x7fffffff)*8
+			return binary.BigEndian.Uint64(data[large:])
+		}
+		return idx, nil
+	}
+
+	// Version 1: the fan-out table is followed by (offset, OID) pairs.
+	idx.fanout = data[:fanoutSize]
+	idx.count = int(binary.BigEndian.Uint32(idx.fanout[fanoutSize-4:]))
+	entrySize := 4 + idx.oidSize
+	if fanoutSize+idx.count*entrySize > len(body) {
+		return nil, fmt.Errorf("pack index %s is truncated", path)
+	}
+
+	entries := data[fanoutSize : fanoutSize+idx.count*entrySize]
+	idx.oids = make([]byte, 0, idx.count*idx.oidSize)
+	for i := 0; i < idx.count; i++ {
+		idx.oids = append(idx.oids, entries[i*entrySize+4:(i+1)*entrySize]...)
+	}
+	idx.offsets = func(i int) uint64 {
+		return uint64(binary.BigEndian.Uint32(entries[i*entrySize:]))
+	}
+	return idx, nil
+}
+
+// oidAt returns the OID of the ith entry.
+func (idx *packIndex) oidAt(i int) object.ObjectID {
+	oid, _ := object.OIDFromBytes(idx.oids[i*idx.oidSize:(i+1)*idx.oidSize], idx.algo)
+	return oid
+}
+
+// prefixMatch returns the OIDs in the pack starting with the hex prefix,
+// which must be at least two digits long.
+func (idx *packIndex) prefixMatch(prefix string) []object.ObjectID {
+	first, err := hex.DecodeString(prefix[:2])
+	if err != nil {
+		return nil
+	}
+
+	lo := 0
+	if first[0] > 0 {
+		lo = int(binary.BigEndian.Uint32(idx.fanout[(int(first[0])-1)*4:]))
+	}
+	hi := int(binary.BigEndian.Uint32(idx.fanout[int(first[0])*4:]))
+
+	var matches []object.ObjectID
+	for i := lo; i < hi; i++ {
+		if oid := idx.oidAt(i); strings.HasPrefix(oid.String(), prefix) {
+			matches = append(matches, oid)
+		}
+	}
+	return matches
+}
+
+// lookup returns the pack offset of oid and whether the pack contains it.
+func (idx *packIndex) lookup(oid object.ObjectID) (uint64, bool) {
+	raw := oid.Bytes()
+	if len(raw) != idx.oidSize {
+		return 0, false
+	}
+
+	// The fan-out table bounds the search to OIDs sharing the first byte.
+	lo := 0
+	if raw[0] > 0 {
+		lo = int(binary.BigEndian.Uint32(idx.fanout[(int(raw[0])-1)*4:]))
+	}
+	hi := int(binary.BigEndian.Uint32(idx.fanout[int(raw[0])*4:]))
+
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		start := (lo + i) * idx.oidSize
+		return bytes.Compare(idx.oids[start:start+idx.oidSize], raw) >= 0
+	})
+	if i < hi && bytes.Equal(idx.oids[i*idx.oidSize:(i+1)*idx.oidSize], raw) {
+		return idx.offsets(i), true
+	}
+	return 0, false
+}
+
+// packIndexes returns the indexes of every pack in the database, reading
+// them on first use.
+func (db *Database) packIndexes() ([]*packIndex, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.packs != nil {
+		return db.packs, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(db.pathname, PackDir, "pack-*.idx"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	db.packs = make([]*packIndex, 0, len(paths))
+	for _, path := range paths {
+		idx, err := readPackIndex(path, db.algo)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				// Removed by a concurrent repack.
+				continue
+			}
+			return nil, err
+		}
+		db.packs = append(db.packs, idx)
+	}
+
+	return db.packs, nil
+}
+
+// findPacked returns the index of the pack containing oid, or nil.
+func (db *Database) findPacked(oid object.ObjectID) (*packIndex, error) {
+	packs, err := db.packIndexes()
+	if err != nil {
+		return nil, err
+	}
+	for _, idx := range packs {
+		if _, ok := idx.lookup(oid); ok {
+			return idx, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,268 @@
+package storage
+
+import (
+	"bufio"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/shanmugharajk/gogit/internal/file"
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+// packVersion is the version of packfiles written by WritePack.
+const packVersion = 2
+
+// packedEntry records where WritePack put an object, for the index.
+type packedEntry struct {
+	oid    object.ObjectID
+	offset uint64
+	crc    uint32
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n uint64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += uint64(n)
+	return n, err
+}
+
+// WritePack writes the objects oids to a new packfile and its version 2
+// index under objects/pack, and returns the path of the pack. Objects are
+// stored whole rather than as deltas. The index is moved into place after
+// the pack, so that a visible index always has its pack.
+func (db *Database) WritePack(oids []object.ObjectID) (string, error) {
+	packDir := filepath.Join(db.pathname, PackDir)
+	if err := os.MkdirAll(packDir, file.ModeDir); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	packFile, err := os.CreateTemp(packDir, "tmp_pack_")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	entries, checksum, err := db.writePackData(packFile, oids)
+	if err == nil {
+		err = packFile.Sync()
+	}
+	if err != nil {
+		discardTemp(packFile)
+		return "", fmt.Errorf("failed to write pack: %w", err)
+	}
+	if err := packFile.Close(); err != nil {
+		os.Remove(packFile.Name())
+		return "", fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	idxFile, err := os.CreateTemp(packDir, "tmp_idx_")
+	if err != nil {
+		os.Remove(packFile.Name())
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	if _, err := idxFile.Write(db.encodePackIndex(entries, checksum)); err == nil {
+		err = idxFile.Sync()
+	}
+	if err != nil {
+		discardTemp(idxFile)
+		os.Remove(packFile.Name())
+		return "", fmt.Errorf("failed to write pack index: %w", err)
+	}
+	if err := idxFile.Close(); err != nil {
+		os.Remove(idxFile.Name())
+		os.Remove(packFile.Name())
+		return "", fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	base := filepath.Join(packDir, "pack-"+hex.EncodeToString(checksum))
+	if err := os.Rename(packFile.Name(), base+".pack"); err != nil {
+		os.Remove(idxFile.Name())
+		os.Remove(packFile.Name())
+		return "", fmt.Errorf("failed to rename temporary file: %w", err)
+	}
+	if err := os.Rename(idxFile.Name(), base+".idx"); err != nil {
+		os.Remove(idxFile.Name())
+		return "", fmt.Errorf("failed to rename temporary file: %w", err)
+	}
+
+	db.reloadPacks()
+	return base + ".pack", nil
+}
+
+// writePackData writes the header, entries and trailer of a pack holding
+// oids to w, returning the position of each entry and the pack checksum.
+func (db *Database) writePackData(w io.Writer, oids []object.ObjectID) ([]packedEntry, []byte, error) {
+	digest := db.algo.New()
+	buffered := bufio.NewWriter(io.MultiWriter(w, digest))
+	out := &countingWriter{w: buffered}
+
+	header := make([]byte, packHeaderSize)
+	copy(header, packSignature)
+	binary.BigEndian.PutUint32(header[4:8], packVersion)
+	binary.BigEndian.PutUint32(header[8:12], uint32(len(oids)))
+	if _, err := out.Write(header); err != nil {
+		return nil, nil, err
+	}
+
+	entries := make([]packedEntry, 0, len(oids))
+	for _, oid := range oids {
+		entry := packedEntry{oid: oid, offset: out.n}
+		crc, err := db.writePackEntry(out, oid)
+		if err != nil {
+			return nil, nil, err
+		}
+		entry.crc = crc
+		entries = append(entries, entry)
+	}
+
+	if err := buffered.Flush(); err != nil {
+		return nil, nil, err
+	}
+	checksum := digest.Sum(nil)
+	if _, err := w.Write(checksum); err != nil {
+		return nil, nil, err
+	}
+	return entries, checksum, nil
+}
+
+// writePackEntry streams one object into a pack and returns the CRC-32 of
+// its entry, which the index records.
+func (db *Database) writePackEntry(w io.Writer, oid object.ObjectID) (uint32, error) {
+	obj, err := db.OpenObject(oid)
+	if err != nil {
+		return 0, err
+	}
+	defer obj.Close()
+
+	packType, ok := packTypeCodes[obj.Type]
+	if !ok {
+		return 0, fmt.Errorf("object %s has unsupported type %s", oid, obj.Type)
+	}
+
+	crc := crc32.NewIEEE()
+	entry := io.MultiWriter(w, crc)
+	if _, err := entry.Write(appendEntryHeader(nil, packType, uint64(obj.Size))); err != nil {
+		return 0, err
+	}
+
+	encoder := zlib.NewWriter(entry)
+	n, err := io.Copy(encoder, obj)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read object %s: %w", oid, err)
+	}
+	if n != obj.Size {
+		return 0, fmt.Errorf("object %s: size mismatch: header says %d, found %d", oid, obj.Size, n)
+	}
+	if err := encoder.Close(); err != nil {
+		return 0, err
+	}
+	return crc.Sum32(), nil
+}
+
+// appendEntryHeader appends the type and size header of a pack entry, the
+// inverse of readEntryHeader.
+func appendEntryHeader(buf []byte, packType byte, size uint64) []byte {
+	c := packType<<4 | byte(size&0x0f)
+	size >>= 4
+	for size != 0 {
+		buf = append(buf, c|0x80)
+		c = byte(size & 0x7f)
+		size >>= 7
+	}
+	return append(buf, c)
+}
+
+// encodePackIndex builds a version 2 index for the entries of the pack whose
+// checksum is packChecksum.
+func (db *Database) encodePackIndex(entries []packedEntry, packChecksum []byte) []byte {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].oid.Compare(entries[j].oid) < 0
+	})
+
+	var buf []byte
+	buf = append(buf, packIndexMagic...)
+	buf = binary.BigEndian.AppendUint32(buf, packIndexVersion)
+
+	var fanout [256]uint32
+	for _, entry := range entries {
+		fanout[entry.oid.Bytes()[0]]++
+	}
+	var total uint32
+	for _, count := range fanout {
+		total += count
+		buf = binary.BigEndian.AppendUint32(buf, total)
+	}
+
+	for _, entry := range entries {
+		buf = append(buf, entry.oid.Bytes()...)
+	}
+	for _, entry := range entries {
+		buf = binary.BigEndian.AppendUint32(buf, entry.crc)
+	}
+
+	// Offsets that do not fit in 31 bits go to a table of 64-bit values.
+	var large []uint64
+	for _, entry := range entries {
+		if entry.offset < 0x80000000 {
+			buf = binary.BigEndian.AppendUint32(buf, uint32(entry.offset))
+			continue
+		}
+		buf = binary.BigEndian.AppendUint32(buf, 0x80000000|uint32(len(large)))
+		large = append(large, entry.offset)
+	}
+	for _, offset := range large {
+		buf = binary.BigEndian.AppendUint64(buf, offset)
+	}
+
+	buf = append(buf, packChecksum...)
+	return append(buf, db.algo.Sum(buf)...)
+}
+
+// reloadPacks forgets the cached pack indexes so that the next lookup sees
+// packs added or removed since.
+func (db *Database) reloadPacks() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.packs = nil
+}
+
+// Packs returns the paths of the packfiles in the database.
+func (db *Database) Packs() ([]string, error) {
+	packs, err := db.packIndexes()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(packs))
+	for i, idx := range packs {
+		paths[i] = idx.packPath
+	}
+	return paths, nil
+}
+
+// RemovePack deletes the packfile at packPath together with its index. The
+// index goes first so that readers never see an index without its pack.
+func (db *Database) RemovePack(packPath string) error {
+	base := packPath[:len(packPath)-len(filepath.Ext(packPath))]
+	for _, path := range []string{base + ".idx", packPath} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	db.reloadPacks()
+	return nil
+}
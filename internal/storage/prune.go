@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+// tempPrefixes name the temporary files object and pack writers create,
+// which an interrupted process leaves behind.
+var tempPrefixes = []string{"tmp_obj_", "tmp_batch_", "tmp_pack_", "tmp_idx_"}
+
+// PruneLoose removes the loose objects for which keep returns false and
+// whose files were last modified before expire, and returns their OIDs. The
+// grace period protects objects that a concurrent command has written but
+// not yet referenced. With dryRun nothing is removed.
+func (db *Database) PruneLoose(keep func(object.ObjectID) bool, expire time.Time, dryRun bool) ([]object.ObjectID, error) {
+	loose, err := db.LooseObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []object.ObjectID
+	for _, oid := range loose {
+		if keep(oid) {
+			continue
+		}
+		info, err := os.Stat(db.objectPath(oid))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return pruned, err
+		}
+		if !info.ModTime().Before(expire) {
+			continue
+		}
+
+		if !dryRun {
+			if err := db.removeLoose(oid); err != nil {
+				return pruned, err
+			}
+		}
+		pruned = append(pruned, oid)
+	}
+	return pruned, nil
+}
+
+// PrunePacked removes loose objects that are also stored in a pack and
+// returns their OIDs. With dryRun nothing is removed.
+func (db *Database) PrunePacked(dryRun bool) ([]object.ObjectID, error) {
+	loose, err := db.LooseObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []object.ObjectID
+	for _, oid := range loose {
+		idx, err := db.findPacked(oid)
+		if err != nil {
+			return pruned, err
+		}
+		if idx == nil {
+			continue
+		}
+
+		if !dryRun {
+			if err := db.removeLoose(oid); err != nil {
+				return pruned, err
+			}
+		}
+		pruned = append(pruned, oid)
+	}
+	return pruned, nil
+}
+
+// removeLoose deletes the file of a loose object, and its fan-out directory
+// once that is empty.
+func (db *Database) removeLoose(oid object.ObjectID) error {
+	objPath := db.objectPath(oid)
+	if err := os.Remove(objPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove object %s: %w", oid, err)
+	}
+
+	// Fails harmlessly while other objects remain in the directory.
+	os.Remove(filepath.Dir(objPath))
+	return nil
+}
+
+// RemoveTempFiles removes temporary files left by interrupted object and
+// pack writes that were last modified before expire, and returns their
+// paths. With dryRun nothing is removed.
+func (db *Database) RemoveTempFiles(expire time.Time, dryRun bool) ([]string, error) {
+	var removed []string
+	for _, dir := range []string{db.pathname, filepath.Join(db.pathname, PackDir)} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return removed, err
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !isTempName(entry.Name()) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || !info.ModTime().Before(expire) {
+				continue
+			}
+
+			path := filepath.Join(dir, entry.Name())
+			if !dryRun {
+				if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+					return removed, err
+				}
+			}
+			removed = append(removed, path)
+		}
+	}
+	return removed, nil
+}
+
+func isTempName(name string) bool {
+	for _, prefix := range tempPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoosenPacked writes the objects of packs other than except for which keep
+// returns false out as loose objects, so that dropping those packs leaves
+// them to PruneLoose and its grace period. Each loosened object takes the
+// modification time of its pack; objects of packs last modified before
+// expire are not loosened, as they would be pruned straight away. It returns
+// the number of objects written.
+func (db *Database) LoosenPacked(keep func(object.ObjectID) bool, expire time.Time, except string) (int, error) {
+	packs, err := db.packIndexes()
+	if err != nil {
+		return 0, err
+	}
+
+	loosened := make(map[object.ObjectID]time.Time)
+	for _, idx := range packs {
+		if idx.packPath == except {
+			continue
+		}
+		info, err := os.Stat(idx.packPath)
+		if err != nil {
+			return 0, err
+		}
+		if info.ModTime().Before(expire) {
+			continue
+		}
+
+		for i := 0; i < idx.count; i++ {
+			oid := idx.oidAt(i)
+			if keep(oid) {
+				continue
+			}
+			if _, ok := loosened[oid]; ok {
+				continue
+			}
+			if _, err := os.Stat(db.objectPath(oid)); err == nil {
+				continue
+			}
+
+			offset := idx.offsets(i)
+			objType, data, err := db.readPacked(idx, offset)
+			if err != nil {
+				return 0, fmt.Errorf("object %s: %w", oid, err)
+			}
+			content := fmt.Appendf(nil, "%s %d\x00", objType, len(data))
+			if err := db.writeObject(oid, append(content, data...)); err != nil {
+				return 0, err
+			}
+			loosened[oid] = info.ModTime()
+		}
+	}
+
+	if err := db.Flush(); err != nil {
+		return 0, err
+	}
+	for oid, mtime := range loosened {
+		if err := os.Chtimes(db.objectPath(oid), mtime, mtime); err != nil {
+			return 0, err
+		}
+	}
+	return len(loosened), nil
+}
@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/shanmugharajk/gogit/internal/commit"
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/object"
+	"github.com/shanmugharajk/gogit/internal/tag"
+)
+
+// ObjectStore reads and writes git objects. Database keeps them on disk and
+// Memory keeps them in memory.
+type ObjectStore interface {
+	// Algorithm returns the hash algorithm naming the store's objects.
+	Algorithm() *hashalgo.Algorithm
+
+	// Store persists obj and sets its OID.
+	Store(obj object.Object) error
+
+	// HashObject computes and sets the OID of obj without storing it.
+	HashObject(obj object.Object) object.ObjectID
+
+	// StoreStream stores an object of objType whose size bytes of contents
+	// are read from r, and returns its OID.
+	StoreStream(objType string, size int64, r io.Reader) (object.ObjectID, error)
+
+	// HashStream computes the OID of an object of objType whose size bytes
+	// of contents are read from r, without storing it.
+	HashStream(objType string, size int64, r io.Reader) (object.ObjectID, error)
+
+	// OpenObject opens the object oid for reading. The caller must close
+	// the returned reader.
+	OpenObject(oid object.ObjectID) (*ObjectReader, error)
+
+	// ReadObject returns the type and contents of the object oid.
+	ReadObject(oid object.ObjectID) (string, []byte, error)
+
+	// Load reads and parses the object oid.
+	Load(oid object.ObjectID) (object.Object, error)
+
+	// LoadCommit loads oid and checks that it is a commit.
+	LoadCommit(oid object.ObjectID) (*commit.Commit, error)
+
+	// LoadTree loads oid and checks that it is a tree.
+	LoadTree(oid object.ObjectID) (*object.Tree, error)
+
+	// ListTree flattens the tree oid into a map from slash-separated path
+	// to blob entry. prefix is prepended to every path.
+	ListTree(oid object.ObjectID, prefix string) (map[string]*object.StoredEntry, error)
+
+	// Exists reports whether the object oid is stored.
+	Exists(oid object.ObjectID) (bool, error)
+
+	// PrefixMatch returns the sorted OIDs of all stored objects whose hex
+	// form starts with prefix, which must be at least two hex digits long.
+	PrefixMatch(prefix string) ([]object.ObjectID, error)
+
+	// Flush makes every object stored so far durable and visible.
+	Flush() error
+}
+
+var (
+	_ ObjectStore = (*Database)(nil)
+	_ ObjectStore = (*Memory)(nil)
+)
+
+// encodeObject builds "type size\0data" for obj and sets its OID to the hash
+// of that content under algo.
+func encodeObject(algo *hashalgo.Algorithm, obj object.Object) []byte {
+	data := obj.Bytes()
+
+	// Create the content with null terminator: "type size\0data"
+	header := fmt.Sprintf("%s %d\x00", obj.Type(), len(data))
+	content := append([]byte(header), data...)
+
+	// Compute the hash and set OID
+	oid, _ := object.OIDFromBytes(algo.Sum(content), algo)
+	obj.SetOID(oid)
+
+	return content
+}
+
+// copyObject writes the header and contents of an object to w and returns
+// the hash of everything written under algo. r must yield exactly size
+// bytes.
+func copyObject(algo *hashalgo.Algorithm, w io.Writer, objType string, size int64, r io.Reader) (object.ObjectID, error) {
+	digest := algo.New()
+	out := io.MultiWriter(w, digest)
+
+	if _, err := fmt.Fprintf(out, "%s %d\x00", objType, size); err != nil {
+		return object.ObjectID{}, err
+	}
+
+	n, err := io.Copy(out, io.LimitReader(r, size))
+	if err != nil {
+		return object.ObjectID{}, fmt.Errorf("failed to read object contents: %w", err)
+	}
+	if n != size {
+		return object.ObjectID{}, fmt.Errorf("object contents shorter than expected: read %d of %d bytes", n, size)
+	}
+
+	return object.OIDFromBytes(digest.Sum(nil), algo)
+}
+
+// readObject reads the whole object oid from store, checking its size.
+func readObject(store ObjectStore, oid object.ObjectID) (string, []byte, error) {
+	obj, err := store.OpenObject(oid)
+	if err != nil {
+		return "", nil, err
+	}
+	defer obj.Close()
+
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to inflate object %s: %w", oid, err)
+	}
+	if int64(len(data)) != obj.Size {
+		return "", nil, fmt.Errorf("object %s: size mismatch: header says %d, found %d", oid, obj.Size, len(data))
+	}
+
+	return obj.Type, data, nil
+}
+
+// loadObject reads and parses the object oid from store.
+func loadObject(store ObjectStore, oid object.ObjectID) (object.Object, error) {
+	objType, data, err := store.ReadObject(oid)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj object.Object
+	switch objType {
+	case "blob":
+		obj = object.NewBlob(data)
+	case "tree":
+		obj, err = object.ParseTree(data, store.Algorithm())
+	case "commit":
+		obj, err = commit.Parse(data, store.Algorithm())
+	case "tag":
+		obj, err = tag.Parse(data, store.Algorithm())
+	default:
+		return nil, fmt.Errorf("object %s has unsupported type %s", oid, objType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s %s: %w", objType, oid, err)
+	}
+
+	obj.SetOID(oid)
+	return obj, nil
+}
+
+// loadCommit loads oid from store and checks that it is a commit.
+func loadCommit(store ObjectStore, oid object.ObjectID) (*commit.Commit, error) {
+	obj, err := store.Load(oid)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := obj.(*commit.Commit)
+	if !ok {
+		return nil, fmt.Errorf("object %s is a %s, not a commit", oid, obj.Type())
+	}
+	return c, nil
+}
+
+// loadTree loads oid from store and checks that it is a tree.
+func loadTree(store ObjectStore, oid object.ObjectID) (*object.Tree, error) {
+	obj, err := store.Load(oid)
+	if err != nil {
+		return nil, err
+	}
+	tree, ok := obj.(*object.Tree)
+	if !ok {
+		return nil, fmt.Errorf("object %s is a %s, not a tree", oid, obj.Type())
+	}
+	return tree, nil
+}
+
+// listTree adds the blobs of the tree oid in store to result, descending
+// into subtrees.
+func listTree(store ObjectStore, oid object.ObjectID, prefix string, result map[string]*object.StoredEntry) error {
+	tree, err := store.LoadTree(oid)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range tree.Names() {
+		entry, ok := tree.Get(name).(*object.StoredEntry)
+		if !ok {
+			continue
+		}
+		entryPath := path.Join(prefix, name)
+		if entry.IsTree() {
+			if err := listTree(store, entry.OID, entryPath, result); err != nil {
+				return err
+			}
+			continue
+		}
+		result[entryPath] = entry
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/file"
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+// StoreStream writes an object of objType whose size bytes of contents are
+// read from r, hashing and deflating as it goes so the contents are never
+// held in memory. It returns the OID of the stored object. When r can seek,
+// the contents are hashed first so that an already stored object is not
+// compressed again.
+func (db *Database) StoreStream(objType string, size int64, r io.Reader) (object.ObjectID, error) {
+	if seeker, ok := r.(io.Seeker); ok {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return object.ObjectID{}, err
+		}
+		oid, err := db.HashStream(objType, size, r)
+		if err != nil {
+			return object.ObjectID{}, err
+		}
+		if db.freshen(oid) {
+			return oid, nil
+		}
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return object.ObjectID{}, err
+		}
+	}
+
+	if err := os.MkdirAll(db.pathname, file.ModeDir); err != nil {
+		return object.ObjectID{}, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// The OID is only known at the end, so write to the top-level directory
+	// and move the file into place afterwards.
+	tempFile, err := os.CreateTemp(db.pathname, "tmp_obj_")
+	if err != nil {
+		return object.ObjectID{}, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	encoder := zlib.NewWriter(tempFile)
+	oid, err := copyObject(db.algo, encoder, objType, size, r)
+	if err == nil {
+		err = encoder.Close()
+	}
+	if err != nil {
+		discardTemp(tempFile)
+		return object.ObjectID{}, fmt.Errorf("failed to write object: %w", err)
+	}
+
+	if db.freshen(oid) {
+		discardTemp(tempFile)
+		return oid, nil
+	}
+	if err := db.finishObject(oid, tempFile); err != nil {
+		return object.ObjectID{}, err
+	}
+	return oid, nil
+}
+
+// HashStream computes the OID of an object of objType whose size bytes of
+// contents are read from r, without writing anything.
+func (db *Database) HashStream(objType string, size int64, r io.Reader) (object.ObjectID, error) {
+	return copyObject(db.algo, io.Discard, objType, size, r)
+}
+
+// ObjectReader streams the contents of a stored object, inflating it as it
+// is read.
+type ObjectReader struct {
+	Type string
+	Size int64
+
+	contents io.Reader
+	decoder  io.ReadCloser
+	file     *os.File
+}
+
+// OpenObject opens the object with the given OID for reading, looking in
+// the alternates when it is not stored locally. The caller must close the
+// returned reader.
+func (db *Database) OpenObject(oid object.ObjectID) (*ObjectReader, error) {
+	if oid.Size() != db.algo.Size {
+		return nil, &ObjectNotFoundError{OID: oid}
+	}
+
+	obj, err := db.openLocal(oid)
+	var notFound *ObjectNotFoundError
+	if !errors.As(err, &notFound) {
+		return obj, err
+	}
+
+	alternates, err := db.alternateDatabases()
+	if err != nil {
+		return nil, err
+	}
+	for _, alt := range alternates {
+		obj, err := alt.OpenObject(oid)
+		if !errors.As(err, &notFound) {
+			return obj, err
+		}
+	}
+	return nil, &ObjectNotFoundError{OID: oid}
+}
+
+// openLocal opens an object stored loose or packed in this database.
+func (db *Database) openLocal(oid object.ObjectID) (*ObjectReader, error) {
+	f, err := os.Open(db.loosePath(oid))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return db.openPacked(oid)
+		}
+		return nil, err
+	}
+
+	decoder, err := zlib.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to inflate object %s: %w", oid, err)
+	}
+
+	buffered := bufio.NewReader(decoder)
+	header, err := buffered.ReadString(0)
+	if err != nil {
+		decoder.Close()
+		f.Close()
+		return nil, fmt.Errorf("object %s: missing object header", oid)
+	}
+	objType, size, err := parseHeader(strings.TrimSuffix(header, "\x00"))
+	if err != nil {
+		decoder.Close()
+		f.Close()
+		return nil, fmt.Errorf("object %s: %w", oid, err)
+	}
+
+	return &ObjectReader{
+		Type:     objType,
+		Size:     size,
+		contents: io.LimitReader(buffered, size),
+		decoder:  decoder,
+		file:     f,
+	}, nil
+}
+
+// openPacked opens a packed object. Deltas make it impractical to stream
+// packed contents, so the object is inflated into memory.
+func (db *Database) openPacked(oid object.ObjectID) (*ObjectReader, error) {
+	idx, err := db.findPacked(oid)
+	if err != nil {
+		return nil, err
+	}
+	if idx == nil {
+		return nil, &ObjectNotFoundError{OID: oid}
+	}
+
+	offset, _ := idx.lookup(oid)
+	objType, data, err := db.readPacked(idx, offset)
+	if err != nil {
+		return nil, fmt.Errorf("object %s: %w", oid, err)
+	}
+
+	return &ObjectReader{
+		Type:     objType,
+		Size:     int64(len(data)),
+		contents: bytes.NewReader(data),
+	}, nil
+}
+
+// Read reads inflated object contents.
+func (o *ObjectReader) Read(p []byte) (int, error) {
+	return o.contents.Read(p)
+}
+
+// Close releases the underlying file, if any.
+func (o *ObjectReader) Close() error {
+	if o.file == nil {
+		return nil
+	}
+	o.decoder.Close()
+	return o.file.Close()
+}
+
+// parseHeader splits an object header of the form "type size".
+func parseHeader(header string) (string, int64, error) {
+	objType, sizeText, ok := strings.Cut(header, " ")
+	if !ok {
+		return "", 0, fmt.Errorf("malformed object header")
+	}
+	size, err := strconv.ParseInt(sizeText, 10, 64)
+	if err != nil || size < 0 {
+		return "", 0, fmt.Errorf("malformed object size %q", sizeText)
+	}
+	return objType, size, nil
+}
@@ -0,0 +1,137 @@
+package tag
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/commit"
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+// signatureMarkers begin the armored signatures git appends to the message
+// of a signed tag: OpenPGP, X.509 and SSH.
+var signatureMarkers = []string{
+	"-----BEGIN PGP SIGNATURE-----",
+	"-----BEGIN PGP MESSAGE-----",
+	"-----BEGIN SIGNED MESSAGE-----",
+	"-----BEGIN SSH SIGNATURE-----",
+}
+
+// Tag is an annotated tag: a named, optionally signed pointer to another
+// object.
+type Tag struct {
+	oid object.ObjectID
+
+	Object     object.ObjectID
+	ObjectType string
+	Name       string
+	// Tagger is nil for the old tags made without one.
+	Tagger  *commit.Author
+	Message string
+
+	// Signature is the armored signature over the rest of the tag, which
+	// follows the message, if any.
+	Signature string
+}
+
+func (t *Tag) SetOID(oid object.ObjectID) {
+	t.oid = oid
+}
+
+func (t *Tag) GetOID() object.ObjectID {
+	return t.oid
+}
+
+func (t *Tag) Type() string {
+	return "tag"
+}
+
+func (t *Tag) Bytes() []byte {
+	result := fmt.Appendf(nil, "object %s\ntype %s\ntag %s\n", t.Object, t.ObjectType, t.Name)
+	if t.Tagger != nil {
+		result = fmt.Appendf(result, "tagger %s\n", t.Tagger.Bytes())
+	}
+	result = fmt.Appendf(result, "\n%s%s", t.Message, t.Signature)
+	return result
+}
+
+// NewTag creates an unsigned tag called name pointing at target, an object
+// of objType.
+func NewTag(target object.ObjectID, objType string, name string, tagger *commit.Author, message string) *Tag {
+	return &Tag{
+		Object:     target,
+		ObjectType: objType,
+		Name:       name,
+		Tagger:     tagger,
+		Message:    message,
+	}
+}
+
+// ExtractSignature splits the raw contents of a tag into the payload that
+// was signed and the signature following it, which is empty when there is
+// none.
+func ExtractSignature(data []byte) ([]byte, string) {
+	start := -1
+	for _, marker := range signatureMarkers {
+		for i := bytes.LastIndex(data, []byte(marker)); i >= 0; i = bytes.LastIndex(data[:i], []byte(marker)) {
+			// A signature starts a line
+			if i == 0 || data[i-1] == '\n' {
+				start = max(start, i)
+				break
+			}
+		}
+	}
+	if start < 0 {
+		return data, ""
+	}
+	return data[:start], string(data[start:])
+}
+
+// Parse decodes the raw contents of a tag object whose target is an object
+// ID of algo.
+func Parse(data []byte, algo *hashalgo.Algorithm) (*Tag, error) {
+	payload, signature := ExtractSignature(data)
+	header, message, found := bytes.Cut(payload, []byte("\n\n"))
+	if !found {
+		header, message = bytes.TrimSuffix(payload, []byte("\n")), nil
+	}
+
+	t := &Tag{Message: string(message), Signature: signature}
+	for _, line := range strings.Split(string(header), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "object":
+			oid, err := object.ParseOID(value, algo)
+			if err != nil {
+				return nil, fmt.Errorf("malformed object line: %w", err)
+			}
+			t.Object = oid
+		case "type":
+			t.ObjectType = value
+		case "tag":
+			t.Name = value
+		case "tagger":
+			ident, err := commit.ParseAuthor(value)
+			if err != nil {
+				return nil, fmt.Errorf("malformed tagger line: %w", err)
+			}
+			t.Tagger = ident
+		}
+	}
+
+	if t.Object.IsZero() {
+		return nil, fmt.Errorf("tag has no object")
+	}
+	switch t.ObjectType {
+	case "blob", "tree", "commit", "tag":
+	default:
+		return nil, fmt.Errorf("tag has invalid type %q", t.ObjectType)
+	}
+	if t.Name == "" {
+		return nil, fmt.Errorf("tag has no name")
+	}
+
+	return t, nil
+}
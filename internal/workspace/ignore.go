@@ -0,0 +1,248 @@
+package workspace
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFile is the name of per-directory exclude files.
+const IgnoreFile = ".gitignore"
+
+// Ignore decides whether workspace paths are excluded by .gitignore files in
+// the workspace and by repository-wide exclude files such as info/exclude.
+// Per-directory files are read lazily the first time a path beneath them is
+// checked.
+type Ignore struct {
+	root   string
+	global []*patternList
+	perDir map[string]*patternList
+}
+
+// patternList holds the patterns of one exclude file, relative to base.
+type patternList struct {
+	base     string
+	patterns []*ignorePattern
+}
+
+// ignorePattern is a single compiled exclude line.
+type ignorePattern struct {
+	regexp   *regexp.Regexp
+	negate   bool
+	dirOnly  bool
+	basename bool
+}
+
+// NewIgnore creates an Ignore for the workspace rooted at root.
+func NewIgnore(root string) *Ignore {
+	return &Ignore{
+		root:   root,
+		perDir: make(map[string]*patternList),
+	}
+}
+
+// AddExcludeFile loads a repository-wide exclude file whose patterns apply
+// relative to the workspace root. Files added later take precedence. A
+// missing file is ignored.
+func (ig *Ignore) AddExcludeFile(pathname string) error {
+	list, err := readPatternList(pathname, "")
+	if err != nil || list == nil {
+		return err
+	}
+	ig.global = append(ig.global, list)
+	return nil
+}
+
+// IsIgnored reports whether the slash-separated path relative to the
+// workspace root is excluded. A path inside an excluded directory is always
+// excluded, as git does not look inside such directories.
+func (ig *Ignore) IsIgnored(pathname string, isDir bool) (bool, error) {
+	for dir := path.Dir(pathname); dir != "."; dir = path.Dir(dir) {
+		excluded, err := ig.matches(dir, true)
+		if err != nil || excluded {
+			return excluded, err
+		}
+	}
+	return ig.matches(pathname, isDir)
+}
+
+// matches checks pathname against exclude files from the deepest directory
+// up to the root, then the repository-wide files; the first file with a
+// matching pattern decides.
+func (ig *Ignore) matches(pathname string, isDir bool) (bool, error) {
+	var lists []*patternList
+
+	for dir := path.Dir(pathname); ; dir = path.Dir(dir) {
+		list, err := ig.dirPatterns(dir)
+		if err != nil {
+			return false, err
+		}
+		if list != nil {
+			lists = append(lists, list)
+		}
+		if dir == "." {
+			break
+		}
+	}
+	for i := len(ig.global) - 1; i >= 0; i-- {
+		lists = append(lists, ig.global[i])
+	}
+
+	for _, list := range lists {
+		if excluded, ok := list.match(pathname, isDir); ok {
+			return excluded, nil
+		}
+	}
+	return false, nil
+}
+
+func (ig *Ignore) dirPatterns(dir string) (*patternList, error) {
+	if list, ok := ig.perDir[dir]; ok {
+		return list, nil
+	}
+
+	base := dir
+	if base == "." {
+		base = ""
+	}
+	list, err := readPatternList(filepath.Join(ig.root, filepath.FromSlash(dir), IgnoreFile), base)
+	if err != nil {
+		return nil, err
+	}
+	ig.perDir[dir] = list
+	return list, nil
+}
+
+// match returns the verdict of the last pattern matching pathname, and
+// whether any pattern matched at all.
+func (l *patternList) match(pathname string, isDir bool) (bool, bool) {
+	rel := pathname
+	if l.base != "" {
+		if !strings.HasPrefix(pathname, l.base+"/") {
+			return false, false
+		}
+		rel = pathname[len(l.base)+1:]
+	}
+
+	for i := len(l.patterns) - 1; i >= 0; i-- {
+		p := l.patterns[i]
+		if p.dirOnly && !isDir {
+			continue
+		}
+		subject := rel
+		if p.basename {
+			subject = path.Base(rel)
+		}
+		if p.regexp.MatchString(subject) {
+			return !p.negate, true
+		}
+	}
+	return false, false
+}
+
+func readPatternList(pathname string, base string) (*patternList, error) {
+	f, err := os.Open(pathname)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, os.ErrPermission) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	list := &patternList{base: base}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if p := parseIgnorePattern(scanner.Text()); p != nil {
+			list.patterns = append(list.patterns, p)
+		}
+	}
+	return list, scanner.Err()
+}
+
+// parseIgnorePattern compiles one line of an exclude file, returning nil for
+// blank lines and comments.
+func parseIgnorePattern(line string) *ignorePattern {
+	line = strings.TrimSuffix(line, "\r")
+
+	// Trailing spaces are dropped unless escaped with a backslash.
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	p := &ignorePattern{}
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil
+	}
+
+	// Patterns without a slash match the name at any depth; otherwise they
+	// are anchored to the directory of the exclude file.
+	p.basename = !strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	re, err := regexp.Compile("^" + globToRegexp(line) + "$")
+	if err != nil {
+		return nil
+	}
+	p.regexp = re
+	return p
+}
+
+// globToRegexp translates a gitignore glob into a regular expression where
+// "*" and "?" stop at slashes and "**" spans directories.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(glob); i++ {
+		ch := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/") && (i == 0 || glob[i-1] == '/'):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**") && i+2 == len(glob) && (i == 0 || glob[i-1] == '/'):
+			b.WriteString(".*")
+			i++
+		case ch == '*':
+			b.WriteString("[^/]*")
+		case ch == '?':
+			b.WriteString("[^/]")
+		case ch == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case ch == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+
+	return b.String()
+}
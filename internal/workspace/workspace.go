@@ -1,8 +1,13 @@
 package workspace
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
+
+	"github.com/shanmugharajk/gogit/internal/file"
 )
 
 // ignoredEntries are the entries that should not be included when listing files.
@@ -30,6 +35,20 @@ func (w *Workspace) ListFiles() ([]string, error) {
 	return w.listFilesRecursive(w.pathname)
 }
 
+// ListFilesUnder returns path itself when it is a file, or every file beneath
+// it when it is a directory, relative to the workspace root.
+func (w *Workspace) ListFilesUnder(path string) ([]string, error) {
+	fullPath := filepath.Join(w.pathname, path)
+	stat, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	if !stat.IsDir() {
+		return []string{filepath.Clean(path)}, nil
+	}
+	return w.listFilesRecursive(fullPath)
+}
+
 // listFilesRecursive is a helper function that recursively lists files in a directory.
 func (w *Workspace) listFilesRecursive(dir string) ([]string, error) {
 	entries, err := os.ReadDir(dir)
@@ -71,8 +90,102 @@ func (w *Workspace) ReadFile(path string) ([]byte, error) {
 	return os.ReadFile(fullPath)
 }
 
+// OpenFile opens the file at path relative to the workspace root for
+// streaming and returns it together with its size.
+func (w *Workspace) OpenFile(path string) (*os.File, int64, error) {
+	f, err := os.Open(filepath.Join(w.pathname, path))
+	if err != nil {
+		return nil, 0, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, stat.Size(), nil
+}
+
 // StatFile returns file information for a file at the specified path relative to the workspace root.
 func (w *Workspace) StatFile(path string) (os.FileInfo, error) {
 	fullPath := filepath.Join(w.pathname, path)
 	return os.Stat(fullPath)
 }
+
+// WriteFile replaces the file at path with data, creating parent directories
+// as needed. Anything in the way of the file, such as a directory, is removed.
+func (w *Workspace) WriteFile(path string, data []byte, executable bool) error {
+	return w.WriteStream(path, bytes.NewReader(data), executable)
+}
+
+// WriteStream is like WriteFile but copies the contents from r, so large
+// files need not be held in memory.
+func (w *Workspace) WriteStream(path string, r io.Reader, executable bool) error {
+	fullPath := filepath.Join(w.pathname, path)
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), file.ModeDir); err != nil {
+		return err
+	}
+	if stat, err := os.Lstat(fullPath); err == nil && stat.IsDir() {
+		if err := os.RemoveAll(fullPath); err != nil {
+			return err
+		}
+	}
+
+	mode := file.ModeFile
+	if executable {
+		mode = file.ModeExecutable
+	}
+	f, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	// OpenFile only applies the mode when creating the file.
+	return os.Chmod(fullPath, mode)
+}
+
+// RemoveFile deletes the file at path and any parent directories it leaves
+// empty. A file that is already gone is not an error.
+func (w *Workspace) RemoveFile(path string) error {
+	fullPath := filepath.Join(w.pathname, path)
+	if err := os.Remove(fullPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	for dir := filepath.Dir(fullPath); dir != w.pathname && len(dir) > len(w.pathname); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// Rename moves the file or directory at from to to, both relative to the
+// workspace root.
+func (w *Workspace) Rename(from string, to string) error {
+	return os.Rename(filepath.Join(w.pathname, from), filepath.Join(w.pathname, to))
+}
+
+// ListDir returns the entries of the directory at path relative to the
+// workspace root, sorted by name, leaving out the .git directory.
+func (w *Workspace) ListDir(path string) ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(filepath.Join(w.pathname, path))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []os.DirEntry
+	for _, entry := range entries {
+		if !ignoredEntries[entry.Name()] {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
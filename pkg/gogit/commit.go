@@ -0,0 +1,161 @@
+package gogit
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/shanmugharajk/gogit/internal/commit"
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+// CommitOptions describes a commit to create.
+type CommitOptions struct {
+	// Message is the commit message, stored as given.
+	Message string
+
+	// Author defaults to GIT_AUTHOR_NAME and GIT_AUTHOR_EMAIL at the
+	// current time.
+	Author *Signature
+	// Committer defaults to GIT_COMMITTER_NAME and GIT_COMMITTER_EMAIL,
+	// falling back to the author.
+	Committer *Signature
+
+	// Tree is the tree to commit. When zero, the index is written as a tree.
+	Tree ObjectID
+	// Parents are the commit's parents. When nil, HEAD is the parent unless
+	// the current branch has no commits yet.
+	Parents []ObjectID
+
+	// Signer, when set, signs the commit.
+	Signer Signer
+
+	// Amend is the commit being replaced. Its parents and author are used
+	// unless others are given, and the reflog records an amend.
+	Amend *Commit
+
+	// ReflogMessage is recorded in the reflog of HEAD and the current
+	// branch. It defaults to the one git commit writes.
+	ReflogMessage string
+}
+
+// WriteTree stores the index as a tree, with a subtree for each directory,
+// and returns the OID of the root tree.
+func (r *Repository) WriteTree() (ObjectID, error) {
+	idx, err := r.ReadIndex()
+	if err != nil {
+		return ObjectID{}, err
+	}
+
+	entries := make([]*object.Entry, 0, len(idx.Entries()))
+	for _, entry := range idx.Entries() {
+		entries = append(entries, object.NewEntryWithMode(entry.Path, entry.OID, object.FormatMode(entry.Mode)))
+	}
+	return r.storeTree(entries)
+}
+
+// storeTree builds the tree hierarchy of entries and stores every tree in
+// it, returning the OID of the root.
+func (r *Repository) storeTree(entries []*object.Entry) (ObjectID, error) {
+	root := object.Build(entries)
+
+	var storeErr error
+	root.Traverse(func(tree *object.Tree) {
+		if storeErr != nil {
+			return
+		}
+		if err := r.objects.Store(tree); err != nil {
+			storeErr = fmt.Errorf("failed to store tree: %w", err)
+		}
+	})
+	if storeErr != nil {
+		return ObjectID{}, storeErr
+	}
+	return root.GetOID(), nil
+}
+
+// CreateCommit stores a new commit and moves HEAD, or the branch it points
+// at, to it.
+func (r *Repository) CreateCommit(opts CommitOptions) (*Commit, error) {
+	treeOID := opts.Tree
+	if treeOID.IsZero() {
+		var err error
+		if treeOID, err = r.WriteTree(); err != nil {
+			return nil, err
+		}
+	}
+
+	parents := opts.Parents
+	if parents == nil && opts.Amend != nil {
+		parents = append([]ObjectID{}, opts.Amend.Parents...)
+	}
+	if parents == nil {
+		head, err := r.Head()
+		if err != nil {
+			return nil, err
+		}
+		if !head.IsZero() {
+			parents = []ObjectID{head}
+		}
+	}
+
+	now := time.Now()
+	author := opts.Author
+	if author == nil && opts.Amend != nil {
+		author = opts.Amend.Author
+	}
+	if author == nil {
+		author = commit.NewAuthor(os.Getenv("GIT_AUTHOR_NAME"), os.Getenv("GIT_AUTHOR_EMAIL"), now)
+	}
+	committer := opts.Committer
+	if committer == nil {
+		committer = author
+		if opts.Amend != nil {
+			// The reused author is not who is committing now
+			committer = commit.NewAuthor(os.Getenv("GIT_AUTHOR_NAME"), os.Getenv("GIT_AUTHOR_EMAIL"), now)
+		}
+		if name, email := os.Getenv("GIT_COMMITTER_NAME"), os.Getenv("GIT_COMMITTER_EMAIL"); name != "" || email != "" {
+			committer = commit.NewAuthor(name, email, now)
+		}
+	}
+
+	c := &commit.Commit{
+		Parents:   parents,
+		TreeOID:   treeOID,
+		Author:    author,
+		Committer: committer,
+		Message:   opts.Message,
+	}
+	if opts.Signer != nil {
+		signature, err := opts.Signer.Sign(c.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign commit: %w", err)
+		}
+		c.Signature, c.SignatureHeader = signature, commit.SignatureHeaderFor(r.algo)
+	}
+	if err := r.objects.Store(c); err != nil {
+		return nil, fmt.Errorf("failed to store commit: %w", err)
+	}
+
+	// Make the new objects durable before HEAD refers to them
+	if err := r.objects.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to flush objects: %w", err)
+	}
+
+	message := opts.ReflogMessage
+	if message == "" {
+		message = "commit: " + c.Title()
+		if opts.Amend != nil {
+			message = "commit (amend): " + c.Title()
+		} else if len(parents) == 0 {
+			message = "commit (initial): " + c.Title()
+		} else if len(parents) > 1 {
+			message = "commit (merge): " + c.Title()
+		}
+	}
+	if err := r.refs.UpdateHead(c.GetOID(), message); err != nil {
+		return nil, fmt.Errorf("failed to update HEAD: %w", err)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,109 @@
+package gogit
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/shanmugharajk/gogit/internal/diff"
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+// FileStat counts the lines a change inserts and deletes. Binary files
+// count none.
+type FileStat struct {
+	Change
+	Insertions int
+	Deletions  int
+	Binary     bool
+}
+
+// DiffTrees compares the files of two trees. A zero OID stands for the
+// empty tree. Changes are sorted by path.
+func (r *Repository) DiffTrees(oldTree ObjectID, newTree ObjectID) ([]Change, error) {
+	oldFiles, err := r.treeFiles(oldTree)
+	if err != nil {
+		return nil, err
+	}
+	newFiles, err := r.treeFiles(newTree)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for name, entry := range newFiles {
+		change := Change{Path: name, NewMode: entry.Mode(), NewOID: entry.OID}
+		if old, ok := oldFiles[name]; !ok {
+			change.Kind = Added
+		} else if old.OID != entry.OID || old.Mode() != entry.Mode() {
+			change.Kind = Modified
+			change.OldMode, change.OldOID = old.Mode(), old.OID
+		} else {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	for name, old := range oldFiles {
+		if _, ok := newFiles[name]; !ok {
+			changes = append(changes, Change{Path: name, Kind: Deleted, OldMode: old.Mode(), OldOID: old.OID})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+// treeFiles returns the files of a tree keyed by path, or none for the
+// zero OID.
+func (r *Repository) treeFiles(oid ObjectID) (map[string]*TreeEntry, error) {
+	if oid.IsZero() {
+		return map[string]*TreeEntry{}, nil
+	}
+	return r.objects.ListTree(oid, "")
+}
+
+// DiffStat is DiffTrees with the lines each change inserts and deletes.
+func (r *Repository) DiffStat(oldTree ObjectID, newTree ObjectID) ([]FileStat, error) {
+	changes, err := r.DiffTrees(oldTree, newTree)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]FileStat, 0, len(changes))
+	for _, change := range changes {
+		stat := FileStat{Change: change}
+		// Submodules are commits in another repository
+		if change.OldMode != object.GitlinkMode && change.NewMode != object.GitlinkMode {
+			oldData, err := r.blobData(change.OldOID)
+			if err != nil {
+				return nil, err
+			}
+			newData, err := r.blobData(change.NewOID)
+			if err != nil {
+				return nil, err
+			}
+			if diff.IsBinary(oldData) || diff.IsBinary(newData) {
+				stat.Binary = true
+			} else {
+				stat.Insertions, stat.Deletions = diff.Count(diff.Lines(oldData), diff.Lines(newData))
+			}
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+// blobData returns the contents of a blob, or nothing for the zero OID.
+func (r *Repository) blobData(oid ObjectID) ([]byte, error) {
+	if oid.IsZero() {
+		return nil, nil
+	}
+	objType, data, err := r.objects.ReadObject(oid)
+	if err != nil {
+		return nil, err
+	}
+	if objType != "blob" {
+		return nil, fmt.Errorf("object %s is a %s, not a blob", oid, objType)
+	}
+	return data, nil
+}
@@ -0,0 +1,184 @@
+package gogit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// gitFilePrefix starts a .git file pointing at a repository elsewhere.
+const gitFilePrefix = "gitdir: "
+
+// DiscoverOptions changes how DiscoverWithOptions finds a repository, the
+// way the GIT_DIR, GIT_WORK_TREE, GIT_CEILING_DIRECTORIES and
+// GIT_DISCOVERY_ACROSS_FILESYSTEM variables do for git.
+type DiscoverOptions struct {
+	// GitDir is the repository to open instead of searching for one. It may
+	// also name a .git file.
+	GitDir string
+	// WorkTree overrides the root of the working tree. Without it, the
+	// working tree is the directory holding the .git found by the search,
+	// or the starting directory when GitDir is given, unless core.bare is
+	// set. A repository found directly, rather than as a .git, has none.
+	WorkTree string
+
+	// CeilingDirectories are absolute paths the search does not move up
+	// into.
+	CeilingDirectories []string
+	// AcrossFilesystems lets the search continue into parent directories
+	// on other filesystems.
+	AcrossFilesystems bool
+}
+
+// Discover opens the repository containing path, looking in path and then
+// each of its parents for a .git directory or file, or for a repository
+// itself.
+func Discover(path string) (*Repository, error) {
+	return DiscoverWithOptions(path, nil)
+}
+
+// DiscoverWithOptions is like Discover but lets opts name the repository or
+// limit the search. Relative paths in opts are taken from path.
+func DiscoverWithOptions(path string, opts *DiscoverOptions) (*Repository, error) {
+	if opts == nil {
+		opts = &DiscoverOptions{}
+	}
+	start, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// An explicit working tree applies whatever core.bare says.
+	open := openImplicit
+	if opts.WorkTree != "" {
+		workTree := absFrom(start, opts.WorkTree)
+		open = func(gitDir string, _ string) (*Repository, error) {
+			return OpenGitDir(gitDir, workTree)
+		}
+	}
+
+	if opts.GitDir != "" {
+		gitDir, err := resolveGitDir(absFrom(start, opts.GitDir))
+		if err != nil {
+			return nil, err
+		}
+		if gitDir == "" {
+			return nil, fmt.Errorf("%w: '%s'", ErrRepositoryNotFound, opts.GitDir)
+		}
+		return open(gitDir, start)
+	}
+
+	ceilings := make(map[string]bool)
+	for _, ceiling := range opts.CeilingDirectories {
+		if filepath.IsAbs(ceiling) {
+			ceilings[filepath.Clean(ceiling)] = true
+		}
+	}
+
+	dir := start
+	dev, devOK := deviceOf(dir)
+	for {
+		gitDir, err := resolveGitDir(filepath.Join(dir, GitDirName))
+		if err != nil {
+			return nil, err
+		}
+		if gitDir != "" {
+			return open(gitDir, dir)
+		}
+		if isGitDir(dir) {
+			return open(dir, "")
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir || ceilings[parent] {
+			return nil, fmt.Errorf("%w (or any of the parent directories): %s", ErrRepositoryNotFound, GitDirName)
+		}
+		if !opts.AcrossFilesystems && devOK {
+			if parentDev, ok := deviceOf(parent); ok && parentDev != dev {
+				return nil, fmt.Errorf("%w (or any parent up to mount point %s)\n"+
+					"Stopping at filesystem boundary (GIT_DISCOVERY_ACROSS_FILESYSTEM not set).", ErrRepositoryNotFound, dir)
+			}
+		}
+		dir = parent
+	}
+}
+
+// resolveGitDir returns the repository at path, following it when it is a
+// .git file, or "" when there is none.
+func resolveGitDir(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", nil
+	}
+	if info.IsDir() {
+		if isGitDir(path) {
+			return path, nil
+		}
+		return "", nil
+	}
+
+	gitDir, err := readGitFile(path)
+	if err != nil {
+		return "", err
+	}
+	if !isGitDir(gitDir) {
+		return "", fmt.Errorf("%w: %s", ErrRepositoryNotFound, gitDir)
+	}
+	return gitDir, nil
+}
+
+// readGitFile returns the repository a .git file points at. Relative paths
+// are taken from the directory holding the file.
+func readGitFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	target, ok := strings.CutPrefix(string(bytes.TrimRight(data, "\r\n")), gitFilePrefix)
+	if !ok || target == "" {
+		return "", fmt.Errorf("invalid gitfile format: %s", path)
+	}
+	return absFrom(filepath.Dir(path), target), nil
+}
+
+// absFrom returns path made absolute relative to dir.
+func absFrom(dir string, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(dir, path)
+}
+
+// deviceOf returns the device holding path.
+func deviceOf(path string) (uint64, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Dev), true
+}
+
+// WorkTreePath converts path, absolute or relative to the current
+// directory, into a slash-separated path relative to the root of the
+// working tree. The root itself is ".".
+func (r *Repository) WorkTreePath(path string) (string, error) {
+	if r.workTree == "" {
+		return "", ErrNoWorkTree
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(r.workTree, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("'%s' is outside repository at '%s'", path, r.workTree)
+	}
+	return filepath.ToSlash(rel), nil
+}
@@ -0,0 +1,235 @@
+package gogit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/object"
+	"github.com/shanmugharajk/gogit/internal/refs"
+)
+
+// The hooks gogit runs, named as in the hooks directory.
+const (
+	// HookPreCommit runs before a commit is made and can stop it.
+	HookPreCommit = "pre-commit"
+	// HookPrepareCommitMsg can edit the message file before it is used,
+	// given the file and the source of the message.
+	HookPrepareCommitMsg = "prepare-commit-msg"
+	// HookCommitMsg checks the message file and can stop the commit.
+	HookCommitMsg = "commit-msg"
+	// HookPostCommit runs after a commit is made.
+	HookPostCommit = "post-commit"
+	// HookPostCheckout runs after the working tree is updated, given the
+	// previous and new HEAD and whether branches were checked out.
+	HookPostCheckout = "post-checkout"
+	// HookReferenceTransaction runs for every ref update, given its state
+	// and reading the updates from standard input.
+	HookReferenceTransaction = "reference-transaction"
+)
+
+// hooksPathConfigKey names a directory to find hooks in instead of hooks/
+// in the repository.
+const hooksPathConfigKey = "core.hooksPath"
+
+// HookOptions is how a hook is run.
+type HookOptions struct {
+	// Args are passed to the hook after its name.
+	Args []string
+	// Stdin is fed to the hook. The hook reads nothing when it is nil.
+	Stdin io.Reader
+	// Env holds variables set for the hook on top of the environment and
+	// GIT_DIR.
+	Env []string
+}
+
+// HookError reports a hook that ran but failed.
+type HookError struct {
+	Name string
+	Err  error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("%s hook failed: %v", e.Name, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// HooksDir returns the directory hooks are found in: core.hooksPath when
+// set, otherwise hooks/ in the repository. It is "" for repositories in
+// memory, which have no hooks.
+func (r *Repository) HooksDir() string {
+	if r.gitDir == "" {
+		return ""
+	}
+	dir, ok := r.config.Get(hooksPathConfigKey)
+	if !ok || dir == "" {
+		return filepath.Join(r.gitDir, "hooks")
+	}
+	if rest, ok := strings.CutPrefix(dir, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, rest)
+		}
+	}
+	return absFrom(r.hookDir(), dir)
+}
+
+// hookDir returns the directory hooks run in: the root of the working tree,
+// or the repository itself when there is none.
+func (r *Repository) hookDir() string {
+	if r.workTree != "" {
+		return r.workTree
+	}
+	return r.gitDir
+}
+
+// HookPath returns the executable for the hook name, or false when there is
+// none. Files without an executable bit are not hooks.
+func (r *Repository) HookPath(name string) (string, bool) {
+	dir := r.HooksDir()
+	if dir == "" {
+		return "", false
+	}
+	path := filepath.Join(dir, name)
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Mode()&0o111 == 0 {
+		return "", false
+	}
+	return path, true
+}
+
+// RunHook runs the hook name, if there is one, from the root of the working
+// tree with GIT_DIR set. Its output goes to standard error. A hook that
+// exits non-zero yields a *HookError.
+func (r *Repository) RunHook(name string, opts *HookOptions) error {
+	path, ok := r.HookPath(name)
+	if !ok {
+		return nil
+	}
+	if opts == nil {
+		opts = &HookOptions{}
+	}
+
+	cmd := exec.Command(path, opts.Args...)
+	cmd.Dir = r.hookDir()
+	cmd.Env = append(os.Environ(), "GIT_DIR="+r.gitDir)
+	cmd.Env = append(cmd.Env, opts.Env...)
+	cmd.Stdin = opts.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return &HookError{Name: name, Err: err}
+		}
+		return fmt.Errorf("failed to run %s hook: %w", name, err)
+	}
+	return nil
+}
+
+// refUpdate is one change reported to the reference-transaction hook. A
+// zero side means the ref did not or will not exist.
+type refUpdate struct {
+	name   string
+	oldOID ObjectID
+	newOID ObjectID
+}
+
+// hookRefs runs the reference-transaction hook around every update made
+// through the RefStore it wraps: "prepared" before the update, which the
+// hook can refuse, then "committed" or "aborted".
+type hookRefs struct {
+	refs.Refs
+	repo *Repository
+}
+
+var _ refs.Refs = (*hookRefs)(nil)
+
+func (h *hookRefs) UpdateHead(oid ObjectID, message string) error {
+	oldOID, err := h.Refs.ReadHead()
+	if err != nil {
+		return err
+	}
+	target, err := h.Refs.ReadSymref(refs.HeadRef)
+	if err != nil {
+		return err
+	}
+
+	updates := []refUpdate{{name: refs.HeadRef, oldOID: oldOID, newOID: oid}}
+	if target != refs.HeadRef {
+		updates = append(updates, refUpdate{name: target, oldOID: oldOID, newOID: oid})
+	}
+	return h.transaction(updates, func() error {
+		return h.Refs.UpdateHead(oid, message)
+	})
+}
+
+func (h *hookRefs) UpdateRef(name string, oid ObjectID, message string) error {
+	oldOID, err := h.Refs.ReadRef(name)
+	if err != nil {
+		return err
+	}
+	return h.transaction([]refUpdate{{name: name, oldOID: oldOID, newOID: oid}}, func() error {
+		return h.Refs.UpdateRef(name, oid, message)
+	})
+}
+
+func (h *hookRefs) DeleteRef(name string) error {
+	oldOID, err := h.Refs.ReadRef(name)
+	if err != nil {
+		return err
+	}
+	return h.transaction([]refUpdate{{name: name, oldOID: oldOID}}, func() error {
+		return h.Refs.DeleteRef(name)
+	})
+}
+
+// transaction applies an update between the hook's phases. Without a hook
+// the update is simply applied.
+func (h *hookRefs) transaction(updates []refUpdate, apply func() error) error {
+	if _, ok := h.repo.HookPath(HookReferenceTransaction); !ok {
+		return apply()
+	}
+
+	null := object.NullOID(h.repo.algo)
+	var input strings.Builder
+	for _, u := range updates {
+		oldOID, newOID := u.oldOID, u.newOID
+		if oldOID.IsZero() {
+			oldOID = null
+		}
+		if newOID.IsZero() {
+			newOID = null
+		}
+		fmt.Fprintf(&input, "%s %s %s\n", oldOID, newOID, u.name)
+	}
+	run := func(state string) error {
+		return h.repo.RunHook(HookReferenceTransaction, &HookOptions{
+			Args:  []string{state},
+			Stdin: strings.NewReader(input.String()),
+		})
+	}
+
+	if err := run("prepared"); err != nil {
+		run("aborted")
+		var hookErr *HookError
+		if errors.As(err, &hookErr) {
+			return fmt.Errorf("in 'prepared' phase, update aborted by the reference-transaction hook")
+		}
+		return err
+	}
+	if err := apply(); err != nil {
+		run("aborted")
+		return err
+	}
+	// As with git, the outcome of the "committed" phase changes nothing.
+	run("committed")
+	return nil
+}
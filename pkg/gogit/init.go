@@ -0,0 +1,450 @@
+package gogit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/shanmugharajk/gogit/internal/config"
+	"github.com/shanmugharajk/gogit/internal/file"
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/index"
+	"github.com/shanmugharajk/gogit/internal/refs"
+	"github.com/shanmugharajk/gogit/internal/storage"
+)
+
+// DefaultBranch is the branch HEAD points at in a new repository unless
+// InitOptions names another.
+const DefaultBranch = "master"
+
+// defaultFiles are written into a new repository when its template does not
+// provide them.
+var defaultFiles = map[string]string{
+	"description": "Unnamed repository; edit this file 'description' to name the repository.\n",
+	filepath.Join("info", "exclude"): `# git ls-files --others --exclude-from=.git/info/exclude
+# Lines that start with '#' are comments.
+# For a project mostly in C, the following would be a good set of
+# exclude patterns (uncomment them if you want to use them):
+# *.[oa]
+# *~
+`,
+}
+
+// InitOptions controls how Init and InitMemory create a repository. The
+// zero value creates a SHA-1 repository storing refs as files, with HEAD on
+// DefaultBranch.
+type InitOptions struct {
+	// ObjectFormat is "sha1" or "sha256".
+	ObjectFormat string
+	// RefFormat is RefsFormatFiles or RefsFormatReftable. It does not apply
+	// to repositories in memory.
+	RefFormat string
+	// Bare creates the repository directly in the given path, without a
+	// working tree.
+	Bare bool
+
+	// InitialBranch is the branch HEAD points at in a new repository.
+	InitialBranch string
+	// TemplateDir is a directory whose contents are copied into the
+	// repository. Files already present are never overwritten.
+	TemplateDir string
+	// SeparateGitDir places the repository there and leaves a .git file
+	// pointing at it in the working tree. An existing repository is moved.
+	SeparateGitDir string
+}
+
+// algorithm returns the hash algorithm the options select.
+func (opts *InitOptions) algorithm() (*hashalgo.Algorithm, error) {
+	if opts == nil || opts.ObjectFormat == "" {
+		return hashalgo.SHA1, nil
+	}
+	return hashalgo.Lookup(opts.ObjectFormat)
+}
+
+// refFormat returns the ref storage format the options select.
+func (opts *InitOptions) refFormat() (string, error) {
+	if opts == nil || opts.RefFormat == "" {
+		return refs.StorageFiles, nil
+	}
+	if opts.RefFormat != refs.StorageFiles && opts.RefFormat != refs.StorageReftable {
+		return "", fmt.Errorf("unknown ref storage format '%s'", opts.RefFormat)
+	}
+	return opts.RefFormat, nil
+}
+
+// initialBranch returns the branch the options select for HEAD.
+func (opts *InitOptions) initialBranch() (string, error) {
+	if opts == nil || opts.InitialBranch == "" {
+		return DefaultBranch, nil
+	}
+	if !refs.ValidShortName(refs.HeadsPrefix, opts.InitialBranch) {
+		return "", fmt.Errorf("invalid initial branch name: '%s'", opts.InitialBranch)
+	}
+	return opts.InitialBranch, nil
+}
+
+// Init creates a repository in path/.git, or directly in path when it is
+// bare, and opens it. An existing repository there is reinitialized: files
+// missing from it are added, but nothing in it is overwritten, and asking
+// for a different object or ref format is an error.
+func Init(path string, opts *InitOptions) (*Repository, error) {
+	if opts == nil {
+		opts = &InitOptions{}
+	}
+	algo, err := opts.algorithm()
+	if err != nil {
+		return nil, err
+	}
+	refFormat, err := opts.refFormat()
+	if err != nil {
+		return nil, err
+	}
+	branch, err := opts.initialBranch()
+	if err != nil {
+		return nil, err
+	}
+
+	workTree, err := filepath.Abs(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	gitDir := filepath.Join(workTree, GitDirName)
+	switch {
+	case opts.Bare && opts.SeparateGitDir != "":
+		return nil, fmt.Errorf("--separate-git-dir incompatible with bare repository")
+	case opts.Bare:
+		gitDir, workTree = workTree, ""
+	case opts.SeparateGitDir != "":
+		separate, err := filepath.Abs(opts.SeparateGitDir)
+		if err != nil {
+			return nil, err
+		}
+		if err := linkGitDir(gitDir, separate); err != nil {
+			return nil, err
+		}
+		gitDir = separate
+	default:
+		// A .git file points at the repository to reinitialize.
+		existing, err := resolveGitDir(gitDir)
+		if err != nil {
+			return nil, err
+		}
+		if existing != "" {
+			gitDir = existing
+		}
+	}
+
+	reinit := isGitDir(gitDir)
+	if reinit {
+		if algo, refFormat, err = checkReinit(gitDir, opts, algo, refFormat); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(gitDir, file.ModeDir); err != nil {
+		return nil, err
+	}
+	if opts.TemplateDir != "" {
+		if err := copyTemplate(opts.TemplateDir, gitDir); err != nil {
+			return nil, fmt.Errorf("failed to copy templates: %w", err)
+		}
+	}
+
+	dirs := []string{
+		filepath.Join("objects", "info"),
+		filepath.Join("objects", "pack"),
+		refs.RefsDir,
+		"hooks",
+		"info",
+	}
+	if refFormat == refs.StorageFiles {
+		dirs = append(dirs, filepath.Join(refs.RefsDir, "heads"), filepath.Join(refs.RefsDir, "tags"))
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(gitDir, dir), file.ModeDir); err != nil {
+			return nil, err
+		}
+	}
+	for name, content := range defaultFiles {
+		if err := writeIfMissing(filepath.Join(gitDir, name), content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := initConfig(gitDir, refFormat, algo, opts.Bare); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case refFormat == refs.StorageReftable && !reinit:
+		if err := initReftable(gitDir, algo, branch); err != nil {
+			return nil, err
+		}
+	case refFormat == refs.StorageFiles:
+		if _, err := os.Lstat(filepath.Join(gitDir, refs.HeadRef)); errors.Is(err, fs.ErrNotExist) {
+			if err := refs.NewFileRefs(gitDir, algo).SetSymref(refs.HeadRef, refs.HeadsPrefix+branch); err != nil {
+				return nil, fmt.Errorf("failed to write HEAD: %w", err)
+			}
+		}
+	}
+
+	r, err := OpenGitDir(gitDir, workTree)
+	if err != nil {
+		return nil, err
+	}
+	r.reinitialized = reinit
+	return r, nil
+}
+
+// Reinitialized reports whether Init found the repository already in place.
+func (r *Repository) Reinitialized() bool {
+	return r.reinitialized
+}
+
+// linkGitDir points the .git file at gitFile to the repository at target,
+// first moving the repository gitFile already leads to, if any, there.
+func linkGitDir(gitFile string, target string) error {
+	existing, err := resolveGitDir(gitFile)
+	if err != nil {
+		return err
+	}
+	if existing != "" && existing != target {
+		if _, err := os.Lstat(target); err == nil {
+			return fmt.Errorf("cannot move %s to %s: destination exists", existing, target)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), file.ModeDir); err != nil {
+			return err
+		}
+		if err := os.Rename(existing, target); err != nil {
+			return fmt.Errorf("unable to move %s to %s: %w", existing, target, err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(gitFile), file.ModeDir); err != nil {
+		return err
+	}
+	return os.WriteFile(gitFile, []byte(gitFilePrefix+target+"\n"), file.ModeFile)
+}
+
+// checkReinit returns the formats of the existing repository at gitDir,
+// refusing options that ask for different ones.
+func checkReinit(gitDir string, opts *InitOptions, algo *hashalgo.Algorithm, refFormat string) (*hashalgo.Algorithm, string, error) {
+	cfg, err := config.Open(filepath.Join(gitDir, "config"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	existingAlgo, err := hashalgo.FromConfig(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	if opts.ObjectFormat != "" && existingAlgo != algo {
+		return nil, "", fmt.Errorf("attempt to reinitialize repository with different hash")
+	}
+
+	existingRefFormat := refs.StorageFiles
+	if value, ok := cfg.Get("extensions.refStorage"); ok {
+		existingRefFormat = value
+	}
+	if opts.RefFormat != "" && existingRefFormat != refFormat {
+		return nil, "", fmt.Errorf("attempt to reinitialize repository with different reference storage format")
+	}
+
+	return existingAlgo, existingRefFormat, nil
+}
+
+// copyTemplate copies the files and directories under templateDir into
+// gitDir, leaving files that already exist alone. A missing template
+// directory is not an error.
+func copyTemplate(templateDir string, gitDir string) error {
+	if _, err := os.Stat(templateDir); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return filepath.WalkDir(templateDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(templateDir, path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(gitDir, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(dest, file.ModeDir)
+		}
+		if _, err := os.Lstat(dest); err == nil {
+			return nil
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(target, dest)
+		}
+		return copyFile(path, dest)
+	})
+}
+
+// copyFile copies the regular file src to dst, keeping it executable if it
+// was.
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	mode := file.ModeFile
+	if info.Mode()&0o111 != 0 {
+		mode = file.ModeExecutable
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// writeIfMissing creates the file at path with content unless it exists.
+func writeIfMissing(path string, content string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, file.ModeFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return nil
+		}
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// initConfig writes the core settings git init records, together with the
+// extensions the repository needs beyond the defaults. Either extension
+// requires repository format version 1, so that older tools refuse to
+// operate on the repository rather than misread it. Other settings,
+// including those from a template, are kept.
+func initConfig(gitDir string, refFormat string, algo *hashalgo.Algorithm, bare bool) error {
+	cfg, err := config.Open(filepath.Join(gitDir, "config"))
+	if err != nil {
+		return err
+	}
+
+	version := "0"
+	if algo != hashalgo.SHA1 || refFormat == refs.StorageReftable {
+		version = "1"
+	}
+	cfg.Set("core.repositoryformatversion", version)
+
+	filemode, err := probeFileMode(gitDir)
+	if err != nil {
+		return err
+	}
+	cfg.Set("core.filemode", fmt.Sprint(filemode))
+	cfg.Set(bareConfigKey, fmt.Sprint(bare))
+	if _, ok := cfg.Get("core.logallrefupdates"); !ok && !bare {
+		cfg.Set("core.logallrefupdates", "true")
+	}
+
+	if algo != hashalgo.SHA1 {
+		cfg.Set(hashalgo.ConfigKey, algo.Name)
+	}
+	if refFormat == refs.StorageReftable {
+		cfg.Set("extensions.refStorage", refs.StorageReftable)
+	}
+
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// probeFileMode reports whether the filesystem holding dir keeps the
+// executable bit, by toggling it on a scratch file.
+func probeFileMode(dir string) (bool, error) {
+	f, err := os.CreateTemp(dir, "filemode-")
+	if err != nil {
+		return false, err
+	}
+	name := f.Name()
+	f.Close()
+	defer os.Remove(name)
+
+	if err := os.Chmod(name, file.ModeExecutable); err != nil {
+		return false, nil
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		return false, err
+	}
+	return info.Mode()&0o100 != 0, nil
+}
+
+// initReftable sets up the reftable stack, with HEAD on branch, and the
+// placeholder files git uses so that tools expecting the files layout fail
+// loudly instead of misreading.
+func initReftable(gitDir string, algo *hashalgo.Algorithm, branch string) error {
+	if err := os.MkdirAll(filepath.Join(gitDir, refs.ReftableDir), file.ModeDir); err != nil {
+		return err
+	}
+	placeholders := map[string]string{
+		filepath.Join(refs.ReftableDir, refs.TablesListFile): "",
+		refs.HeadRef:                         "ref: refs/heads/.invalid\n",
+		filepath.Join(refs.RefsDir, "heads"): "this repository uses the reftable format\n",
+	}
+	for name, content := range placeholders {
+		if err := os.WriteFile(filepath.Join(gitDir, name), []byte(content), file.ModeFile); err != nil {
+			return err
+		}
+	}
+
+	return refs.NewReftableRefs(gitDir, algo).SetSymref(refs.HeadRef, refs.HeadsPrefix+branch)
+}
+
+// InitMemory creates an empty repository held entirely in memory. It has
+// no working tree, and its config starts out empty and cannot be saved.
+func InitMemory(opts *InitOptions) (*Repository, error) {
+	algo, err := opts.algorithm()
+	if err != nil {
+		return nil, err
+	}
+	branch, err := opts.initialBranch()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.Open("")
+	if err != nil {
+		return nil, err
+	}
+
+	refsStore := refs.NewMemoryRefs(algo)
+	if err := refsStore.SetSymref(refs.HeadRef, refs.HeadsPrefix+branch); err != nil {
+		return nil, err
+	}
+
+	return &Repository{
+		algo:    algo,
+		config:  cfg,
+		objects: storage.NewMemory(algo),
+		refs:    refsStore,
+		index:   index.NewMemoryStore(),
+	}, nil
+}
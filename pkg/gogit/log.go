@@ -0,0 +1,73 @@
+package gogit
+
+import "container/heap"
+
+// History returns the commits reachable from start, newest committer date
+// first as git log lists them, stopping after max commits unless max is
+// negative.
+func (r *Repository) History(start ObjectID, max int) ([]*Commit, error) {
+	var history []*Commit
+	queue := &commitQueue{}
+	seen := map[ObjectID]bool{start: true}
+
+	first, err := r.objects.LoadCommit(start)
+	if err != nil {
+		return nil, err
+	}
+	queue.push(first)
+	for queue.Len() > 0 && (max < 0 || len(history) < max) {
+		c := heap.Pop(queue).(*queuedCommit).Commit
+		history = append(history, c)
+
+		for _, parent := range c.Parents {
+			if seen[parent] {
+				continue
+			}
+			seen[parent] = true
+			pc, err := r.objects.LoadCommit(parent)
+			if err != nil {
+				return nil, err
+			}
+			queue.push(pc)
+		}
+	}
+	return history, nil
+}
+
+// commitQueue is a heap of commits ordered newest committer date first,
+// and in the order they were queued when their dates are equal.
+type commitQueue struct {
+	commits []*queuedCommit
+	queued  int
+}
+
+type queuedCommit struct {
+	*Commit
+	seq int
+}
+
+func (q *commitQueue) push(c *Commit) {
+	heap.Push(q, &queuedCommit{Commit: c, seq: q.queued})
+	q.queued++
+}
+
+func (q *commitQueue) Len() int { return len(q.commits) }
+
+func (q *commitQueue) Less(i, j int) bool {
+	a, b := q.commits[i], q.commits[j]
+	if !a.Committer.Time.Equal(b.Committer.Time) {
+		return a.Committer.Time.After(b.Committer.Time)
+	}
+	return a.seq < b.seq
+}
+
+func (q *commitQueue) Swap(i, j int) { q.commits[i], q.commits[j] = q.commits[j], q.commits[i] }
+
+func (q *commitQueue) Push(x any) { q.commits = append(q.commits, x.(*queuedCommit)) }
+
+func (q *commitQueue) Pop() any {
+	n := len(q.commits)
+	c := q.commits[n-1]
+	q.commits = q.commits[:n-1]
+	return c
+}
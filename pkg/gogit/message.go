@@ -0,0 +1,121 @@
+package gogit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CleanupMode is how a commit message is tidied before it is recorded, as
+// chosen by git commit --cleanup.
+type CleanupMode int
+
+const (
+	// CleanupDefault strips the message when it was edited and only
+	// tidies its whitespace otherwise.
+	CleanupDefault CleanupMode = iota
+	// CleanupStrip removes comment lines as well as surplus whitespace.
+	CleanupStrip
+	// CleanupWhitespace removes trailing whitespace, repeated blank lines
+	// and blank lines at either end, keeping comment lines.
+	CleanupWhitespace
+	// CleanupVerbatim leaves the message as it is.
+	CleanupVerbatim
+	// CleanupScissors is CleanupWhitespace, but an edited message is cut
+	// off at the scissors line.
+	CleanupScissors
+)
+
+// CommentChar starts the lines of a message that CleanupStrip removes.
+const CommentChar = '#'
+
+// ScissorsLine marks where CleanupScissors cuts an edited message.
+const ScissorsLine = "# ------------------------ >8 ------------------------"
+
+// ParseCleanupMode parses a --cleanup or commit.cleanup value.
+func ParseCleanupMode(value string) (CleanupMode, error) {
+	switch value {
+	case "default":
+		return CleanupDefault, nil
+	case "strip":
+		return CleanupStrip, nil
+	case "whitespace":
+		return CleanupWhitespace, nil
+	case "verbatim":
+		return CleanupVerbatim, nil
+	case "scissors":
+		return CleanupScissors, nil
+	default:
+		return 0, fmt.Errorf("invalid cleanup mode %s", value)
+	}
+}
+
+// Resolve returns the mode used for a message that was or was not edited:
+// CleanupDefault becomes CleanupStrip or CleanupWhitespace.
+func (m CleanupMode) Resolve(edited bool) CleanupMode {
+	if m != CleanupDefault {
+		return m
+	}
+	if edited {
+		return CleanupStrip
+	}
+	return CleanupWhitespace
+}
+
+// CleanupMessage tidies message according to mode. edited says whether the
+// message went through an editor, which decides what CleanupDefault does
+// and whether CleanupScissors cuts the message.
+func CleanupMessage(message string, mode CleanupMode, edited bool) string {
+	switch mode.Resolve(edited) {
+	case CleanupVerbatim:
+		return message
+	case CleanupScissors:
+		if edited {
+			if i := scissorsIndex(message); i >= 0 {
+				message = message[:i]
+			}
+		}
+		return StripSpace(message, false)
+	case CleanupStrip:
+		return StripSpace(message, true)
+	default:
+		return StripSpace(message, false)
+	}
+}
+
+// scissorsIndex returns the offset of the scissors line in message, or -1.
+func scissorsIndex(message string) int {
+	for offset := 0; offset < len(message); {
+		line, _, _ := strings.Cut(message[offset:], "\n")
+		if line == ScissorsLine {
+			return offset
+		}
+		offset += len(line) + 1
+	}
+	return -1
+}
+
+// StripSpace removes trailing whitespace from every line, collapses runs of
+// blank lines into one and drops blank lines at the start and end, ending
+// the result with a newline unless it is empty. With stripComments, lines
+// starting with CommentChar are removed first.
+func StripSpace(message string, stripComments bool) string {
+	var b strings.Builder
+	blank := 0
+	for _, line := range strings.Split(message, "\n") {
+		if stripComments && len(line) > 0 && line[0] == CommentChar {
+			continue
+		}
+		line = strings.TrimRight(line, " \t\r\v\f")
+		if line == "" {
+			blank++
+			continue
+		}
+		if blank > 0 && b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		blank = 0
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
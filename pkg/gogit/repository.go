@@ -0,0 +1,289 @@
+// Package gogit is the public interface to git repositories: opening and
+// creating them, and reading and writing their objects, refs, index and
+// working tree. Repositories live on disk or, for tools and tests that must
+// not touch it, entirely in memory.
+package gogit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shanmugharajk/gogit/internal/config"
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/index"
+	"github.com/shanmugharajk/gogit/internal/refs"
+	"github.com/shanmugharajk/gogit/internal/revision"
+	"github.com/shanmugharajk/gogit/internal/storage"
+	"github.com/shanmugharajk/gogit/internal/workspace"
+)
+
+const (
+	// GitDirName is the directory holding the repository inside a working
+	// tree.
+	GitDirName = ".git"
+
+	// bareConfigKey records that a repository has no working tree.
+	bareConfigKey = "core.bare"
+)
+
+// ErrRepositoryNotFound is returned when no repository exists where one
+// was expected.
+var ErrRepositoryNotFound = errors.New("not a git repository")
+
+// ErrNoWorkTree is returned by operations that need a working tree when the
+// repository has none.
+var ErrNoWorkTree = errors.New("this operation must be run in a work tree")
+
+// ErrNoDatabase is returned by operations on the object database on disk
+// when the repository is in memory.
+var ErrNoDatabase = errors.New("this operation needs an object database on disk")
+
+// Repository is a git repository: its objects, refs and index, and the
+// working tree they describe. It is safe to share between goroutines as
+// long as callers coordinate updates, just as separate git processes must.
+type Repository struct {
+	gitDir   string
+	workTree string
+	algo     *hashalgo.Algorithm
+	config   *config.Config
+
+	objects storage.ObjectStore
+	db      *storage.Database
+	refs    refs.Refs
+	index   index.Store
+
+	// reinitialized is set by Init when the repository already existed.
+	reinitialized bool
+}
+
+// Open opens the repository at path: either a working tree with the
+// repository in path/.git, or a bare repository.
+func Open(path string) (*Repository, error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	gitDir, err := resolveGitDir(filepath.Join(dir, GitDirName))
+	if err != nil {
+		return nil, err
+	}
+	if gitDir != "" {
+		return openImplicit(gitDir, dir)
+	}
+	return openImplicit(dir, "")
+}
+
+// openImplicit opens the repository at gitDir with workTree as its working
+// tree unless core.bare says it has none.
+func openImplicit(gitDir string, workTree string) (*Repository, error) {
+	r, err := OpenGitDir(gitDir, workTree)
+	if err != nil {
+		return nil, err
+	}
+	bare, err := r.config.Bool(bareConfigKey, false)
+	if err != nil {
+		return nil, fmt.Errorf("bad config: %w", err)
+	}
+	if bare {
+		r.workTree = ""
+	}
+	return r, nil
+}
+
+// OpenGitDir opens the repository at gitDir with the given working tree,
+// which is empty when there is none.
+func OpenGitDir(gitDir string, workTree string) (*Repository, error) {
+	if !isGitDir(gitDir) {
+		return nil, fmt.Errorf("%w: %s", ErrRepositoryNotFound, gitDir)
+	}
+
+	cfg, err := config.Open(filepath.Join(gitDir, "config"))
+	if err != nil {
+		return nil, err
+	}
+	algo, err := hashalgo.FromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db := storage.New(filepath.Join(gitDir, "objects"), algo)
+	policy, err := storage.FsyncPolicyFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("bad config: %w", err)
+	}
+	db.SetFsyncPolicy(policy)
+
+	refsStore, err := refs.New(gitDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open refs: %w", err)
+	}
+
+	r := &Repository{
+		gitDir:   gitDir,
+		workTree: workTree,
+		algo:     algo,
+		config:   cfg,
+		objects:  db,
+		db:       db,
+		index:    index.NewFileStore(filepath.Join(gitDir, "index")),
+	}
+	r.refs = &hookRefs{Refs: refsStore, repo: r}
+	return r, nil
+}
+
+// isGitDir reports whether dir looks like a repository: a directory with
+// objects and refs directories.
+func isGitDir(dir string) bool {
+	for _, sub := range []string{"objects", refs.RefsDir} {
+		info, err := os.Stat(filepath.Join(dir, sub))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// GitDir returns the directory holding the repository, or "" when it is in
+// memory.
+func (r *Repository) GitDir() string {
+	return r.gitDir
+}
+
+// WorkTree returns the root of the working tree, or "" when there is none.
+func (r *Repository) WorkTree() string {
+	return r.workTree
+}
+
+// IsBare reports whether the repository has no working tree.
+func (r *Repository) IsBare() bool {
+	return r.workTree == ""
+}
+
+// ObjectFormat returns the hash algorithm naming the repository's objects.
+func (r *Repository) ObjectFormat() *HashAlgorithm {
+	return r.algo
+}
+
+// Config returns the repository's config as it was when the repository was
+// opened. Changes take effect on disk once saved.
+func (r *Repository) Config() *Config {
+	return r.config
+}
+
+// Objects returns the object store.
+func (r *Repository) Objects() ObjectStore {
+	return r.objects
+}
+
+// Database returns the object database on disk, or ErrNoDatabase when the
+// repository is in memory.
+func (r *Repository) Database() (*Database, error) {
+	if r.db == nil {
+		return nil, ErrNoDatabase
+	}
+	return r.db, nil
+}
+
+// Refs returns the ref store.
+func (r *Repository) Refs() RefStore {
+	return r.refs
+}
+
+// ReadIndex loads the index for reading.
+func (r *Repository) ReadIndex() (*Index, error) {
+	idx := index.NewWithStore(r.index, r.algo)
+	if err := idx.Load(); err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+	return idx, nil
+}
+
+// LockIndex locks and loads the index for updating. The caller must call
+// WriteUpdates or Release on the result.
+func (r *Repository) LockIndex() (*Index, error) {
+	idx := index.NewWithStore(r.index, r.algo)
+	if err := idx.LoadForUpdate(); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// workspace returns the working tree, failing when there is none.
+func (r *Repository) workspace() (*workspace.Workspace, error) {
+	if r.workTree == "" {
+		return nil, ErrNoWorkTree
+	}
+	return workspace.New(r.workTree), nil
+}
+
+// Head returns the commit HEAD points at, or the zero ID when the current
+// branch has no commits yet.
+func (r *Repository) Head() (ObjectID, error) {
+	oid, err := r.refs.ReadHead()
+	if err != nil {
+		return ObjectID{}, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	return oid, nil
+}
+
+// CurrentBranch returns the name of the branch HEAD points at, such as
+// "main", or "" when HEAD is detached.
+func (r *Repository) CurrentBranch() (string, error) {
+	target, err := r.refs.ReadSymref(refs.HeadRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	branch, ok := strings.CutPrefix(target, refs.HeadsPrefix)
+	if !ok {
+		return "", nil
+	}
+	return branch, nil
+}
+
+// Resolve returns the object a revision expression such as "HEAD~2",
+// "v1.0^{tree}" or an abbreviated object ID names.
+func (r *Repository) Resolve(rev string) (ObjectID, error) {
+	return revision.New(r.refs, r.objects, rev).Resolve()
+}
+
+// ResolveCommit is like Resolve but requires the result to be a commit.
+func (r *Repository) ResolveCommit(rev string) (ObjectID, error) {
+	return revision.New(r.refs, r.objects, rev).ResolveCommit()
+}
+
+// Ignore returns the exclude rules git uses with --exclude-standard:
+// core.excludesFile, then .git/info/exclude, then the .gitignore files in
+// the working tree, each taking precedence over the last.
+func (r *Repository) Ignore() (*Ignore, error) {
+	ignore := workspace.NewIgnore(r.workTree)
+
+	excludesFile, ok := r.config.Get("core.excludesFile")
+	if !ok {
+		if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+			excludesFile = filepath.Join(xdg, "git", "ignore")
+		} else if home, err := os.UserHomeDir(); err == nil {
+			excludesFile = filepath.Join(home, ".config", "git", "ignore")
+		}
+	} else if strings.HasPrefix(excludesFile, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			excludesFile = filepath.Join(home, excludesFile[2:])
+		}
+	}
+
+	if excludesFile != "" {
+		if err := ignore.AddExcludeFile(excludesFile); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", excludesFile, err)
+		}
+	}
+	if r.gitDir != "" {
+		if err := ignore.AddExcludeFile(filepath.Join(r.gitDir, "info", "exclude")); err != nil {
+			return nil, fmt.Errorf("failed to read info/exclude: %w", err)
+		}
+	}
+
+	return ignore, nil
+}
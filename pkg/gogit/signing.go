@@ -0,0 +1,155 @@
+package gogit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shanmugharajk/gogit/internal/commit"
+	"github.com/shanmugharajk/gogit/internal/sshsig"
+	"github.com/shanmugharajk/gogit/internal/tag"
+)
+
+// signatureNamespace is the SSH signature namespace git signs objects in.
+const signatureNamespace = "git"
+
+// ErrNoSignature is returned when verifying an object that is not signed.
+var ErrNoSignature = errors.New("no signature found")
+
+// Signer signs the payload of a commit or tag, returning the armored
+// signature.
+type Signer interface {
+	Sign(payload []byte) (string, error)
+}
+
+// sshSigner signs with an SSH key, as git does with gpg.format=ssh.
+type sshSigner struct {
+	key *sshsig.PrivateKey
+}
+
+var _ Signer = (*sshSigner)(nil)
+
+// NewSSHSigner returns a Signer using the unencrypted ed25519 or RSA
+// private key at path. As with user.signingKey, path may name the public
+// key, in which case the private key is expected beside it.
+func NewSSHSigner(path string) (Signer, error) {
+	data, err := os.ReadFile(strings.TrimSuffix(path, ".pub"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	key, err := sshsig.ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load signing key %s: %w", path, err)
+	}
+	return &sshSigner{key: key}, nil
+}
+
+func (s *sshSigner) Sign(payload []byte) (string, error) {
+	return s.key.Sign(payload, signatureNamespace)
+}
+
+// SignatureCheck is the outcome of verifying a signature that was made
+// correctly by its key.
+type SignatureCheck struct {
+	// KeyType and Fingerprint identify the key, as ssh-keygen prints them.
+	KeyType     string
+	Fingerprint string
+	// Principal is the first identity the allowed signers file trusts the
+	// key for, or is empty when it trusts no one with the key.
+	Principal string
+
+	// Payload is the signed contents of the object, without the signature.
+	Payload []byte
+}
+
+// Trusted reports whether the key is one of the allowed signers.
+func (c *SignatureCheck) Trusted() bool {
+	return c.Principal != ""
+}
+
+// VerifyCommit checks the SSH signature of a commit against the allowed
+// signers file at allowedSigners, as of the time it was committed. A
+// signature that does not match its commit is an error; one by an untrusted
+// key is reported by the result.
+func (r *Repository) VerifyCommit(oid ObjectID, allowedSigners string) (*SignatureCheck, error) {
+	objType, data, err := r.objects.ReadObject(oid)
+	if err != nil {
+		return nil, err
+	}
+	if objType != "commit" {
+		return nil, fmt.Errorf("cannot verify a non-commit object of type %s", objType)
+	}
+	c, err := commit.Parse(data, r.algo)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, signature := commit.ExtractSignature(data, commit.SignatureHeaderFor(r.algo))
+	return verifySignature(payload, signature, allowedSigners, c.Committer.Time)
+}
+
+// VerifyTag checks the SSH signature of an annotated tag, which follows its
+// message, like VerifyCommit does for commits.
+func (r *Repository) VerifyTag(oid ObjectID, allowedSigners string) (*SignatureCheck, error) {
+	objType, data, err := r.objects.ReadObject(oid)
+	if err != nil {
+		return nil, err
+	}
+	if objType != "tag" {
+		return nil, fmt.Errorf("cannot verify a non-tag object of type %s", objType)
+	}
+	t, err := tag.Parse(data, r.algo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tags made without a tagger are checked as of now
+	at := time.Now()
+	if t.Tagger != nil {
+		at = t.Tagger.Time
+	}
+	payload, signature := tag.ExtractSignature(data)
+	return verifySignature(payload, signature, allowedSigners, at)
+}
+
+// verifySignature checks that signature was made over payload and looks
+// its key up in the allowed signers file.
+func verifySignature(payload []byte, signature string, allowedSigners string, at time.Time) (*SignatureCheck, error) {
+	if signature == "" {
+		return nil, ErrNoSignature
+	}
+	if !strings.HasPrefix(signature, sshsig.BeginMarker) {
+		return nil, errors.New("only SSH signatures can be verified")
+	}
+	if allowedSigners == "" {
+		return nil, errors.New("gpg.ssh.allowedSignersFile needs to be configured and exist for ssh signature verification")
+	}
+	data, err := os.ReadFile(allowedSigners)
+	if err != nil {
+		return nil, fmt.Errorf("gpg.ssh.allowedSignersFile needs to be configured and exist for ssh signature verification: %w", err)
+	}
+	signers, err := sshsig.ParseAllowedSigners(data)
+	if err != nil {
+		return nil, fmt.Errorf("bad allowed signers file %s: %w", allowedSigners, err)
+	}
+
+	sig, err := sshsig.ParseSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	if err := sig.Verify(payload, signatureNamespace); err != nil {
+		return nil, fmt.Errorf("signature verification failed: %w", err)
+	}
+
+	check := &SignatureCheck{
+		KeyType:     sig.PublicKey.TypeName(),
+		Fingerprint: sig.PublicKey.Fingerprint(),
+		Payload:     payload,
+	}
+	if principals := sshsig.FindPrincipals(signers, sig.PublicKey, signatureNamespace, at); len(principals) > 0 {
+		check.Principal = principals[0]
+	}
+	return check, nil
+}
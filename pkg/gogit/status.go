@@ -0,0 +1,263 @@
+package gogit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/shanmugharajk/gogit/internal/object"
+)
+
+// ChangeKind describes how a path differs between two versions.
+type ChangeKind int
+
+const (
+	// Added paths exist only in the newer version.
+	Added ChangeKind = iota
+	// Modified paths exist in both versions with different contents or mode.
+	Modified
+	// Deleted paths exist only in the older version.
+	Deleted
+)
+
+// String returns the name of the kind as git status prints it.
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "new file"
+	case Modified:
+		return "modified"
+	case Deleted:
+		return "deleted"
+	default:
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+}
+
+// Change is a path that differs between two versions.
+type Change struct {
+	Path string
+	Kind ChangeKind
+
+	// OldMode, OldOID, NewMode and NewOID describe each side of a change
+	// found by DiffTrees. They are empty for a side that does not exist.
+	OldMode string
+	OldOID  ObjectID
+	NewMode string
+	NewOID  ObjectID
+}
+
+// Status describes the working tree relative to the index and HEAD.
+type Status struct {
+	// Staged lists the differences between HEAD and the index.
+	Staged []Change
+	// Unstaged lists the differences between the index and the working
+	// tree for tracked files.
+	Unstaged []Change
+	// Untracked lists the files that are neither tracked nor ignored.
+	Untracked []string
+}
+
+// Clean reports whether there is nothing to commit and no untracked file.
+func (s *Status) Clean() bool {
+	return len(s.Staged) == 0 && len(s.Unstaged) == 0 && len(s.Untracked) == 0
+}
+
+// Status compares HEAD, the index and the working tree. All paths are
+// sorted.
+func (r *Repository) Status() (*Status, error) {
+	idx, err := r.ReadIndex()
+	if err != nil {
+		return nil, err
+	}
+	headFiles, err := r.HeadFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{}
+	for _, entry := range idx.Entries() {
+		if head, ok := headFiles[entry.Path]; !ok {
+			status.Staged = append(status.Staged, Change{Path: entry.Path, Kind: Added})
+		} else if IndexDiffersFromTree(head, entry) {
+			status.Staged = append(status.Staged, Change{Path: entry.Path, Kind: Modified})
+		}
+
+		modified, exists, err := r.WorkTreeModified(entry)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case !exists:
+			status.Unstaged = append(status.Unstaged, Change{Path: entry.Path, Kind: Deleted})
+		case modified:
+			status.Unstaged = append(status.Unstaged, Change{Path: entry.Path, Kind: Modified})
+		}
+	}
+	for name := range headFiles {
+		if _, ok := idx.Entry(name); !ok {
+			status.Staged = append(status.Staged, Change{Path: name, Kind: Deleted})
+		}
+	}
+	sort.Slice(status.Staged, func(i, j int) bool {
+		return status.Staged[i].Path < status.Staged[j].Path
+	})
+
+	ignore, err := r.Ignore()
+	if err != nil {
+		return nil, err
+	}
+	if status.Untracked, err = r.UntrackedFiles(idx, ignore, false); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// HeadFiles returns the files of the tree HEAD points at, keyed by path. An
+// unborn HEAD yields an empty map.
+func (r *Repository) HeadFiles() (map[string]*TreeEntry, error) {
+	headOID, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+	if headOID.IsZero() {
+		return map[string]*TreeEntry{}, nil
+	}
+
+	head, err := r.objects.LoadCommit(headOID)
+	if err != nil {
+		return nil, err
+	}
+	return r.objects.ListTree(head.TreeOID, "")
+}
+
+// WorkTreeModified reports whether the working tree file for entry differs
+// from the index. When the stat information is inconclusive the file is
+// hashed and compared by content. exists is false when the file is gone.
+func (r *Repository) WorkTreeModified(entry *IndexEntry) (modified bool, exists bool, err error) {
+	ws, err := r.workspace()
+	if err != nil {
+		return false, false, err
+	}
+
+	stat, err := ws.StatFile(entry.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+	if stat.IsDir() {
+		return true, true, nil
+	}
+
+	if !entry.StatMatch(stat) {
+		return true, true, nil
+	}
+	if entry.TimesMatch(stat) {
+		return false, true, nil
+	}
+
+	f, size, err := ws.OpenFile(entry.Path)
+	if err != nil {
+		return false, true, err
+	}
+	defer f.Close()
+
+	oid, err := r.objects.HashStream("blob", size, f)
+	if err != nil {
+		return false, true, err
+	}
+	return oid != entry.OID, true, nil
+}
+
+// IndexDiffersFromTree reports whether the staged entry differs from the
+// version of the same path in a tree, which is nil when the tree lacks it.
+func IndexDiffersFromTree(tree *TreeEntry, entry *IndexEntry) bool {
+	if tree == nil {
+		return true
+	}
+	return tree.OID != entry.OID || tree.Mode() != object.FormatMode(entry.Mode)
+}
+
+// UntrackedFiles walks the working tree for files that are not in idx,
+// sorted by path. With exclude rules, ignored files are left out, or with
+// onlyIgnored only ignored files are returned. Nested repositories are
+// listed as a single directory entry with a trailing slash.
+func (r *Repository) UntrackedFiles(idx *Index, ignore *Ignore, onlyIgnored bool) ([]string, error) {
+	ws, err := r.workspace()
+	if err != nil {
+		return nil, err
+	}
+
+	var others []string
+
+	trackedDirs := make(map[string]bool)
+	for _, entry := range idx.Entries() {
+		for dir := path.Dir(entry.Path); dir != "."; dir = path.Dir(dir) {
+			trackedDirs[dir] = true
+		}
+	}
+
+	var walk func(dir string, insideIgnored bool) error
+	walk = func(dir string, insideIgnored bool) error {
+		entries, err := ws.ListDir(dir)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if dir != "" {
+				name = dir + "/" + name
+			}
+
+			excluded := insideIgnored
+			if ignore != nil && !excluded {
+				if excluded, err = ignore.IsIgnored(name, entry.IsDir()); err != nil {
+					return err
+				}
+			}
+
+			if entry.IsDir() {
+				if trackedDirs[name] {
+					if err := walk(name, excluded); err != nil {
+						return err
+					}
+					continue
+				}
+				if ignore != nil && excluded && !onlyIgnored {
+					continue
+				}
+				if _, err := ws.StatFile(path.Join(name, GitDirName)); err == nil {
+					if excluded == onlyIgnored {
+						others = append(others, name+"/")
+					}
+					continue
+				}
+				if err := walk(name, excluded); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if _, tracked := idx.Entry(name); tracked {
+				continue
+			}
+			if ignore == nil || excluded == onlyIgnored {
+				others = append(others, name)
+			}
+		}
+		return nil
+	}
+
+	if err := walk("", false); err != nil {
+		return nil, fmt.Errorf("failed to list workspace: %w", err)
+	}
+
+	sort.Strings(others)
+	return others, nil
+}
@@ -0,0 +1,139 @@
+package gogit
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/shanmugharajk/gogit/internal/commit"
+	"github.com/shanmugharajk/gogit/internal/refs"
+	"github.com/shanmugharajk/gogit/internal/tag"
+)
+
+// TagOptions describes a tag to create.
+type TagOptions struct {
+	// Name is the name of the tag, without refs/tags/.
+	Name string
+	// Target is the object tagged. When zero, HEAD is tagged.
+	Target ObjectID
+
+	// Annotated stores a tag object recording the tagger and Message, which
+	// the ref then points at. Otherwise the tag is a lightweight ref
+	// pointing at Target itself.
+	Annotated bool
+	// Message is the message of an annotated tag, stored as given.
+	Message string
+	// Tagger defaults to GIT_COMMITTER_NAME and GIT_COMMITTER_EMAIL at the
+	// current time, falling back to the author.
+	Tagger *Signature
+	// Signer, when set, signs an annotated tag.
+	Signer Signer
+
+	// Force replaces an existing tag of the same name.
+	Force bool
+}
+
+// ErrTagExists is returned when creating a tag whose name is taken.
+type ErrTagExists struct {
+	Name string
+}
+
+func (e *ErrTagExists) Error() string {
+	return fmt.Sprintf("tag '%s' already exists", e.Name)
+}
+
+// CreateTag points refs/tags/<name> at the target, through a new tag object
+// when the tag is annotated, and returns the OID the ref points at.
+func (r *Repository) CreateTag(opts TagOptions) (ObjectID, error) {
+	if !refs.ValidShortName(refs.TagsPrefix, opts.Name) {
+		return ObjectID{}, fmt.Errorf("'%s' is not a valid tag name.", opts.Name)
+	}
+	refName := refs.TagsPrefix + opts.Name
+
+	existing, err := r.refs.ReadRef(refName)
+	if err != nil {
+		return ObjectID{}, err
+	}
+	if !existing.IsZero() && !opts.Force {
+		return ObjectID{}, &ErrTagExists{Name: opts.Name}
+	}
+
+	target := opts.Target
+	if target.IsZero() {
+		if target, err = r.Head(); err != nil {
+			return ObjectID{}, err
+		}
+		if target.IsZero() {
+			return ObjectID{}, fmt.Errorf("failed to resolve 'HEAD' as a valid ref.")
+		}
+	}
+
+	oid := target
+	if opts.Annotated {
+		if oid, err = r.storeTag(target, opts); err != nil {
+			return ObjectID{}, err
+		}
+	}
+
+	if err := r.refs.UpdateRef(refName, oid, "tag: tagging "+target.String()); err != nil {
+		return ObjectID{}, fmt.Errorf("failed to update %s: %w", refName, err)
+	}
+	return oid, nil
+}
+
+// storeTag stores the tag object of an annotated tag pointing at target.
+func (r *Repository) storeTag(target ObjectID, opts TagOptions) (ObjectID, error) {
+	obj, err := r.objects.OpenObject(target)
+	if err != nil {
+		return ObjectID{}, err
+	}
+	objType := obj.Type
+	obj.Close()
+
+	tagger := opts.Tagger
+	if tagger == nil {
+		name, email := os.Getenv("GIT_COMMITTER_NAME"), os.Getenv("GIT_COMMITTER_EMAIL")
+		if name == "" && email == "" {
+			name, email = os.Getenv("GIT_AUTHOR_NAME"), os.Getenv("GIT_AUTHOR_EMAIL")
+		}
+		tagger = commit.NewAuthor(name, email, time.Now())
+	}
+
+	t := tag.NewTag(target, objType, opts.Name, tagger, opts.Message)
+	if opts.Signer != nil {
+		// The signature follows the message, which has to end its line
+		if n := len(t.Message); n > 0 && t.Message[n-1] != '\n' {
+			t.Message += "\n"
+		}
+		signature, err := opts.Signer.Sign(t.Bytes())
+		if err != nil {
+			return ObjectID{}, fmt.Errorf("failed to sign tag: %w", err)
+		}
+		t.Signature = signature
+	}
+	if err := r.objects.Store(t); err != nil {
+		return ObjectID{}, fmt.Errorf("failed to store tag: %w", err)
+	}
+
+	// Make the new object durable before the ref refers to it
+	if err := r.objects.Flush(); err != nil {
+		return ObjectID{}, fmt.Errorf("failed to flush objects: %w", err)
+	}
+	return t.GetOID(), nil
+}
+
+// DeleteTag removes refs/tags/<name> and returns the OID it pointed at.
+func (r *Repository) DeleteTag(name string) (ObjectID, error) {
+	refName := refs.TagsPrefix + name
+	oid, err := r.refs.ReadRef(refName)
+	if err != nil {
+		return ObjectID{}, err
+	}
+	if oid.IsZero() {
+		return ObjectID{}, fmt.Errorf("tag '%s' not found.", name)
+	}
+	if err := r.refs.DeleteRef(refName); err != nil {
+		return ObjectID{}, err
+	}
+	return oid, nil
+}
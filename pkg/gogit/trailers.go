@@ -0,0 +1,576 @@
+package gogit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultTrailerSeparators separate the key of a trailer from its value
+// unless trailer.separators says otherwise.
+const DefaultTrailerSeparators = ":"
+
+// SignedOffBy is the trailer commit --signoff adds.
+const SignedOffBy = "Signed-off-by"
+
+// gitGeneratedTrailerPrefixes start lines git writes itself, which make a
+// paragraph that is only partly trailers count as a trailer block.
+var gitGeneratedTrailerPrefixes = []string{SignedOffBy + ": ", "(cherry picked from commit "}
+
+// Trailer is a "Key: value" line in the last paragraph of a commit
+// message.
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+// TrailerWhere is where a new trailer goes.
+type TrailerWhere int
+
+const (
+	// TrailerEnd adds after every trailer.
+	TrailerEnd TrailerWhere = iota
+	// TrailerAfter adds after the last trailer with the same key, or at the
+	// end.
+	TrailerAfter
+	// TrailerBefore adds before the first trailer with the same key, or at
+	// the start.
+	TrailerBefore
+	// TrailerStart adds before every trailer.
+	TrailerStart
+)
+
+// ParseTrailerWhere parses a --where or trailer.where value.
+func ParseTrailerWhere(value string) (TrailerWhere, error) {
+	switch strings.ToLower(value) {
+	case "end":
+		return TrailerEnd, nil
+	case "after":
+		return TrailerAfter, nil
+	case "before":
+		return TrailerBefore, nil
+	case "start":
+		return TrailerStart, nil
+	default:
+		return 0, fmt.Errorf("unknown value '%s' for key 'where'", value)
+	}
+}
+
+// afterOrEnd reports whether trailers are placed after those they are
+// compared with, searching from the end.
+func (w TrailerWhere) afterOrEnd() bool {
+	return w == TrailerAfter || w == TrailerEnd
+}
+
+// TrailerIfExists is what happens to a new trailer when one with the same
+// key is already there.
+type TrailerIfExists int
+
+const (
+	// TrailerAddIfDifferentNeighbor adds unless the trailer it would be
+	// placed next to is the same.
+	TrailerAddIfDifferentNeighbor TrailerIfExists = iota
+	// TrailerAddIfDifferent adds unless the same trailer is already there.
+	TrailerAddIfDifferent
+	// TrailerAdd always adds.
+	TrailerAdd
+	// TrailerReplace replaces the trailer with the same key.
+	TrailerReplace
+	// TrailerDoNothing leaves the message as it is.
+	TrailerDoNothing
+)
+
+// ParseTrailerIfExists parses an --if-exists or trailer.ifexists value.
+func ParseTrailerIfExists(value string) (TrailerIfExists, error) {
+	switch strings.ToLower(value) {
+	case "addifdifferentneighbor":
+		return TrailerAddIfDifferentNeighbor, nil
+	case "addifdifferent":
+		return TrailerAddIfDifferent, nil
+	case "add":
+		return TrailerAdd, nil
+	case "replace":
+		return TrailerReplace, nil
+	case "donothing":
+		return TrailerDoNothing, nil
+	default:
+		return 0, fmt.Errorf("unknown value '%s' for key 'ifexists'", value)
+	}
+}
+
+// TrailerIfMissing is what happens to a new trailer when none has its key.
+type TrailerIfMissing int
+
+const (
+	// TrailerAddIfMissing adds the trailer.
+	TrailerAddIfMissing TrailerIfMissing = iota
+	// TrailerDoNothingIfMissing leaves the message as it is.
+	TrailerDoNothingIfMissing
+)
+
+// ParseTrailerIfMissing parses an --if-missing or trailer.ifmissing value.
+func ParseTrailerIfMissing(value string) (TrailerIfMissing, error) {
+	switch strings.ToLower(value) {
+	case "add":
+		return TrailerAddIfMissing, nil
+	case "donothing":
+		return TrailerDoNothingIfMissing, nil
+	default:
+		return 0, fmt.Errorf("unknown value '%s' for key 'ifmissing'", value)
+	}
+}
+
+// TrailerEdit is a trailer to add to a message and how to add it.
+type TrailerEdit struct {
+	Trailer
+	Where     TrailerWhere
+	IfExists  TrailerIfExists
+	IfMissing TrailerIfMissing
+}
+
+// ParseTrailer parses a trailer given as "key=value" or with one of
+// separators, as --trailer takes it. Without a separator the whole of arg
+// is the key.
+func ParseTrailer(arg string, separators string) (Trailer, error) {
+	pos := findTrailerSeparator(arg, separators+"=")
+	t := Trailer{Key: strings.TrimSpace(arg)}
+	if pos >= 0 {
+		t = Trailer{Key: strings.TrimSpace(arg[:pos]), Value: strings.TrimSpace(arg[pos+1:])}
+	}
+	if t.Key == "" {
+		return Trailer{}, fmt.Errorf("empty trailer token in trailer '%s'", arg)
+	}
+	return t, nil
+}
+
+// TrailerParseOptions changes how ParseTrailerBlock finds trailers.
+type TrailerParseOptions struct {
+	// Separators may separate keys from values. DefaultTrailerSeparators is
+	// used when it is empty.
+	Separators string
+	// NoDivider treats a "---" line as part of the message rather than the
+	// start of a patch, which is never searched for trailers.
+	NoDivider bool
+}
+
+// TrailerFormat changes how a TrailerBlock is written out.
+type TrailerFormat struct {
+	// OnlyTrailers writes the trailers alone, without the rest of the
+	// message or lines in the block that are not trailers.
+	OnlyTrailers bool
+	// Unfold joins values continued over several lines into one.
+	Unfold bool
+	// TrimEmpty leaves out trailers without a value.
+	TrimEmpty bool
+}
+
+// trailerItem is a line of the trailer block: a trailer, whose value may
+// span lines, or another line kept as it is when key is empty.
+type trailerItem struct {
+	key   string
+	value string
+}
+
+// TrailerBlock is a commit message split around its trailer block, found
+// as git finds it: the last paragraph, other than the title, ahead of any
+// trailing comments and patch, made entirely of trailers or, when it has a
+// line git generates such as Signed-off-by, of at least a quarter
+// trailers.
+type TrailerBlock struct {
+	before      string
+	items       []trailerItem
+	after       string
+	blankBefore bool
+	separators  string
+}
+
+// ParseTrailerBlock finds the trailers of message. opts may be nil.
+func ParseTrailerBlock(message string, opts *TrailerParseOptions) *TrailerBlock {
+	if opts == nil {
+		opts = &TrailerParseOptions{}
+	}
+	separators := opts.Separators
+	if separators == "" {
+		separators = DefaultTrailerSeparators
+	}
+
+	end := len(message)
+	if !opts.NoDivider {
+		end = findPatchStart(message)
+	}
+	end -= ignoredMessageBytes(message[:end])
+	start := findTrailerStart(message[:end], separators)
+
+	b := &TrailerBlock{
+		before:      message[:start],
+		after:       message[end:],
+		blankBefore: endsWithBlankLine(message[:start]),
+		separators:  separators,
+	}
+
+	// Lines starting with whitespace continue the trailer before them
+	var lines []string
+	continues := false
+	for _, line := range splitLines(message[start:end]) {
+		if continues && isSpace(line[0]) {
+			lines[len(lines)-1] += line
+			continue
+		}
+		lines = append(lines, line)
+		continues = findTrailerSeparator(line, separators) >= 1
+	}
+
+	for _, line := range lines {
+		if line[0] == CommentChar {
+			continue
+		}
+		if pos := findTrailerSeparator(line, separators); pos >= 1 {
+			b.items = append(b.items, trailerItem{
+				key:   strings.TrimSpace(line[:pos]),
+				value: strings.TrimSpace(line[pos+1:]),
+			})
+		} else {
+			b.items = append(b.items, trailerItem{value: strings.TrimSuffix(line, "\n")})
+		}
+	}
+	return b
+}
+
+// Trailers returns the trailers in the block, in order.
+func (b *TrailerBlock) Trailers() []Trailer {
+	var trailers []Trailer
+	for _, item := range b.items {
+		if item.key != "" {
+			trailers = append(trailers, Trailer{Key: item.key, Value: item.value})
+		}
+	}
+	return trailers
+}
+
+// Apply adds each trailer in turn, as git interpret-trailers --trailer
+// does.
+func (b *TrailerBlock) Apply(edits []TrailerEdit) {
+	for _, edit := range edits {
+		if !b.applyIfExists(edit) {
+			b.applyIfMissing(edit)
+		}
+	}
+}
+
+// applyIfExists applies edit when a trailer with its key is in the block,
+// reporting whether there was one. The search runs from the end for
+// TrailerAfter and TrailerEnd and from the start otherwise.
+func (b *TrailerBlock) applyIfExists(edit TrailerEdit) bool {
+	if len(b.items) == 0 {
+		return false
+	}
+	backwards := edit.Where.afterOrEnd()
+	middle := edit.Where == TrailerAfter || edit.Where == TrailerBefore
+	start, step := 0, 1
+	if backwards {
+		start, step = len(b.items)-1, -1
+	}
+
+	for i := start; i >= 0 && i < len(b.items); i += step {
+		if !sameTrailerKey(b.items[i].key, edit.Key) {
+			continue
+		}
+		on := start
+		if middle {
+			on = i
+		}
+
+		switch edit.IfExists {
+		case TrailerReplace:
+			if at := b.insert(on, edit); at <= i {
+				i++
+			}
+			b.items = append(b.items[:i], b.items[i+1:]...)
+		case TrailerAdd:
+			b.insert(on, edit)
+		case TrailerAddIfDifferent:
+			if b.isDifferent(i, edit, true) {
+				b.insert(on, edit)
+			}
+		case TrailerAddIfDifferentNeighbor:
+			if b.isDifferent(on, edit, false) {
+				b.insert(on, edit)
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// applyIfMissing applies edit when no trailer has its key.
+func (b *TrailerBlock) applyIfMissing(edit TrailerEdit) {
+	if edit.IfMissing == TrailerDoNothingIfMissing {
+		return
+	}
+	item := trailerItem{key: edit.Key, value: edit.Value}
+	if edit.Where.afterOrEnd() {
+		b.items = append(b.items, item)
+	} else {
+		b.items = append([]trailerItem{item}, b.items...)
+	}
+}
+
+// insert adds the trailer of edit next to the item at index on, after it
+// for TrailerAfter and TrailerEnd and before it otherwise, returning where
+// it went.
+func (b *TrailerBlock) insert(on int, edit TrailerEdit) int {
+	at := on
+	if edit.Where.afterOrEnd() {
+		at++
+	}
+	b.items = append(b.items[:at], append([]trailerItem{{key: edit.Key, value: edit.Value}}, b.items[at:]...)...)
+	return at
+}
+
+// isDifferent reports whether the item at index i differs from the trailer
+// of edit and, with all, so do the items beyond it in the direction of the
+// search.
+func (b *TrailerBlock) isDifferent(i int, edit TrailerEdit, all bool) bool {
+	step := 1
+	if edit.Where.afterOrEnd() {
+		step = -1
+	}
+	for ; i >= 0 && i < len(b.items); i += step {
+		item := b.items[i]
+		if sameTrailerKey(item.key, edit.Key) && strings.EqualFold(item.value, edit.Value) {
+			return false
+		}
+		if !all {
+			break
+		}
+	}
+	return true
+}
+
+// sameTrailerKey reports whether key names the same trailer as other. As in
+// git, only the length of the shorter is compared, ignoring case.
+func sameTrailerKey(key string, other string) bool {
+	if key == "" {
+		return false
+	}
+	n := min(len(key), len(other))
+	return strings.EqualFold(key[:n], other[:n])
+}
+
+// String returns the whole message with its trailers.
+func (b *TrailerBlock) String() string {
+	return b.Format(TrailerFormat{})
+}
+
+// Format writes out the message, or just its trailers, as f asks.
+func (b *TrailerBlock) Format(f TrailerFormat) string {
+	var out strings.Builder
+	if !f.OnlyTrailers {
+		out.WriteString(b.before)
+		if !b.blankBefore {
+			out.WriteByte('\n')
+		}
+	}
+
+	for _, item := range b.items {
+		if item.key == "" {
+			if !f.OnlyTrailers {
+				out.WriteString(item.value + "\n")
+			}
+			continue
+		}
+		value := item.value
+		if f.Unfold {
+			value = strings.Join(strings.Fields(value), " ")
+		}
+		if f.TrimEmpty && value == "" {
+			continue
+		}
+		// A key ending in a separator, as given to --trailer, keeps it
+		key := strings.TrimRight(item.key, " \t")
+		if key != "" && strings.ContainsRune(b.separators, rune(key[len(key)-1])) {
+			fmt.Fprintf(&out, "%s%s\n", item.key, value)
+		} else {
+			fmt.Fprintf(&out, "%s%c %s\n", item.key, b.separators[0], value)
+		}
+	}
+
+	if !f.OnlyTrailers {
+		out.WriteString(b.after)
+	}
+	return out.String()
+}
+
+// AddTrailers adds trailers to message with the default separators.
+func AddTrailers(message string, edits []TrailerEdit) string {
+	b := ParseTrailerBlock(message, nil)
+	b.Apply(edits)
+	return b.String()
+}
+
+// findTrailerSeparator returns the offset of the separator in a trailer
+// line, or -1 when line is not one: its key must be letters, digits and
+// dashes, optionally followed by whitespace.
+func findTrailerSeparator(line string, separators string) int {
+	whitespace := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if strings.IndexByte(separators, c) >= 0 {
+			return i
+		}
+		if !whitespace && (isAlnum(c) || c == '-') {
+			continue
+		}
+		if i > 0 && (c == ' ' || c == '\t') {
+			whitespace = true
+			continue
+		}
+		break
+	}
+	return -1
+}
+
+// findTrailerStart returns the offset of the trailer block in message, or
+// its length when there is none.
+func findTrailerStart(message string, separators string) int {
+	lines := splitLines(message)
+	offsets := make([]int, len(lines))
+	offset := 0
+	for i, line := range lines {
+		offsets[i] = offset
+		offset += len(line)
+	}
+
+	// The first paragraph is the title and cannot be trailers
+	endOfTitle := len(lines)
+	for i, line := range lines {
+		if line[0] == CommentChar {
+			continue
+		}
+		if isBlankLine(line) {
+			endOfTitle = i
+			break
+		}
+	}
+
+	onlySpaces, recognizedPrefix := true, false
+	trailerLines, nonTrailerLines, possibleContinuations := 0, 0, 0
+lines:
+	for i := len(lines) - 1; i >= endOfTitle; i-- {
+		line := lines[i]
+		if line[0] == CommentChar {
+			nonTrailerLines += possibleContinuations
+			possibleContinuations = 0
+			continue
+		}
+		if isBlankLine(line) {
+			if onlySpaces {
+				continue
+			}
+			nonTrailerLines += possibleContinuations
+			if (recognizedPrefix && trailerLines*3 >= nonTrailerLines) || (trailerLines > 0 && nonTrailerLines == 0) {
+				return offsets[i] + len(line)
+			}
+			return len(message)
+		}
+		onlySpaces = false
+
+		for _, prefix := range gitGeneratedTrailerPrefixes {
+			if strings.HasPrefix(line, prefix) {
+				trailerLines++
+				possibleContinuations = 0
+				recognizedPrefix = true
+				continue lines
+			}
+		}
+
+		switch {
+		case findTrailerSeparator(line, separators) >= 1 && !isSpace(line[0]):
+			trailerLines++
+			possibleContinuations = 0
+		case isSpace(line[0]):
+			possibleContinuations++
+		default:
+			nonTrailerLines += 1 + possibleContinuations
+			possibleContinuations = 0
+		}
+	}
+	return len(message)
+}
+
+// findPatchStart returns the offset of the "---" line dividing a message
+// from a patch, or the length of message when there is none.
+func findPatchStart(message string) int {
+	offset := 0
+	for _, line := range splitLines(message) {
+		if len(line) > 3 && strings.HasPrefix(line, "---") && isSpace(line[3]) {
+			return offset
+		}
+		offset += len(line)
+	}
+	return len(message)
+}
+
+// ignoredMessageBytes returns the length of the end of message that is
+// not part of it: everything from a scissors line, and trailing comments
+// and blank lines.
+func ignoredMessageBytes(message string) int {
+	cutoff := len(message)
+	if i := scissorsIndex(message); i >= 0 {
+		cutoff = i
+	}
+
+	commentStart := 0
+	offset := 0
+	for _, line := range splitLines(message[:cutoff]) {
+		if line[0] == CommentChar || line[0] == '\n' {
+			// The first of a run of comments
+			if commentStart == 0 {
+				commentStart = offset
+			}
+		} else {
+			commentStart = 0
+		}
+		offset += len(line)
+	}
+	if commentStart != 0 {
+		return len(message) - commentStart
+	}
+	return len(message) - cutoff
+}
+
+// splitLines splits s into lines, each keeping its newline.
+func splitLines(s string) []string {
+	return strings.SplitAfter(strings.TrimSuffix(s, "\n"), "\n")[:lineCount(s)]
+}
+
+// lineCount returns how many lines s has, counting a last line without a
+// newline.
+func lineCount(s string) int {
+	if s == "" {
+		return 0
+	}
+	n := strings.Count(s, "\n")
+	if !strings.HasSuffix(s, "\n") {
+		n++
+	}
+	return n
+}
+
+// endsWithBlankLine reports whether the last line of s is blank.
+func endsWithBlankLine(s string) bool {
+	lines := splitLines(s)
+	return len(lines) > 0 && isBlankLine(lines[len(lines)-1])
+}
+
+// isBlankLine reports whether line holds only whitespace.
+func isBlankLine(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+func isAlnum(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
@@ -0,0 +1,93 @@
+package gogit
+
+import (
+	"time"
+
+	"github.com/shanmugharajk/gogit/internal/commit"
+	"github.com/shanmugharajk/gogit/internal/config"
+	"github.com/shanmugharajk/gogit/internal/hashalgo"
+	"github.com/shanmugharajk/gogit/internal/index"
+	"github.com/shanmugharajk/gogit/internal/object"
+	"github.com/shanmugharajk/gogit/internal/refs"
+	"github.com/shanmugharajk/gogit/internal/storage"
+	"github.com/shanmugharajk/gogit/internal/tag"
+	"github.com/shanmugharajk/gogit/internal/workspace"
+)
+
+// The types below are the library's view of the packages it is built on.
+type (
+	// ObjectID names an object by the hash of its contents.
+	ObjectID = object.ObjectID
+	// Object is any object that can be stored: a blob, tree, commit or tag.
+	Object = object.Object
+	// Blob holds the contents of a file.
+	Blob = object.Blob
+	// Tree lists the entries of a directory.
+	Tree = object.Tree
+	// TreeEntry is a file, subtree or submodule within a tree.
+	TreeEntry = object.StoredEntry
+	// Commit records a tree together with its parents, author and message.
+	Commit = commit.Commit
+	// Signature identifies the author or committer of a commit and when
+	// they acted.
+	Signature = commit.Author
+	// Tag is an annotated tag, naming another object along with a message.
+	Tag = tag.Tag
+
+	// HashAlgorithm is the object format of a repository.
+	HashAlgorithm = hashalgo.Algorithm
+
+	// ObjectStore reads and writes objects.
+	ObjectStore = storage.ObjectStore
+	// Database is the ObjectStore of a repository on disk, which adds packs,
+	// alternates and pruning.
+	Database = storage.Database
+	// ObjectReader streams the contents of a stored object.
+	ObjectReader = storage.ObjectReader
+	// ObjectNotFoundError reports that no object with an OID is stored.
+	ObjectNotFoundError = storage.ObjectNotFoundError
+
+	// RefStore reads and updates references.
+	RefStore = refs.Refs
+	// Ref is a named reference together with the object it points at.
+	Ref = refs.Ref
+	// LogEntry is one reflog record.
+	LogEntry = refs.LogEntry
+
+	// Index is the staging area.
+	Index = index.Index
+	// IndexEntry is a file staged in the Index.
+	IndexEntry = index.Entry
+	// IndexStore holds the encoded Index.
+	IndexStore = index.Store
+
+	// Config holds the settings of a repository.
+	Config = config.Config
+
+	// Ignore decides which untracked files are ignored.
+	Ignore = workspace.Ignore
+)
+
+var (
+	// SHA1 is the default object format.
+	SHA1 = hashalgo.SHA1
+	// SHA256 is the SHA-256 object format.
+	SHA256 = hashalgo.SHA256
+)
+
+const (
+	// RefsFormatFiles stores refs as loose and packed files.
+	RefsFormatFiles = refs.StorageFiles
+	// RefsFormatReftable stores refs in reftable stacks.
+	RefsFormatReftable = refs.StorageReftable
+)
+
+// ParseObjectID parses the hex form of an object ID of algo.
+func ParseObjectID(hex string, algo *HashAlgorithm) (ObjectID, error) {
+	return object.ParseOID(hex, algo)
+}
+
+// NewSignature returns a Signature for name and email at the given time.
+func NewSignature(name string, email string, when time.Time) *Signature {
+	return commit.NewAuthor(name, email, when)
+}